@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// Denylist keeps the ids of the revoked sessions until their access tokens
+// expire, so that logging out works without looking up every token. It is
+// kept in memory, each server process only knows the sessions revoked
+// through it.
+type Denylist struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewDenylist returns an empty Denylist.
+func NewDenylist() *Denylist {
+	return &Denylist{revoked: make(map[string]time.Time)}
+}
+
+// Revoke denies the tokens of the session until the given time. Entries
+// past their time are removed.
+func (d *Denylist) Revoke(sessionID string, until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for id, t := range d.revoked {
+		if !t.After(now) {
+			delete(d.revoked, id)
+		}
+	}
+	if t, ok := d.revoked[sessionID]; !ok || until.After(t) {
+		d.revoked[sessionID] = until
+	}
+}
+
+// IsRevoked returns true if the tokens of the session are denied at the
+// given time.
+func (d *Denylist) IsRevoked(sessionID string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	until, ok := d.revoked[sessionID]
+	return ok && until.After(now)
+}
+
+// Len returns the number of sessions in the denylist.
+func (d *Denylist) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.revoked)
+}
@@ -0,0 +1,140 @@
+// Package jwt signs and verifies the JSON Web Tokens used as access tokens.
+// Only HS256 is supported. Tokens are signed with the current key and
+// verified with the key named by their kid header, so that keys can be
+// rotated without logging out users.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Algorithm is the only signing algorithm supported.
+const Algorithm = "HS256"
+
+// MinKeyLength is the minimum length of the secret keys in bytes.
+const MinKeyLength = 32
+
+var (
+	ErrMalformed        = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("token expired")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Claims are the claims in the access tokens. SessionID is the id of the
+// auth token the access token is issued for.
+type Claims struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// Signer signs tokens with the current key and verifies them with any of
+// its keys.
+type Signer struct {
+	keys   map[string][]byte
+	keyID  string
+	header string
+}
+
+// NewSigner returns a Signer with the given secrets by their key id. New
+// tokens are signed with the key of keyID.
+func NewSigner(keys map[string]string, keyID string) (*Signer, error) {
+	signer := &Signer{keys: make(map[string][]byte), keyID: keyID}
+	for id, secret := range keys {
+		if len(secret) < MinKeyLength {
+			return nil, errors.New("jwt: key " + id + " is shorter than 32 bytes")
+		}
+		signer.keys[id] = []byte(secret)
+	}
+	if _, ok := signer.keys[keyID]; !ok {
+		return nil, errors.New("jwt: no key with id " + keyID)
+	}
+	data, err := json.Marshal(header{Algorithm: Algorithm, KeyID: keyID, Type: "JWT"})
+	if err != nil {
+		return nil, err
+	}
+	signer.header = encoding.EncodeToString(data)
+	return signer, nil
+}
+
+// Sign returns the signed token with the claims.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := s.header + "." + encoding.EncodeToString(data)
+	return unsigned + "." + sign(s.keys[s.keyID], unsigned), nil
+}
+
+// Verify checks the signature and expiry of the token at the given time and
+// returns its claims.
+func (s *Signer) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decode(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Algorithm != Algorithm {
+		return nil, ErrUnsupportedAlg
+	}
+	key, ok := s.keys[h.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	expected := sign(key, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, ErrInvalidSignature
+	}
+	claims := new(Claims)
+	if err := decode(parts[1], claims); err != nil {
+		return nil, err
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrExpired
+	}
+	return claims, nil
+}
+
+// IsJWT returns true if the token looks like a JWT rather than an opaque key.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func sign(key []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return encoding.EncodeToString(mac.Sum(nil))
+}
+
+func decode(part string, v interface{}) error {
+	data, err := encoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
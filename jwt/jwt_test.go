@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	oldSecret = "0123456789abcdef0123456789abcdef"
+	newSecret = "fedcba9876543210fedcba9876543210"
+)
+
+func TestSignVerify(t *testing.T) {
+	signer, err := NewSigner(map[string]string{"k1": oldSecret}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1546300800, 0)
+	claims := Claims{Subject: "u1", SessionID: "s1", ID: "j1", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	token, err := signer.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, IsJWT(token))
+	assert.False(t, IsJWT("d2f1c3b4a5"))
+
+	verified, err := signer.Verify(token, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, claims, *verified)
+	}
+	_, err = signer.Verify(token, now.Add(time.Minute))
+	assert.Equal(t, ErrExpired, err)
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u2","sid":"s1","exp":1999999999}`)) + "." + parts[2]
+	_, err = signer.Verify(tampered, now)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"k1"}`)) + "." + parts[1] + "."
+	_, err = signer.Verify(none, now)
+	assert.Equal(t, ErrUnsupportedAlg, err)
+
+	_, err = signer.Verify("a.b", now)
+	assert.Equal(t, ErrMalformed, err)
+	_, err = signer.Verify("!.!.!", now)
+	assert.Equal(t, ErrMalformed, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Unix(1546300800, 0)
+	claims := Claims{Subject: "u1", SessionID: "s1", ExpiresAt: now.Add(time.Minute).Unix()}
+	old, err := NewSigner(map[string]string{"k1": oldSecret}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := old.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens signed with the old key are accepted while it is kept.
+	rotated, err := NewSigner(map[string]string{"k1": oldSecret, "k2": newSecret}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rotated.Verify(oldToken, now)
+	assert.NoError(t, err)
+	newToken, err := rotated.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Verify(newToken, now)
+	assert.Equal(t, ErrUnknownKey, err)
+
+	removed, err := NewSigner(map[string]string{"k2": newSecret}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = removed.Verify(oldToken, now)
+	assert.Equal(t, ErrUnknownKey, err)
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner(map[string]string{"k1": "short"}, "k1")
+	assert.Error(t, err)
+	_, err = NewSigner(map[string]string{"k1": oldSecret}, "k2")
+	assert.Error(t, err)
+}
+
+func TestDenylist(t *testing.T) {
+	denylist := NewDenylist()
+	now := time.Now()
+	denylist.Revoke("s1", now.Add(time.Minute))
+	assert.True(t, denylist.IsRevoked("s1", now))
+	assert.False(t, denylist.IsRevoked("s1", now.Add(time.Minute)))
+	assert.False(t, denylist.IsRevoked("s2", now))
+
+	// Expired entries are removed on the next revoke.
+	denylist.Revoke("s2", now.Add(-time.Second))
+	denylist.Revoke("s3", now.Add(time.Minute))
+	assert.Equal(t, 2, denylist.Len())
+}
@@ -0,0 +1,99 @@
+// Package mail sends the emails of the server, like password reset tokens,
+// through a pluggable Mailer.
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/smtp"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Bytes returns the message in RFC 5322 format with the given sender. Line
+// breaks in the header values are removed.
+func (msg Message) Bytes(from string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.Replace(msg.Body, "\n", "\r\n", -1))
+	return b.Bytes()
+}
+
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+func headerValue(value string) string {
+	return headerReplacer.Replace(value)
+}
+
+// SMTPMailer sends emails through an SMTP server at Addr (host:port). PLAIN
+// authentication is used when Username is set.
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send sends the message through the SMTP server.
+func (m SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, msg.Bytes(m.From, time.Now()))
+}
+
+// LogMailer writes the emails to the log instead of sending them. It is
+// meant for development.
+type LogMailer struct {
+	From string
+}
+
+// Send logs the message.
+func (m LogMailer) Send(msg Message) error {
+	log.Printf("Email to %s\n%s", msg.To, msg.Bytes(m.From, time.Now()))
+	return nil
+}
+
+// FileMailer writes each email to a file in Dir instead of sending it. It is
+// meant for development and tests.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// Send writes the message to a .eml file named after the time and the
+// recipient.
+func (m FileMailer) Send(msg Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return ioutil.WriteFile(filepath.Join(m.Dir, name), msg.Bytes(m.From, now), 0600)
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "Hello", Body: "Line 1\nLine 2"}
+	date := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := "From: wolff@example.com\r\n" +
+		"To: user@example.comBcc: other@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"Date: Wed, 02 Jan 2019 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+		"Line 1\r\nLine 2"
+	assert.Equal(t, expected, string(msg.Bytes("wolff@example.com", date)))
+}
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wolff-mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mailer := FileMailer{Dir: dir, From: "wolff@example.com"}
+	if err := mailer.Send(Message{To: "user@example.com", Subject: "Hello", Body: "Token"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*-user@example.com.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, files, 1) {
+		data, err := ioutil.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.HasSuffix(string(data), "\r\n\r\nToken"))
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// AccountBalance is the balance of an account at the end of Date, made up of
+// the opening balance and the transactions since the opening date. All the
+// amounts are in the account currency.
+type AccountBalance struct {
+	AccountID      string    `json:"account_id"`
+	Date           time.Time `json:"date"`
+	Currency       string    `json:"currency"`
+	OpeningBalance Money     `json:"opening_balance"`
+	Income         Money     `json:"income"`
+	Expense        Money     `json:"expense"`
+	TransfersIn    Money     `json:"transfers_in"`
+	TransfersOut   Money     `json:"transfers_out"`
+	Balance        Money     `json:"balance"`
+	// Unconverted is the number of transactions in a currency other than
+	// the account currency, which are left out of the balance.
+	Unconverted int `json:"unconverted"`
+}
+
+// NewAccountBalance returns the balance of the account on the given day
+// with the opening balance and no transactions yet.
+func NewAccountBalance(account *ExpenseAccount, day time.Time) *AccountBalance {
+	return &AccountBalance{
+		AccountID:      account.ID,
+		Date:           TruncateDate(day),
+		Currency:       account.Currency,
+		OpeningBalance: NewMoney(account.OpeningBalance.Amount, account.Currency),
+	}
+}
+
+// Total sets the currency of the amounts and calculates Balance from the
+// opening balance and the totals of the transactions.
+func (b *AccountBalance) Total() {
+	for _, amount := range []*Money{&b.OpeningBalance, &b.Income, &b.Expense, &b.TransfersIn, &b.TransfersOut} {
+		amount.Currency = b.Currency
+	}
+	total := b.OpeningBalance.Amount + b.Income.Amount - b.Expense.Amount + b.TransfersIn.Amount - b.TransfersOut.Amount
+	b.Balance = NewMoney(total, b.Currency)
+}
+
+// Reconciliation is the result of comparing the balance of an account with
+// the balance in its statement. Discrepancy is the statement balance minus
+// the computed balance, so it is zero when the account is in sync.
+type Reconciliation struct {
+	AccountID        string    `json:"account_id"`
+	Date             time.Time `json:"date"`
+	StatementBalance Money     `json:"statement_balance"`
+	Balance          Money     `json:"balance"`
+	Discrepancy      Money     `json:"discrepancy"`
+	Reconciled       bool      `json:"reconciled"`
+	// Count is the number of expenses marked as reconciled.
+	Count int `json:"count"`
+}
+
+// Reconcile compares the balance with the statement balance of the account.
+func (b AccountBalance) Reconcile(statementBalance Money) Reconciliation {
+	statementBalance.Currency = b.Currency
+	return Reconciliation{
+		AccountID:        b.AccountID,
+		Date:             b.Date,
+		StatementBalance: statementBalance,
+		Balance:          b.Balance,
+		Discrepancy:      NewMoney(statementBalance.Amount-b.Balance.Amount, b.Currency),
+		Reconciled:       statementBalance.Amount == b.Balance.Amount,
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountBalance(t *testing.T) {
+	account := &ExpenseAccount{ID: "111", Currency: "EUR", OpeningBalance: NewMoney(100000, "")}
+	balance := NewAccountBalance(account, date(2019, 3, 10).Add(15*time.Hour))
+	assert.Equal(t, date(2019, 3, 10), balance.Date)
+
+	balance.Income = NewMoney(250000, "")
+	balance.Expense = NewMoney(42050, "")
+	balance.TransfersIn = NewMoney(5000, "")
+	balance.TransfersOut = NewMoney(150000, "")
+	balance.Total()
+	assert.Equal(t, NewMoney(162950, "EUR"), balance.Balance)
+	assert.Equal(t, "EUR", balance.Expense.Currency)
+
+	reconciliation := balance.Reconcile(NewMoney(160000, ""))
+	assert.Equal(t, NewMoney(-2950, "EUR"), reconciliation.Discrepancy)
+	assert.False(t, reconciliation.Reconciled)
+
+	reconciliation = balance.Reconcile(NewMoney(162950, ""))
+	assert.True(t, reconciliation.Reconciled)
+	assert.True(t, reconciliation.Discrepancy.IsZero())
+}
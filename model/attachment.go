@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Attachment is a file like the photo of a receipt attached to an expense.
+// Only the metadata is kept in the database, the content is kept in a blob
+// store under the ID of the attachment. Checksum is the hex encoded SHA-256
+// of the content.
+type Attachment struct {
+	ID          string    `json:"id"`
+	Expense     *Expense  `json:"-"`
+	ExpenseID   string    `json:"expense_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+	User        *User     `json:"-"`
+	UserID      string    `json:"user_id"`
+}
+
+// String return the string representation of Attachment object.
+func (a Attachment) String() string {
+	return fmt.Sprintf("Attachment<%s>", a.ID)
+}
+
+// PreSave populates ID and CreatedAt fields. Call this before saving to db.
+func (a *Attachment) PreSave() {
+	a.ID = GenerateUUID()
+	a.CreatedAt = time.Now()
+}
+
+// ToJSON returns attachment object as json
+func (a Attachment) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(a)
+	return data, err
+}
@@ -2,22 +2,109 @@ package model
 
 import "time"
 
-// AuthToken represent each token used for user authentication.
+// AccessTokenLifetime is how long the key of an auth token can be used for
+// authentication. Clients get a new key with the refresh token after it.
+const AccessTokenLifetime = 15 * time.Minute
+
+// RefreshTokenLifetime is how long a refresh token can be used. Each refresh
+// issues a new refresh token, so sessions used within it don't expire.
+const RefreshTokenLifetime = 30 * 24 * time.Hour
+
+// TokenLastUsedInterval is how often LastUsedAt of a token is updated, so
+// that every request doesn't write to the database.
+const TokenLastUsedInterval = 5 * time.Minute
+
+// DeviceNameMaxLength is the maximum length of the device name of a token.
+const DeviceNameMaxLength = 100
+
+// userAgentMaxLength is the length to which user agents are truncated.
+const userAgentMaxLength = 255
+
+// AuthToken represent each token used for user authentication. Each token is
+// a session of the user on a device, identified by ID in the APIs so that
+// the Key is never exposed. Key is short lived and replaced on each refresh,
+// RefreshExpiry is the expiry of the latest refresh token of the session.
 type AuthToken struct {
-	Key    string
-	User   *User
-	UserID string
-	Expiry time.Time
-	Active bool
+	ID            string    `json:"id"`
+	Key           string    `json:"-"`
+	User          *User     `json:"-"`
+	UserID        string    `json:"-"`
+	Expiry        time.Time `json:"expiry"`
+	RefreshExpiry time.Time `json:"refresh_expiry"`
+	Active        bool      `json:"-"`
+	DeviceName    string    `json:"device_name"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastUsedAt    time.Time `json:"last_used_at"`
+	// Current is true for the token used in the request listing sessions.
+	Current bool `json:"current" sql:"-"`
+	// Refresh is the refresh token issued along with the key. It is set
+	// only when the token is created or refreshed.
+	Refresh *RefreshToken `json:"-" sql:"-"`
+}
+
+// RefreshToken is used once to get a new key and refresh token for the
+// session. All the refresh tokens of a session form a family, using one of
+// them again means it was leaked and the whole session is revoked.
+type RefreshToken struct {
+	Key       string `sql:",pk"`
+	SessionID string
+	UserID    string
+	Used      bool `sql:",notnull,default:false"`
+	Expiry    time.Time
+	CreatedAt time.Time
+}
+
+// Device has the details of the client to which a token is issued.
+type Device struct {
+	Name      string
+	UserAgent string
+	IP        string
 }
 
 // NewAuthToken returns new AuthToken object.
-func NewAuthToken(user *User) *AuthToken {
-	return &AuthToken{Active: true, User: user, UserID: user.ID}
+func NewAuthToken(user *User, device Device) *AuthToken {
+	userAgent := device.UserAgent
+	if len(userAgent) > userAgentMaxLength {
+		userAgent = userAgent[:userAgentMaxLength]
+	}
+	return &AuthToken{
+		Active:     true,
+		User:       user,
+		UserID:     user.ID,
+		DeviceName: device.Name,
+		UserAgent:  userAgent,
+		IP:         device.IP,
+	}
 }
 
-// PreSave populates the required fields before saving.
+// PreSave populates the required fields before saving along with its
+// first refresh token.
 func (authToken *AuthToken) PreSave() {
+	authToken.ID = GenerateUUID()
+	authToken.CreatedAt = time.Now()
+	authToken.Rotate(authToken.CreatedAt)
+}
+
+// Rotate replaces the key of the token and issues a new refresh token for
+// it at the given time.
+func (authToken *AuthToken) Rotate(now time.Time) {
 	authToken.Key = GenerateTokenKey()
-	authToken.Expiry = time.Now().Add(time.Hour * 72)
+	authToken.Expiry = now.Add(AccessTokenLifetime)
+	authToken.RefreshExpiry = now.Add(RefreshTokenLifetime)
+	authToken.LastUsedAt = now
+	authToken.Refresh = &RefreshToken{
+		Key:       GenerateTokenKey(),
+		SessionID: authToken.ID,
+		UserID:    authToken.UserID,
+		Expiry:    authToken.RefreshExpiry,
+		CreatedAt: now,
+	}
+}
+
+// NeedsTouch returns true if LastUsedAt of the token is older than
+// TokenLastUsedInterval at the given time.
+func (authToken *AuthToken) NeedsTouch(now time.Time) bool {
+	return now.Sub(authToken.LastUsedAt) >= TokenLastUsedInterval
 }
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Budget is a monthly spending limit for the expenses of either a category
+// or an account. When Rollover is set, the amount left unspent in the
+// previous months since StartDate is added to the current month.
+type Budget struct {
+	ID         string           `json:"id"`
+	Category   *ExpenseCategory `json:"category" pg:",fk:category_id"`
+	CategoryID string           `json:"category_id"`
+	Account    *ExpenseAccount  `json:"account"`
+	AccountID  string           `json:"account_id"`
+	Amount     Money            `json:"amount" sql:"type:numeric"`
+	Currency   string           `json:"currency"`
+	Rollover   bool             `json:"rollover" sql:",notnull"`
+	StartDate  time.Time        `json:"start_date" sql:"type:date"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	User       *User            `json:"-"`
+	UserID     string           `json:"user_id"`
+}
+
+// String return the string representation of Budget object.
+func (b Budget) String() string {
+	return fmt.Sprintf("Budget<%s>", b.ID)
+}
+
+// PreSave populates ID, CreatedAt and UpdatedAt fields. Call this before saving to db.
+func (b *Budget) PreSave() {
+	b.ID = GenerateUUID()
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = time.Now()
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating in db.
+func (b *Budget) PreUpdate() {
+	b.UpdatedAt = time.Now()
+}
+
+// AfterSelect sets the currency of the amount after fetching from db.
+func (b *Budget) AfterSelect(db orm.DB) error {
+	b.Amount.Currency = b.Currency
+	return nil
+}
+
+// SetCurrency sets the currency of the budget and its amount.
+func (b *Budget) SetCurrency(currency string) {
+	b.Currency = currency
+	b.Amount.Currency = currency
+}
+
+// ToJSON returns budget object as json
+func (b Budget) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(b)
+	return data, err
+}
+
+// BudgetStatus is the spending against a budget in a month. Available is the
+// budget amount with the rollover from the previous months and Remaining is
+// what is left of it after the spending.
+type BudgetStatus struct {
+	Budget      Budget    `json:"budget"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Spent       Money     `json:"spent"`
+	Rollover    Money     `json:"rollover"`
+	Available   Money     `json:"available"`
+	Remaining   Money     `json:"remaining"`
+	Overspent   bool      `json:"overspent"`
+	// Unconverted is the number of expenses left out of Spent for not
+	// having an exchange rate to the budget currency.
+	Unconverted int `json:"unconverted,omitempty"`
+}
+
+// MonthStart returns the first day of the month of the given time.
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Status returns the status of the budget in the month of the given day.
+// monthlySpent is the spending in the budget currency keyed by the first day
+// of each month in ReportPeriodFormat. It should cover the months since
+// StartDate when the budget has rollover.
+func (b Budget) Status(day time.Time, monthlySpent []ReportEntry) BudgetStatus {
+	spent := map[string]ReportEntry{}
+	for _, entry := range monthlySpent {
+		spent[entry.Key] = entry
+	}
+
+	period := MonthStart(day)
+	status := BudgetStatus{
+		Budget:      b,
+		PeriodStart: period,
+		PeriodEnd:   period.AddDate(0, 1, -1),
+		Rollover:    NewMoney(0, b.Currency),
+	}
+	if b.Rollover {
+		// Unspent amounts are carried over, overspending is not.
+		for month := MonthStart(b.StartDate); month.Before(period); month = month.AddDate(0, 1, 0) {
+			entry := spent[month.Format(ReportPeriodFormat)]
+			left := status.Rollover.Amount + b.Amount.Amount - entry.Total.Amount
+			if left < 0 {
+				left = 0
+			}
+			status.Rollover.Amount = left
+			status.Unconverted += entry.Unconverted
+		}
+	}
+
+	entry := spent[period.Format(ReportPeriodFormat)]
+	status.Spent = NewMoney(entry.Total.Amount, b.Currency)
+	status.Unconverted += entry.Unconverted
+	status.Available = NewMoney(b.Amount.Amount+status.Rollover.Amount, b.Currency)
+	status.Remaining = NewMoney(status.Available.Amount-status.Spent.Amount, b.Currency)
+	status.Overspent = status.Remaining.IsNegative()
+	return status
+}
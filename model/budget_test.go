@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetStatus(t *testing.T) {
+	budget := Budget{Amount: NewMoney(10000, "EUR"), Currency: "EUR", StartDate: date(2019, 1, 1)}
+	spent := []ReportEntry{
+		{Key: "2019-01-01", Currency: "EUR", Total: NewMoney(6000, "EUR")},
+		{Key: "2019-02-01", Currency: "EUR", Total: NewMoney(15000, "EUR")},
+		{Key: "2019-04-01", Currency: "EUR", Total: NewMoney(12000, "EUR"), Unconverted: 1},
+	}
+
+	status := budget.Status(date(2019, 4, 10), spent)
+	assert.Equal(t, date(2019, 4, 1), status.PeriodStart)
+	assert.Equal(t, date(2019, 4, 30), status.PeriodEnd)
+	assert.Equal(t, NewMoney(12000, "EUR"), status.Spent)
+	assert.Equal(t, NewMoney(0, "EUR"), status.Rollover, "Rollover is used without asking.")
+	assert.Equal(t, NewMoney(-2000, "EUR"), status.Remaining)
+	assert.True(t, status.Overspent)
+	assert.Equal(t, 1, status.Unconverted)
+
+	budget.Rollover = true
+	status = budget.Status(date(2019, 4, 10), spent)
+	// 40 left in January, overspending in February is not carried and 100 left in March.
+	assert.Equal(t, NewMoney(10000, "EUR"), status.Rollover)
+	assert.Equal(t, NewMoney(20000, "EUR"), status.Available)
+	assert.Equal(t, NewMoney(8000, "EUR"), status.Remaining)
+	assert.False(t, status.Overspent)
+}
@@ -0,0 +1,189 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateDateWindow is the number of days the dates of duplicate expenses
+// can be apart, since card payments often show up a few days later.
+const DuplicateDateWindow = 3
+
+// DuplicateDismissal records that the two expenses were reviewed and are not
+// duplicates of each other. ExpenseID is always the smaller of the ids.
+type DuplicateDismissal struct {
+	ExpenseID string    `sql:",pk"`
+	OtherID   string    `sql:",pk"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewDuplicateDismissal returns the dismissal of the pair of expenses.
+func NewDuplicateDismissal(userID, expenseID, otherID string) DuplicateDismissal {
+	if otherID < expenseID {
+		expenseID, otherID = otherID, expenseID
+	}
+	return DuplicateDismissal{ExpenseID: expenseID, OtherID: otherID, UserID: userID, CreatedAt: time.Now()}
+}
+
+// DuplicateGroup is a set of expenses which seem to be the same transaction.
+type DuplicateGroup struct {
+	Expenses []Expense `json:"expenses"`
+}
+
+// IsLikelyDuplicate returns true if the two expenses seem to be the same
+// transaction: same user, account, kind and amount, dates within
+// DuplicateDateWindow days and similar titles.
+func IsLikelyDuplicate(a, b *Expense) bool {
+	if a.ID != "" && a.ID == b.ID {
+		return false
+	}
+	if a.UserID != b.UserID || a.AccountID != b.AccountID || a.Kind != b.Kind || a.Amount.Amount != b.Amount.Amount {
+		return false
+	}
+	days := TruncateDate(a.Date.UTC()).Sub(TruncateDate(b.Date.UTC())).Hours() / 24
+	if days > DuplicateDateWindow || days < -DuplicateDateWindow {
+		return false
+	}
+	return isSimilarTitle(a.Title, b.Title)
+}
+
+// GroupDuplicates groups the likely duplicates among the expenses, leaving
+// out the pairs which are dismissed. Expenses without duplicates are left
+// out. Groups are ordered by the date of their first expense.
+func GroupDuplicates(expenses []Expense, dismissals []DuplicateDismissal) []DuplicateGroup {
+	dismissed := make(map[[2]string]bool)
+	for _, d := range dismissals {
+		dismissed[[2]string{d.ExpenseID, d.OtherID}] = true
+	}
+	isDismissed := func(a, b string) bool {
+		d := NewDuplicateDismissal("", a, b)
+		return dismissed[[2]string{d.ExpenseID, d.OtherID}]
+	}
+
+	// Union find over the pairs of duplicates.
+	parent := make([]int, len(expenses))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range expenses {
+		for j := i + 1; j < len(expenses); j++ {
+			if IsLikelyDuplicate(&expenses[i], &expenses[j]) && !isDismissed(expenses[i].ID, expenses[j].ID) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]Expense)
+	var roots []int
+	for i := range expenses {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], expenses[i])
+	}
+	groups := []DuplicateGroup{}
+	for _, root := range roots {
+		group := members[root]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Date.Before(group[j].Date) })
+		groups = append(groups, DuplicateGroup{Expenses: group})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Expenses[0].Date.Before(groups[j].Expenses[0].Date)
+	})
+	return groups
+}
+
+// Merge copies the details of the duplicate into the expense which is kept.
+// Tags of the duplicate are added and its category is used if the expense
+// doesn't have one.
+func (e *Expense) Merge(duplicate *Expense) {
+	if e.CategoryID == "" && duplicate.CategoryID != "" {
+		e.CategoryID = duplicate.CategoryID
+		e.Category = duplicate.Category
+	}
+	if e.Title == "" {
+		e.Title = duplicate.Title
+	}
+	e.Reconciled = e.Reconciled || duplicate.Reconciled
+	for _, tag := range duplicate.Tags {
+		if !e.HasTag(tag.Name) {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+}
+
+// isSimilarTitle returns true if the titles are the same after ignoring the
+// case, spaces and punctuation, if one of them contains the other, or if
+// they differ by at most a fifth of their characters.
+func isSimilarTitle(a, b string) bool {
+	a, b = normalizeTitle(a), normalizeTitle(b)
+	if a == b {
+		return true
+	}
+	if a == "" || b == "" {
+		return false
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+	return editDistance(a, b)*5 <= longest
+}
+
+func normalizeTitle(title string) string {
+	return strings.Map(func(c rune) rune {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			return unicode.ToLower(c)
+		}
+		return -1
+	}, title)
+}
+
+// editDistance returns the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLikelyDuplicate(t *testing.T) {
+	date := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+	expense := &Expense{ID: "1", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Starbucks Coffee"}
+	cases := []struct {
+		other     Expense
+		duplicate bool
+	}{
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "starbucks coffee!"}, true},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date.AddDate(0, 0, 3), Title: "STARBUCKS"}, true},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Starbuck Cofee"}, true},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date.AddDate(0, 0, 4), Title: "Starbucks Coffee"}, false},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Grocery store"}, false},
+		{Expense{ID: "2", UserID: "u", AccountID: "112", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Starbucks Coffee"}, false},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1251, "EUR"), Date: date, Title: "Starbucks Coffee"}, false},
+		{Expense{ID: "2", UserID: "u", AccountID: "111", Kind: KindIncome, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Starbucks Coffee"}, false},
+		{Expense{ID: "2", UserID: "v", AccountID: "111", Kind: KindExpense, Amount: NewMoney(1250, "EUR"), Date: date, Title: "Starbucks Coffee"}, false},
+		{*expense, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.duplicate, IsLikelyDuplicate(expense, &tc.other), "%+v", tc.other)
+	}
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	newExpense := func(id string, days int, title string) Expense {
+		return Expense{ID: id, UserID: "u", AccountID: "111", Amount: NewMoney(1000, ""), Date: date.AddDate(0, 0, days), Title: title}
+	}
+	expenses := []Expense{
+		newExpense("4", 10, "Taxi"),
+		newExpense("1", 1, "Lunch"),
+		newExpense("2", 0, "lunch"),
+		newExpense("3", 0, "Books"),
+		newExpense("5", 11, "taxi"),
+		newExpense("6", 12, "Taxi"),
+	}
+	groups := GroupDuplicates(expenses, []DuplicateDismissal{NewDuplicateDismissal("u", "6", "4"), NewDuplicateDismissal("u", "6", "5")})
+	ids := [][]string{}
+	for _, group := range groups {
+		groupIDs := []string{}
+		for _, expense := range group.Expenses {
+			groupIDs = append(groupIDs, expense.ID)
+		}
+		ids = append(ids, groupIDs)
+	}
+	assert.Equal(t, [][]string{{"2", "1"}, {"4", "5"}}, ids)
+}
+
+func TestExpenseMerge(t *testing.T) {
+	expense := &Expense{Title: "Lunch", Tags: []Tag{{Name: "work"}}}
+	duplicate := &Expense{Title: "LUNCH", CategoryID: "121", Reconciled: true, Tags: []Tag{{Name: "work"}, {Name: "team"}}}
+	expense.Merge(duplicate)
+	assert.Equal(t, "Lunch", expense.Title)
+	assert.Equal(t, "121", expense.CategoryID)
+	assert.True(t, expense.Reconciled)
+	assert.Equal(t, 2, len(expense.Tags))
+
+	expense = &Expense{CategoryID: "123"}
+	expense.Merge(duplicate)
+	assert.Equal(t, "123", expense.CategoryID, "Category of the kept expense is replaced.")
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExchangeRateDateFormat is the date format used in exchange rate csv files.
+const ExchangeRateDateFormat = "2006-01-02"
+
+// ExchangeRate keeps the rate for converting one unit of FromCurrency into
+// ToCurrency on the given date.
+type ExchangeRate struct {
+	Date         time.Time `json:"date" sql:"type:date,pk"`
+	FromCurrency string    `json:"from_currency" sql:",pk"`
+	ToCurrency   string    `json:"to_currency" sql:",pk"`
+	Rate         float64   `json:"rate"`
+}
+
+func (r ExchangeRate) String() string {
+	return fmt.Sprintf("ExchangeRate<%s/%s %s>", r.FromCurrency, r.ToCurrency, r.Date.Format(ExchangeRateDateFormat))
+}
+
+// IsValidCurrency returns true if the given code looks like an ISO 4217
+// currency code, ie three upper case letters.
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseExchangeRatesCSV reads exchange rates from csv data with the columns
+// date, from_currency, to_currency and rate. A header row is skipped if present.
+// If a pair of currencies is repeated for a date the last rate is used.
+func ParseExchangeRatesCSV(r io.Reader) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []ExchangeRate
+	seen := map[string]int{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		date, err := time.Parse(ExchangeRateDateFormat, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, record[0])
+		}
+		from, to := strings.ToUpper(record[1]), strings.ToUpper(record[2])
+		if !IsValidCurrency(from) || !IsValidCurrency(to) {
+			return nil, fmt.Errorf("line %d: invalid currency", line)
+		}
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+		rate := ExchangeRate{Date: date, FromCurrency: from, ToCurrency: to, Rate: value}
+		key := date.Format(ExchangeRateDateFormat) + from + to
+		if i, ok := seen[key]; ok {
+			rates[i] = rate
+			continue
+		}
+		seen[key] = len(rates)
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidCurrency(t *testing.T) {
+	assert.True(t, IsValidCurrency("EUR"))
+	assert.False(t, IsValidCurrency("eur"))
+	assert.False(t, IsValidCurrency("EURO"))
+	assert.False(t, IsValidCurrency(""))
+}
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	data := `date,from_currency,to_currency,rate
+2019-01-02,EUR,INR,79.52
+2019-01-02, usd, inr, 69.88
+`
+	rates, err := ParseExchangeRatesCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(rates))
+	assert.Equal(t, ExchangeRate{
+		Date:         time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		FromCurrency: "USD",
+		ToCurrency:   "INR",
+		Rate:         69.88,
+	}, rates[1])
+
+	// The last rate is used for a repeated pair of currencies on a date.
+	data = `2019-01-02,EUR,INR,79.52
+2019-01-03,EUR,INR,79.60
+2019-01-02,eur,inr,79.55
+`
+	rates, err = ParseExchangeRatesCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(rates))
+	assert.Equal(t, 79.55, rates[0].Rate)
+	assert.Equal(t, 79.60, rates[1].Rate)
+
+	invalid := []string{
+		"2019-01-02,EUR,INR",
+		"02-01-2019,EUR,INR,79.52",
+		"2019-01-02,EURO,INR,79.52",
+		"2019-01-02,EUR,INR,-1",
+	}
+	for _, data := range invalid {
+		_, err = ParseExchangeRatesCSV(strings.NewReader(data))
+		assert.NotNil(t, err, "No error for %q", data)
+	}
+}
@@ -4,9 +4,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Kinds of the transactions kept as Expense. Amount is always positive and
+// the kind tells whether the money was spent or received.
+const (
+	KindExpense = "expense"
+	KindIncome  = "income"
 )
 
-// Expense represent each expense data created by an user.
+// IsValidKind returns true if the given kind is one of the transaction kinds.
+func IsValidKind(kind string) bool {
+	return kind == KindExpense || kind == KindIncome
+}
+
+// Expense represent each expense data created by an user. Income like salary
+// or refunds is kept as an Expense of KindIncome. Tags are saved along with
+// the expense, tags without an ID are looked up or created by their name.
 type Expense struct {
 	ID         string           `json:"id"`
 	Account    *ExpenseAccount  `json:"account"`
@@ -16,10 +32,21 @@ type Expense struct {
 	UpdatedAt  time.Time        `json:"updated_at"`
 	Category   *ExpenseCategory `json:"category" pg:",fk:category_id"`
 	CategoryID string           `json:"category_id"`
-	Amount     float64          `json:"amount"`
+	Amount     Money            `json:"amount" sql:"type:numeric"`
+	Currency   string           `json:"currency"`
 	User       *User            `json:"user"`
 	UserID     string           `json:"user_id"`
 	Title      string           `json:"title"`
+	Kind       string           `json:"kind" sql:",notnull,default:'expense'"`
+	Reconciled bool             `json:"reconciled" sql:",notnull,default:false"`
+	Tags       []Tag            `json:"tags" pg:",many2many:expense_tags"`
+	// ConvertedAmount is the amount in user's base currency. It is populated
+	// only when conversion is asked for and an exchange rate is available.
+	ConvertedAmount *Money `json:"converted_amount,omitempty" sql:"-"`
+	// PossibleDuplicates has the ids of existing expenses which seem to be
+	// the same transaction. It is populated only when creating an expense
+	// with the duplicate check.
+	PossibleDuplicates []string `json:"possible_duplicates,omitempty" sql:"-"`
 }
 
 // String return the string representation of Expense object.
@@ -27,11 +54,43 @@ func (e Expense) String() string {
 	return fmt.Sprintf("Expense<%s>", e.ID)
 }
 
-// PreSave popualtes ID, CreateAt and UpdatedAt fields. Call this before saving to db.
+// PreSave popualtes ID, CreateAt and UpdatedAt fields and defaults the kind
+// to KindExpense. Call this before saving to db.
 func (e *Expense) PreSave() {
 	e.ID = GenerateUUID()
 	e.CreatedAt = time.Now()
 	e.UpdatedAt = time.Now()
+	if e.Kind == "" {
+		e.Kind = KindExpense
+	}
+}
+
+// AfterSelect sets the currency of the amount after fetching from db.
+func (e *Expense) AfterSelect(db orm.DB) error {
+	e.Amount.Currency = e.Currency
+	return nil
+}
+
+// SetCurrency sets the currency of the expense and its amount.
+func (e *Expense) SetCurrency(currency string) {
+	e.Currency = currency
+	e.Amount.Currency = currency
+}
+
+// HasTag returns true if the expense has a tag with the given name.
+func (e *Expense) HasTag(name string) bool {
+	name = NormalizeTagName(name)
+	for _, tag := range e.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating the expense in db.
+func (e *Expense) PreUpdate() {
+	e.UpdatedAt = time.Now()
 }
 
 // ToJSON returns expense object as json
@@ -50,14 +109,40 @@ type ExpenseCategory struct {
 	UserID    string    `json:"user_id"`
 }
 
-// ExpenseAccount represent different expense accounts.
+func (e ExpenseCategory) String() string {
+	return fmt.Sprintf("ExpenseCategory<%s>", e.Name)
+}
+
+// PreSave populates ID, CreatedAt and UpdatedAt fields. Call this before saving to db.
+func (e *ExpenseCategory) PreSave() {
+	e.ID = GenerateUUID()
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = time.Now()
+}
+
+// ToJSON returns expense category object as json
+func (e ExpenseCategory) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(e)
+	return data, err
+}
+
+// ExpenseAccount represent different expense accounts. OpeningBalance is the
+// balance of the account on OpeningDate, transactions before it are not
+// counted in the balance. Archived accounts are hidden from the listing.
 type ExpenseAccount struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	User      *User     `json:"-"`
-	UserID    string    `json:"user_id"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Currency       string    `json:"currency"`
+	OpeningBalance Money     `json:"opening_balance" sql:"type:numeric,notnull,default:0"`
+	OpeningDate    time.Time `json:"opening_date" sql:"type:date"`
+	Archived       bool      `json:"archived" sql:",notnull,default:false"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	User           *User     `json:"-"`
+	UserID         string    `json:"user_id"`
+	// Balance is the current balance of the account. It is populated only
+	// when the account is fetched through the accounts api.
+	Balance *Money `json:"balance,omitempty" sql:"-"`
 }
 
 func (e ExpenseAccount) String() string {
@@ -68,6 +153,18 @@ func (e *ExpenseAccount) PreSave() {
 	e.ID = GenerateUUID()
 	e.CreatedAt = time.Now()
 	e.UpdatedAt = time.Now()
+	e.OpeningBalance.Currency = e.Currency
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating the account in db.
+func (e *ExpenseAccount) PreUpdate() {
+	e.UpdatedAt = time.Now()
+}
+
+// AfterSelect sets the currency of the opening balance after fetching from db.
+func (e *ExpenseAccount) AfterSelect(db orm.DB) error {
+	e.OpeningBalance.Currency = e.Currency
+	return nil
 }
 
 func (e ExpenseAccount) ToJSON() ([]byte, error) {
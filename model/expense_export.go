@@ -0,0 +1,41 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// ExpenseExportRow is an expense flattened for exporting, with the names of
+// its account, category and tags in place of the ids.
+type ExpenseExportRow struct {
+	ID           string
+	Date         time.Time
+	Kind         string
+	Title        string
+	Amount       Money `sql:"type:numeric"`
+	Currency     string
+	AccountName  string
+	CategoryName string
+	// Tags is the comma separated names of the tags.
+	Tags       string
+	Reconciled bool
+}
+
+// ExpenseExportHeader is the header row of the exported expenses.
+var ExpenseExportHeader = []string{"date", "kind", "title", "amount", "currency", "account", "category", "tags", "reconciled", "id"}
+
+// Record returns the values of the row in the order of ExpenseExportHeader.
+func (r ExpenseExportRow) Record() []string {
+	return []string{
+		r.Date.Format("2006-01-02"),
+		r.Kind,
+		r.Title,
+		r.Amount.String(),
+		r.Currency,
+		r.AccountName,
+		r.CategoryName,
+		r.Tags,
+		strconv.FormatBool(r.Reconciled),
+		r.ID,
+	}
+}
@@ -18,7 +18,7 @@ func TestExpense(t *testing.T) {
 		Name: "Category1",
 	}
 	expense := Expense{
-		Amount:     100,
+		Amount:     NewMoney(10000, ""),
 		AccountID:  eAccount.ID,
 		Account:    eAccount,
 		Category:   eCategory,
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MoneyDecimalPlaces is the number of decimal places kept in Money amounts.
+const MoneyDecimalPlaces = 2
+
+const moneyUnit = 100
+
+// ErrInvalidMoney is returned when an amount can't be parsed as Money.
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// ErrTooManyDecimals is returned when an amount has more decimal places than MoneyDecimalPlaces.
+var ErrTooManyDecimals = errors.New("money amount has too many decimal places")
+
+// ErrCurrencyMismatch is returned when doing arithmetic on amounts in different currencies.
+var ErrCurrencyMismatch = errors.New("money amounts are in different currencies")
+
+// Money represents an exact amount as integer minor units (hundredths of the
+// currency unit) along with its currency code. An empty currency means the
+// currency is not known and it is compatible with any other currency.
+//
+// Money is stored in a numeric column and is marshalled to json as a number.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns Money with the given amount in minor units.
+func NewMoney(minorUnits int64, currency string) Money {
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// ParseMoney parses a decimal string like "-12.5" into Money. Amounts with
+// more than MoneyDecimalPlaces decimal places are rejected with ErrTooManyDecimals.
+func ParseMoney(s string, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, ErrInvalidMoney
+	}
+	// Trailing zeros doesn't change the amount, so 1.500 is same as 1.50
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > MoneyDecimalPlaces {
+		return Money{}, ErrTooManyDecimals
+	}
+	fraction += strings.Repeat("0", MoneyDecimalPlaces-len(fraction))
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/moneyUnit-1 {
+		return Money{}, ErrInvalidMoney
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	amount := units*moneyUnit + cents
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the amount as a decimal string like "12.50".
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/moneyUnit, amount%moneyUnit)
+}
+
+// IsZero returns true if the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative returns true if the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) currencyWith(other Money) (string, error) {
+	if m.Currency == "" {
+		return other.Currency, nil
+	}
+	if other.Currency != "" && other.Currency != m.Currency {
+		return "", ErrCurrencyMismatch
+	}
+	return m.Currency, nil
+}
+
+// Add returns the sum of the two amounts.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns the difference of the two amounts.
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(other.Neg())
+}
+
+// Neg returns the amount with its sign flipped.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul returns the amount multiplied by n.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Cmp compares the amounts and returns -1, 0 or +1.
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	}
+	return 0
+}
+
+// Convert returns the amount converted into the given currency using rate,
+// which is the price of one unit of m's currency. Result is rounded half away
+// from zero to the nearest minor unit.
+func (m Money) Convert(rate float64, currency string) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: currency}
+}
+
+// MarshalJSON writes the amount as a json number with two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount either from a json number or a string. The
+// currency is left untouched.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer so that Money can be saved into a numeric column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner so that Money can be read from a numeric column.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		m.Amount = v * moneyUnit
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into Money", src)
+	}
+	parsed, err := ParseMoney(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int64
+		err      error
+	}{
+		{"12.34", 1234, nil},
+		{"12.3", 1230, nil},
+		{"12", 1200, nil},
+		{"-0.05", -5, nil},
+		{"+1.50", 150, nil},
+		{".5", 50, nil},
+		{"1.500", 150, nil},
+		{"1.234", 0, ErrTooManyDecimals},
+		{"1e3", 0, ErrInvalidMoney},
+		{"", 0, ErrInvalidMoney},
+		{".", 0, ErrInvalidMoney},
+		{"12.3.4", 0, ErrInvalidMoney},
+		{"99999999999999999999", 0, ErrInvalidMoney},
+	}
+	for _, tc := range cases {
+		money, err := ParseMoney(tc.input, "EUR")
+		assert.Equal(t, tc.err, err, "Parsing %q", tc.input)
+		if err == nil {
+			assert.Equal(t, Money{Amount: tc.expected, Currency: "EUR"}, money, "Parsing %q", tc.input)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "12.34", NewMoney(1234, "").String())
+	assert.Equal(t, "-0.05", NewMoney(-5, "").String())
+	assert.Equal(t, "0.00", Money{}.String())
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := NewMoney(1010, "EUR")
+	b := NewMoney(20, "EUR")
+
+	sum, err := a.Add(b)
+	assert.Nil(t, err)
+	assert.Equal(t, NewMoney(1030, "EUR"), sum)
+
+	diff, err := b.Sub(a)
+	assert.Nil(t, err)
+	assert.Equal(t, NewMoney(-990, "EUR"), diff)
+	assert.True(t, diff.IsNegative())
+
+	// Unknown currency is compatible with any currency.
+	sum, err = NewMoney(1, "").Add(a)
+	assert.Nil(t, err)
+	assert.Equal(t, NewMoney(1011, "EUR"), sum)
+
+	_, err = a.Add(NewMoney(1, "USD"))
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	assert.Equal(t, NewMoney(3030, "EUR"), a.Mul(3))
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(a))
+
+	assert.Equal(t, NewMoney(80315, "INR"), a.Convert(79.52, "INR"))
+	assert.Equal(t, NewMoney(-803, "INR"), NewMoney(-1010, "EUR").Convert(0.795, "INR"))
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1050, "EUR"))
+	assert.Nil(t, err)
+	assert.Equal(t, "10.50", string(data))
+
+	var m Money
+	assert.Nil(t, json.Unmarshal([]byte(`"10.5"`), &m))
+	assert.Equal(t, NewMoney(1050, ""), m)
+	assert.Nil(t, json.Unmarshal([]byte(`0.1`), &m))
+	assert.Equal(t, NewMoney(10, ""), m)
+	assert.Equal(t, ErrTooManyDecimals, json.Unmarshal([]byte(`0.001`), &m))
+}
+
+func TestMoneySQL(t *testing.T) {
+	value, err := NewMoney(-1234, "").Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "-12.34", value)
+
+	m := Money{Currency: "INR"}
+	assert.Nil(t, m.Scan([]byte("99.90")))
+	assert.Equal(t, NewMoney(9990, "INR"), m)
+	assert.Nil(t, m.Scan(nil))
+	assert.True(t, m.IsZero())
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// PasswordResetLifetime is how long a password reset token can be used.
+const PasswordResetLifetime = time.Hour
+
+// PasswordReset is a single use token for setting a new password without
+// the old one. Only the hash of the token is saved, the token itself is
+// sent to the user.
+type PasswordReset struct {
+	TokenHash string `sql:",pk"`
+	UserID    string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewPasswordReset returns a new PasswordReset for the user along with its
+// token.
+func NewPasswordReset(userID string, now time.Time) (*PasswordReset, string) {
+	token := GenerateTokenKey()
+	return &PasswordReset{
+		TokenHash: HashResetToken(token),
+		UserID:    userID,
+		ExpiresAt: now.Add(PasswordResetLifetime),
+		CreatedAt: now,
+	}, token
+}
+
+// HashResetToken returns the hash of the password reset token as saved in
+// PasswordReset.
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Frequencies of the recurring expenses.
+const (
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// IsValidFrequency returns true if the given frequency is one of the supported frequencies.
+func IsValidFrequency(frequency string) bool {
+	return frequency == FrequencyWeekly || frequency == FrequencyMonthly || frequency == FrequencyYearly
+}
+
+// RecurringExpense is a template from which expenses are created on a
+// schedule. Weekly expenses occur on the weekday of StartDate, monthly
+// expenses on DayOfMonth (or the last day of shorter months) and yearly
+// expenses on the day and month of StartDate.
+//
+// NextDate is the date of the next occurrence which is not yet created. The
+// dates are kept without time, at midnight UTC.
+type RecurringExpense struct {
+	ID         string           `json:"id"`
+	Account    *ExpenseAccount  `json:"account"`
+	AccountID  string           `json:"account_id"`
+	Category   *ExpenseCategory `json:"category" pg:",fk:category_id"`
+	CategoryID string           `json:"category_id"`
+	Amount     Money            `json:"amount" sql:"type:numeric"`
+	Currency   string           `json:"currency"`
+	Title      string           `json:"title"`
+	Frequency  string           `json:"frequency"`
+	DayOfMonth int              `json:"day_of_month,omitempty"`
+	StartDate  time.Time        `json:"start_date" sql:"type:date"`
+	EndDate    *time.Time       `json:"end_date" sql:"type:date"`
+	NextDate   time.Time        `json:"next_date" sql:"type:date"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	User       *User            `json:"-"`
+	UserID     string           `json:"user_id"`
+}
+
+// String return the string representation of RecurringExpense object.
+func (r RecurringExpense) String() string {
+	return fmt.Sprintf("RecurringExpense<%s>", r.ID)
+}
+
+// PreSave populates ID, CreatedAt, UpdatedAt and NextDate fields. Call this before saving to db.
+func (r *RecurringExpense) PreSave() {
+	r.ID = GenerateUUID()
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	r.NextDate = time.Time{}
+	r.Reschedule()
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating in db.
+func (r *RecurringExpense) PreUpdate() {
+	r.UpdatedAt = time.Now()
+}
+
+// AfterSelect sets the currency of the amount after fetching from db.
+func (r *RecurringExpense) AfterSelect(db orm.DB) error {
+	r.Amount.Currency = r.Currency
+	return nil
+}
+
+// SetCurrency sets the currency of the recurring expense and its amount.
+func (r *RecurringExpense) SetCurrency(currency string) {
+	r.Currency = currency
+	r.Amount.Currency = currency
+}
+
+// Reschedule recalculates NextDate after the schedule is changed. Occurrences
+// before the current NextDate are not created again.
+func (r *RecurringExpense) Reschedule() {
+	from := r.NextDate
+	if from.Before(r.StartDate) {
+		from = r.StartDate
+	}
+	r.NextDate = r.occurrenceFrom(TruncateDate(from))
+}
+
+// occurrenceFrom returns the first occurrence on or after the given date.
+func (r RecurringExpense) occurrenceFrom(date time.Time) time.Time {
+	start := TruncateDate(r.StartDate)
+	if date.Before(start) {
+		date = start
+	}
+	switch r.Frequency {
+	case FrequencyWeekly:
+		days := int(date.Sub(start).Hours() / 24)
+		weeks := (days + 6) / 7
+		return start.AddDate(0, 0, weeks*7)
+	case FrequencyMonthly:
+		day := r.DayOfMonth
+		if day == 0 {
+			day = start.Day()
+		}
+		occurrence := dateInMonth(date.Year(), date.Month(), day)
+		if occurrence.Before(date) {
+			occurrence = dateInMonth(date.Year(), date.Month()+1, day)
+		}
+		return occurrence
+	case FrequencyYearly:
+		occurrence := dateInMonth(date.Year(), start.Month(), start.Day())
+		if occurrence.Before(date) {
+			occurrence = dateInMonth(date.Year()+1, start.Month(), start.Day())
+		}
+		return occurrence
+	}
+	return date
+}
+
+// IsDue returns true if the next occurrence is due on the given day and
+// before the end date.
+func (r RecurringExpense) IsDue(today time.Time) bool {
+	if r.EndDate != nil && r.NextDate.After(TruncateDate(*r.EndDate)) {
+		return false
+	}
+	return !r.NextDate.After(TruncateDate(today))
+}
+
+// DueExpenses returns the expenses of all occurrences due till the given day
+// and moves NextDate past them.
+func (r *RecurringExpense) DueExpenses(today time.Time) []Expense {
+	var expenses []Expense
+	for r.IsDue(today) {
+		expense := Expense{
+			AccountID:  r.AccountID,
+			CategoryID: r.CategoryID,
+			Date:       r.NextDate,
+			Amount:     r.Amount,
+			Title:      r.Title,
+			UserID:     r.UserID,
+		}
+		expense.SetCurrency(r.Currency)
+		expenses = append(expenses, expense)
+		r.NextDate = r.occurrenceFrom(r.NextDate.AddDate(0, 0, 1))
+	}
+	return expenses
+}
+
+// ToJSON returns recurring expense object as json
+func (r RecurringExpense) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(r)
+	return data, err
+}
+
+// TruncateDate returns midnight UTC of the day of the given time.
+func TruncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dateInMonth returns the given day of the month, or the last day of the
+// month if it is shorter.
+func dateInMonth(year int, month time.Month, day int) time.Time {
+	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstDay.AddDate(0, 0, day-1)
+}
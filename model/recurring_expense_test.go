@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func dueDates(r *RecurringExpense, today time.Time) []time.Time {
+	var dates []time.Time
+	for _, expense := range r.DueExpenses(today) {
+		dates = append(dates, expense.Date)
+	}
+	return dates
+}
+
+func TestRecurringExpenseMonthly(t *testing.T) {
+	r := &RecurringExpense{Frequency: FrequencyMonthly, DayOfMonth: 31, StartDate: date(2019, 1, 15)}
+	r.PreSave()
+	assert.Equal(t, date(2019, 1, 31), r.NextDate)
+
+	dates := dueDates(r, date(2019, 4, 30))
+	assert.Equal(t, []time.Time{date(2019, 1, 31), date(2019, 2, 28), date(2019, 3, 31), date(2019, 4, 30)}, dates)
+	assert.Equal(t, date(2019, 5, 31), r.NextDate)
+	assert.Empty(t, r.DueExpenses(date(2019, 5, 30)), "Occurrences are created again.")
+
+	r.DayOfMonth = 1
+	r.Reschedule()
+	assert.Equal(t, date(2019, 6, 1), r.NextDate, "Occurrence before next date is created after rescheduling.")
+}
+
+func TestRecurringExpenseWeeklyAndYearly(t *testing.T) {
+	r := &RecurringExpense{Frequency: FrequencyWeekly, StartDate: date(2019, 1, 2)}
+	r.PreSave()
+	end := date(2019, 1, 20)
+	r.EndDate = &end
+	dates := dueDates(r, date(2019, 3, 1))
+	assert.Equal(t, []time.Time{date(2019, 1, 2), date(2019, 1, 9), date(2019, 1, 16)}, dates)
+	assert.False(t, r.IsDue(date(2019, 3, 1)), "Occurrence after end date is due.")
+
+	r = &RecurringExpense{Frequency: FrequencyYearly, StartDate: date(2016, 2, 29)}
+	r.PreSave()
+	dates = dueDates(r, date(2018, 1, 1))
+	assert.Equal(t, []time.Time{date(2016, 2, 29), date(2017, 2, 28)}, dates)
+	assert.Equal(t, date(2018, 2, 28), r.NextDate)
+}
+
+func TestRecurringExpenseDueExpenses(t *testing.T) {
+	r := &RecurringExpense{
+		Frequency:  FrequencyMonthly,
+		StartDate:  date(2019, 1, 5),
+		AccountID:  "account",
+		CategoryID: "category",
+		Amount:     NewMoney(1250, ""),
+		Title:      "Rent",
+		UserID:     "user",
+	}
+	r.SetCurrency("EUR")
+	r.PreSave()
+	expenses := r.DueExpenses(date(2019, 1, 5))
+	assert.Equal(t, []Expense{{
+		AccountID:  "account",
+		CategoryID: "category",
+		Date:       date(2019, 1, 5),
+		Amount:     NewMoney(1250, "EUR"),
+		Currency:   "EUR",
+		Title:      "Rent",
+		UserID:     "user",
+	}}, expenses)
+}
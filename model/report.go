@@ -0,0 +1,31 @@
+package model
+
+// ReportPeriodFormat is the format of the start dates used as keys in period reports.
+const ReportPeriodFormat = "2006-01-02"
+
+// ReportEntry is a single row of an aggregated spending report. Key is the id
+// of the category or account, or the start date of the period depending on the report.
+type ReportEntry struct {
+	Key      string `json:"key"`
+	Name     string `json:"name,omitempty"`
+	Currency string `json:"currency"`
+	Total    Money  `json:"total"`
+	Count    int    `json:"count"`
+	// Unconverted is the number of expenses left out of Total since they
+	// couldn't be converted into the report currency.
+	Unconverted int `json:"unconverted,omitempty"`
+}
+
+// CashFlowEntry is the income, expense and net totals of a period. Key is the
+// start date of the period.
+type CashFlowEntry struct {
+	Key      string `json:"key"`
+	Currency string `json:"currency"`
+	Income   Money  `json:"income"`
+	Expense  Money  `json:"expense"`
+	Net      Money  `json:"net"`
+	Count    int    `json:"count"`
+	// Unconverted is the number of transactions left out of the totals since
+	// they couldn't be converted into the report currency.
+	Unconverted int `json:"unconverted,omitempty"`
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Rule assigns a category and tags to the expenses matching its conditions.
+// All the conditions which are set should match: the title containing
+// TitleContains ignoring the case, the title matching the regular expression
+// in TitlePattern, the amount being between MinAmount and MaxAmount and the
+// expense being in the account.
+//
+// Rules of a user are tried in the order of Position and only the first
+// matching rule is applied. The category is assigned only to the expenses
+// without one unless overwriting, the tags are added to the existing ones.
+type Rule struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Position      int       `json:"position" sql:",notnull,default:0"`
+	TitleContains string    `json:"title_contains"`
+	TitlePattern  string    `json:"title_pattern"`
+	MinAmount     *Money    `json:"min_amount" sql:"type:numeric"`
+	MaxAmount     *Money    `json:"max_amount" sql:"type:numeric"`
+	AccountID     string    `json:"account_id"`
+	CategoryID    string    `json:"category_id"`
+	TagNames      []string  `json:"tags" sql:",array"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	User          *User     `json:"-"`
+	UserID        string    `json:"user_id"`
+	pattern       *regexp.Regexp
+}
+
+// String return the string representation of Rule object.
+func (r Rule) String() string {
+	return fmt.Sprintf("Rule<%s>", r.ID)
+}
+
+// PreSave populates ID, CreatedAt and UpdatedAt fields. Call this before saving to db.
+func (r *Rule) PreSave() {
+	r.ID = GenerateUUID()
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating in db.
+func (r *Rule) PreUpdate() {
+	r.UpdatedAt = time.Now()
+}
+
+// ToJSON returns rule object as json
+func (r Rule) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(r)
+	return data, err
+}
+
+// Matches returns true if the expense matches all the conditions of the
+// rule. Rules with an invalid TitlePattern don't match any expense.
+func (r *Rule) Matches(expense *Expense) bool {
+	if r.AccountID != "" && r.AccountID != expense.AccountID {
+		return false
+	}
+	if r.MinAmount != nil && expense.Amount.Amount < r.MinAmount.Amount {
+		return false
+	}
+	if r.MaxAmount != nil && expense.Amount.Amount > r.MaxAmount.Amount {
+		return false
+	}
+	if r.TitleContains != "" && !strings.Contains(strings.ToLower(expense.Title), strings.ToLower(r.TitleContains)) {
+		return false
+	}
+	if r.TitlePattern != "" {
+		if r.pattern == nil || r.pattern.String() != r.TitlePattern {
+			pattern, err := regexp.Compile(r.TitlePattern)
+			if err != nil {
+				return false
+			}
+			r.pattern = pattern
+		}
+		if !r.pattern.MatchString(expense.Title) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply assigns the category and the tags of the rule to the expense. The
+// category of the expense is replaced only if overwrite is set. Returns true
+// if the expense is changed.
+func (r *Rule) Apply(expense *Expense, overwrite bool) bool {
+	changed := false
+	if r.CategoryID != "" && r.CategoryID != expense.CategoryID && (expense.CategoryID == "" || overwrite) {
+		expense.CategoryID = r.CategoryID
+		expense.Category = nil
+		changed = true
+	}
+	for _, name := range r.TagNames {
+		if !expense.HasTag(name) {
+			expense.Tags = append(expense.Tags, Tag{Name: name})
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ApplyRules applies the first of the rules matching the expense. Returns
+// the applied rule, or nil if none of the rules changed the expense.
+func ApplyRules(rules []Rule, expense *Expense, overwrite bool) *Rule {
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Matches(expense) {
+			continue
+		}
+		if rule.Apply(expense, overwrite) {
+			return rule
+		}
+		return nil
+	}
+	return nil
+}
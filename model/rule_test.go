@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleMatches(t *testing.T) {
+	min, max := NewMoney(1000, ""), NewMoney(5000, "")
+	expense := &Expense{AccountID: "111", Title: "UBER *TRIP 1234", Amount: NewMoney(2500, "EUR")}
+	cases := []struct {
+		rule    Rule
+		matches bool
+	}{
+		{Rule{TitleContains: "uber"}, true},
+		{Rule{TitleContains: "lyft"}, false},
+		{Rule{TitlePattern: `^UBER \*TRIP \d+$`}, true},
+		{Rule{TitlePattern: `^uber`}, false},
+		{Rule{TitlePattern: `(?i)^uber`}, true},
+		{Rule{TitlePattern: `(`}, false},
+		{Rule{MinAmount: &min, MaxAmount: &max}, true},
+		{Rule{MaxAmount: &min}, false},
+		{Rule{MinAmount: &max}, false},
+		{Rule{AccountID: "111", TitleContains: "trip"}, true},
+		{Rule{AccountID: "112", TitleContains: "trip"}, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.matches, tc.rule.Matches(expense), "%+v", tc.rule)
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rules := []Rule{
+		{ID: "1", TitleContains: "uber", CategoryID: "121", TagNames: []string{"travel"}},
+		{ID: "2", TitleContains: "trip", CategoryID: "122", TagNames: []string{"work"}},
+	}
+
+	expense := &Expense{Title: "Uber trip"}
+	rule := ApplyRules(rules, expense, false)
+	if assert.NotNil(t, rule) {
+		assert.Equal(t, "1", rule.ID, "First matching rule is not applied.")
+	}
+	assert.Equal(t, "121", expense.CategoryID)
+	assert.True(t, expense.HasTag("travel"))
+	assert.False(t, expense.HasTag("work"))
+	assert.Nil(t, ApplyRules(rules, expense, false), "Rule is applied again without any change.")
+
+	expense = &Expense{Title: "Uber trip", CategoryID: "123", Tags: []Tag{{Name: "travel"}}}
+	assert.Nil(t, ApplyRules(rules, expense, false))
+	assert.Equal(t, "123", expense.CategoryID, "Category is overwritten.")
+	assert.Equal(t, 1, len(expense.Tags))
+
+	assert.NotNil(t, ApplyRules(rules, expense, true))
+	assert.Equal(t, "121", expense.CategoryID)
+
+	expense = &Expense{Title: "Train trip"}
+	rule = ApplyRules(rules, expense, false)
+	if assert.NotNil(t, rule) {
+		assert.Equal(t, "2", rule.ID)
+	}
+	assert.Nil(t, ApplyRules(rules, &Expense{Title: "Groceries"}, false))
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TagNameMaxLength is the maximum length of a tag name.
+const TagNameMaxLength = 50
+
+// Tag is a free-form label like "reimbursable" which can be attached to any
+// number of expenses, unlike the category of an expense. Names are unique
+// for each user.
+type Tag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name" sql:",unique:user_tag_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      *User     `json:"-"`
+	UserID    string    `json:"user_id" sql:",unique:user_tag_name"`
+}
+
+// ExpenseTag is the join table between expenses and tags.
+type ExpenseTag struct {
+	ExpenseID string `sql:",pk"`
+	TagID     string `sql:",pk"`
+}
+
+// NormalizeTagName returns the name in the form it is saved, which is
+// lowercased without the surrounding spaces.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// String return the string representation of Tag object.
+func (t Tag) String() string {
+	return fmt.Sprintf("Tag<%s>", t.Name)
+}
+
+// PreSave populates ID, CreatedAt and UpdatedAt fields. Call this before saving to db.
+func (t *Tag) PreSave() {
+	t.ID = GenerateUUID()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating in db.
+func (t *Tag) PreUpdate() {
+	t.UpdatedAt = time.Now()
+}
+
+// ToJSON returns tag object as json
+func (t Tag) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(t)
+	return data, err
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// Transfer is money moved between two accounts of the same user, like paying
+// a credit card bill from a bank account. Transfers are not expenses so they
+// are left out of the spending reports, but they change the balances of both
+// accounts. Amount is taken out of FromAccount in its currency and ToAmount
+// is put into ToAccount in its currency.
+type Transfer struct {
+	ID            string          `json:"id"`
+	FromAccount   *ExpenseAccount `json:"from_account"`
+	FromAccountID string          `json:"from_account_id"`
+	ToAccount     *ExpenseAccount `json:"to_account"`
+	ToAccountID   string          `json:"to_account_id"`
+	Amount        Money           `json:"amount" sql:"type:numeric"`
+	Currency      string          `json:"currency"`
+	ToAmount      Money           `json:"to_amount" sql:"type:numeric"`
+	ToCurrency    string          `json:"to_currency"`
+	Date          time.Time       `json:"date"`
+	Title         string          `json:"title"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	User          *User           `json:"-"`
+	UserID        string          `json:"user_id"`
+}
+
+// String return the string representation of Transfer object.
+func (t Transfer) String() string {
+	return fmt.Sprintf("Transfer<%s>", t.ID)
+}
+
+// PreSave populates ID, CreatedAt and UpdatedAt fields. Call this before saving to db.
+func (t *Transfer) PreSave() {
+	t.ID = GenerateUUID()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+}
+
+// PreUpdate refreshes UpdatedAt field. Call this before updating in db.
+func (t *Transfer) PreUpdate() {
+	t.UpdatedAt = time.Now()
+}
+
+// AfterSelect sets the currencies of the amounts after fetching from db.
+func (t *Transfer) AfterSelect(db orm.DB) error {
+	t.Amount.Currency = t.Currency
+	t.ToAmount.Currency = t.ToCurrency
+	return nil
+}
+
+// SetCurrencies sets the currencies of the transfer and its amounts.
+func (t *Transfer) SetCurrencies(currency, toCurrency string) {
+	t.Currency = currency
+	t.Amount.Currency = currency
+	t.ToCurrency = toCurrency
+	t.ToAmount.Currency = toCurrency
+}
+
+// ToJSON returns transfer object as json
+func (t Transfer) ToJSON() ([]byte, error) {
+	data, err := json.Marshal(t)
+	return data, err
+}
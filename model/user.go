@@ -15,6 +15,8 @@ type User struct {
 	Password  string    `json:"-"`
 	Name      string    `json:"name,omitempty"`
 	Active    bool      `json:"active"`
+	// BaseCurrency is the currency to which expenses are converted in summaries.
+	BaseCurrency string `json:"base_currency"`
 }
 
 // SetPassword : Set new password for the user.
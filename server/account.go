@@ -0,0 +1,184 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+// fetchUserAccount fetches the account with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserAccount(c *Context, w http.ResponseWriter, r *http.Request) *model.ExpenseAccount {
+	id := mux.Vars(r)["id"]
+	account, err := c.Srv.Store.Expense().GetAccountByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if account.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return account
+}
+
+// setAccountBalance populates the current balance of the account.
+func setAccountBalance(c *Context, account *model.ExpenseAccount) error {
+	balance, err := c.Srv.Store.Expense().AccountBalance(account, model.TruncateDate(time.Now()))
+	if err != nil {
+		return err
+	}
+	account.Balance = &balance.Balance
+	return nil
+}
+
+func getExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	account := fetchUserAccount(c, w, r)
+	if account == nil {
+		return
+	}
+	if err := setAccountBalance(c, account); err != nil {
+		log.Println("Error in calculating account balance: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(account, http.StatusOK, w)
+}
+
+// getAccountBalance returns the breakdown of the account balance at the end
+// of the day in the date parameter, or today if it is not given.
+func getAccountBalance(c *Context, w http.ResponseWriter, r *http.Request) {
+	account := fetchUserAccount(c, w, r)
+	if account == nil {
+		return
+	}
+	day, ok := parseStatusDate(w, r)
+	if !ok {
+		return
+	}
+
+	balance, err := c.Srv.Store.Expense().AccountBalance(account, model.TruncateDate(day))
+	if err != nil {
+		log.Println("Error in calculating account balance: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(balance, http.StatusOK, w)
+}
+
+// reconcileAccount compares the statement balance in the payload with the
+// balance of the account on the statement date and marks the expenses up to
+// that date as reconciled.
+func reconcileAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	account := fetchUserAccount(c, w, r)
+	if account == nil {
+		return
+	}
+	payload := &reconcilePayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	day := model.TruncateDate(payload.Date)
+	balance, err := c.Srv.Store.Expense().AccountBalance(account, day)
+	if err != nil {
+		log.Println("Error in calculating account balance: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	reconciliation := balance.Reconcile(payload.balance)
+	reconciliation.Count, err = c.Srv.Store.Expense().ReconcileAccount(account, day)
+	if err != nil {
+		log.Println("Error in reconciling account: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(reconciliation, http.StatusOK, w)
+}
+
+func updateExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	account := fetchUserAccount(c, w, r)
+	if account == nil {
+		return
+	}
+	payload := &updateExpenseAccountPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	payload.apply(account)
+	if err := c.Srv.Store.Expense().UpdateAccount(account); err != nil {
+		log.Println("Error in updating account: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(account, http.StatusOK, w)
+}
+
+// deleteExpenseAccount deletes the account if it has no transactions. When
+// reassign_to parameter is given the transactions are moved to that account
+// first, which should be in the same currency. Responds with 409 Conflict
+// and the number of transactions if the account is still in use, or with the
+// number of transfers between the two accounts when reassigning as they
+// can't be moved.
+func deleteExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	account := fetchUserAccount(c, w, r)
+	if account == nil {
+		return
+	}
+
+	var moveTo *model.ExpenseAccount
+	if id := r.URL.Query().Get("reassign_to"); id != "" {
+		validator := payloadValidator{errs: url.Values{}}
+		var err error
+		moveTo, err = validator.validateAccountField(c, "reassign_to", id)
+		if err != nil {
+			log.Println("Error in validating account: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+			return
+		}
+		if moveTo != nil && (moveTo.ID == account.ID || moveTo.Currency != account.Currency) {
+			validator.errs.Add("reassign_to", errorInvalidChoice)
+		}
+		if len(validator.errs) > 0 {
+			validator.writeErrorMessage(w)
+			return
+		}
+	}
+
+	err := c.Srv.Store.Expense().DeleteAccount(account, moveTo)
+	if inUse, ok := err.(*store.AccountInUseError); ok {
+		res := errorResponse(errorAccountInUse)
+		res["count"] = inUse.Count
+		writeJSONResponse(res, http.StatusConflict, w)
+		return
+	}
+	if err != nil {
+		log.Println("Error in deleting account: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
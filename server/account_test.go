@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAccountWithOpeningBalance(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{"name": "Savings", "opening_balance": "-1200.50", "opening_date": "2019-01-01T10:00:00Z"}`, http.StatusOK, ""},
+		{`{"name": "Savings", "opening_balance": "12.505"}`, http.StatusBadRequest, `{"errors":{"opening_balance":["max_decimal_places"]}}`},
+		{`{"name": "Savings", "opening_balance": "abc"}`, http.StatusBadRequest, `{"errors":{"opening_balance":["invalid_amount"]}}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/accounts/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+			continue
+		}
+		var account model.ExpenseAccount
+		if err := json.Unmarshal(recorder.Body.Bytes(), &account); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "-1200.50", account.OpeningBalance.String())
+		assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), account.OpeningDate)
+	}
+}
+
+func TestAccountBalance(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	day := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	balance := &model.AccountBalance{AccountID: "111", Date: day, Currency: "EUR", OpeningBalance: model.NewMoney(100000, "EUR"), Expense: model.NewMoney(20000, "EUR")}
+	balance.Total()
+	testStore.expenseStore.On("AccountBalance", "111", day).Return(balance, nil)
+	testStore.expenseStore.On("ReconcileAccount", "111", day).Return(4, nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method   string
+		url      string
+		payload  string
+		status   int
+		expected string
+	}{
+		{"GET", "/api/expenses/accounts/111/balance/?date=2019-03-10", "", http.StatusOK, ""},
+		{"GET", "/api/expenses/accounts/113/balance/?date=2019-03-10", "", http.StatusUnauthorized, ""},
+		{"GET", "/api/expenses/accounts/114/balance/", "", http.StatusNotFound, ""},
+		{"GET", "/api/expenses/accounts/111/balance/?date=10-03-2019", "", http.StatusBadRequest, `{"errors":{"date":["invalid_date"]}}`},
+		{"POST", "/api/expenses/accounts/111/reconcile/", `{"date": "2019-03-10T00:00:00Z", "balance": "790.00"}`, http.StatusOK,
+			`{"account_id":"111","date":"2019-03-10T00:00:00Z","statement_balance":790.00,"balance":800.00,"discrepancy":-10.00,"reconciled":false,"count":4}`},
+		{"POST", "/api/expenses/accounts/111/reconcile/", `{"date": "2019-03-10T00:00:00Z", "balance": "800"}`, http.StatusOK,
+			`{"account_id":"111","date":"2019-03-10T00:00:00Z","statement_balance":800.00,"balance":800.00,"discrepancy":0.00,"reconciled":true,"count":4}`},
+		{"POST", "/api/expenses/accounts/111/reconcile/", `{}`, http.StatusBadRequest, `{"errors":{"date":["is_required"],"balance":["is_required"]}}`},
+		{"POST", "/api/expenses/accounts/113/reconcile/", `{"date": "2019-03-10T00:00:00Z", "balance": "0"}`, http.StatusUnauthorized, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.method+" "+tc.url)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/api/expenses/accounts/111/balance/?date=2019-03-10", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	var fetched model.AccountBalance
+	if err := json.Unmarshal(recorder.Body.Bytes(), &fetched); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "800.00", fetched.Balance.String())
+}
+
+func TestGetExpenseAccounts(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	accounts := []model.ExpenseAccount{{ID: "111", Currency: "EUR", UserID: userID}, {ID: "112", UserID: userID}}
+	balances := []model.AccountBalance{
+		{AccountID: "111", Currency: "EUR", Income: model.NewMoney(50000, "EUR"), Expense: model.NewMoney(20000, "EUR")},
+		{AccountID: "112", OpeningBalance: model.NewMoney(1000, "")},
+	}
+	for i := range balances {
+		balances[i].Total()
+	}
+	testStore.expenseStore.On("GetExpenseAccounts", userID, true).Return(accounts, nil)
+	testStore.expenseStore.On("GetAccountBalances", userID, mock.Anything).Return(balances, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/expenses/accounts/?archived=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response []model.ExpenseAccount
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(response))
+	assert.Equal(t, "300.00", response[0].Balance.String())
+	assert.Equal(t, "10.00", response[1].Balance.String())
+	testStore.expenseStore.AssertNumberOfCalls(t, "GetAccountBalances", 1)
+	testStore.expenseStore.AssertNotCalled(t, "AccountBalance", mock.Anything, mock.Anything)
+}
+
+func TestUpdateExpenseAccount(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		url      string
+		payload  string
+		status   int
+		expected string
+	}{
+		{"/api/expenses/accounts/111/", `{"name": "Savings", "archived": true, "currency": "USD"}`, http.StatusOK, ""},
+		{"/api/expenses/accounts/111/", `{"archived": true}`, http.StatusBadRequest, `{"errors":{"name":["is_required"]}}`},
+		{"/api/expenses/accounts/113/", `{"name": "Savings"}`, http.StatusUnauthorized, ""},
+		{"/api/expenses/accounts/114/", `{"name": "Savings"}`, http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("PUT", tc.url, bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.url+" "+tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		}
+		if tc.status == http.StatusOK {
+			var account model.ExpenseAccount
+			if err := json.Unmarshal(recorder.Body.Bytes(), &account); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "Savings", account.Name)
+			assert.True(t, account.Archived)
+			assert.Equal(t, "EUR", account.Currency, "Currency of the account is changed.")
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/api/expenses/accounts/?archived=maybe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestUpdateExpenseAccountKeepsOmittedFields(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	openingDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	testStore.expenseStore.On("GetAccountByID", "115").Return(&model.ExpenseAccount{ID: "115", Name: "Old", Currency: "EUR", Archived: true,
+		OpeningBalance: model.NewMoney(50000, "EUR"), OpeningDate: openingDate, UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("PUT", "/api/expenses/accounts/115/", bytes.NewBufferString(`{"name": "Savings"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var account model.ExpenseAccount
+	if err := json.Unmarshal(recorder.Body.Bytes(), &account); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Savings", account.Name)
+	assert.True(t, account.Archived)
+	assert.Equal(t, "500.00", account.OpeningBalance.String())
+	assert.Equal(t, openingDate, account.OpeningDate)
+}
+
+func TestArchivedAccountRejectsNewTransactions(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.expenseStore.On("GetAccountByID", "115").Return(&model.ExpenseAccount{ID: "115", Currency: "EUR", Archived: true, UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method  string
+		url     string
+		payload string
+	}{
+		{"POST", "/api/expenses/", `{"amount": "10", "title": "Nesto", "category_id": "121", "account_id": "115", "date": "2019-01-02T15:04:05Z"}`},
+		{"PATCH", "/api/expenses/9123/", `{"account_id": "115"}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, tc.method+" "+tc.url)
+		assert.JSONEq(t, `{"errors":{"account_id":["account_archived"]}}`, recorder.Body.String(), tc.method+" "+tc.url)
+	}
+}
+
+func TestDeleteExpenseAccount(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.expenseStore.On("GetAccountByID", "115").Return(&model.ExpenseAccount{ID: "115", Currency: "EUR", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	testStore.expenseStore.On("DeleteAccount", "111", "").Return(&store.AccountInUseError{Count: 3})
+	testStore.expenseStore.On("DeleteAccount", "111", "115").Return(nil)
+	testStore.expenseStore.On("DeleteAccount", "112", "").Return(nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		url      string
+		status   int
+		expected string
+	}{
+		{"/api/expenses/accounts/111/", http.StatusConflict, `{"error":"account_in_use","count":3}`},
+		{"/api/expenses/accounts/111/?reassign_to=115", http.StatusNoContent, ""},
+		{"/api/expenses/accounts/111/?reassign_to=112", http.StatusBadRequest, `{"errors":{"reassign_to":["invalid_choice"]}}`},
+		{"/api/expenses/accounts/111/?reassign_to=111", http.StatusBadRequest, `{"errors":{"reassign_to":["invalid_choice"]}}`},
+		{"/api/expenses/accounts/111/?reassign_to=113", http.StatusBadRequest, `{"errors":{"reassign_to":["invalid_choice"]}}`},
+		{"/api/expenses/accounts/112/", http.StatusNoContent, ""},
+		{"/api/expenses/accounts/113/", http.StatusUnauthorized, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("DELETE", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.url)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.url)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+// maxAttachmentSize is the maximum size of an uploaded file in bytes.
+const maxAttachmentSize = 10 << 20
+
+// defaultAttachmentQuota is the total size of attachments allowed for each user in bytes.
+const defaultAttachmentQuota = 100 << 20
+
+// attachmentContentTypes are the types of files which can be attached. The
+// type is sniffed from the content instead of trusting the client.
+var attachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+func (srv *Server) InitAttachmentAPIs() {
+	srv.Routes.Expense.Handle("/attachments/", srv.ApiWithTokenValidation(getAttachments)).Methods("GET")
+	srv.Routes.Expense.Handle("/attachments/", srv.ApiWithTokenValidation(uploadAttachment)).Methods("POST")
+	srv.Routes.Expense.Handle("/attachments/{attachment_id}/", srv.ApiWithTokenValidation(downloadAttachment)).Methods("GET")
+	srv.Routes.Expense.Handle("/attachments/{attachment_id}/", srv.ApiWithTokenValidation(deleteAttachment)).Methods("DELETE")
+}
+
+// fetchUserExpenseForAttachments fetches the expense in the url like
+// fetchUserExpense after making sure attachments are enabled.
+func fetchUserExpenseForAttachments(c *Context, w http.ResponseWriter, r *http.Request) *model.Expense {
+	if c.Srv.Attachments == nil {
+		writeJSONResponse(errorResponse(errorAttachmentsDisabled), http.StatusNotImplemented, w)
+		return nil
+	}
+	return fetchUserExpense(c, w, r)
+}
+
+// fetchExpenseAttachment fetches the attachment with id in the url and makes
+// sure it belongs to the expense. Writes the error response and returns nil
+// otherwise.
+func fetchExpenseAttachment(c *Context, w http.ResponseWriter, r *http.Request, expense *model.Expense) *model.Attachment {
+	id := mux.Vars(r)["attachment_id"]
+	attachment, err := c.Srv.Store.Expense().GetAttachmentByID(id)
+	if err != nil && err != pg.ErrNoRows {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return nil
+	}
+	if err == pg.ErrNoRows || attachment.ExpenseID != expense.ID {
+		writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		return nil
+	}
+	return attachment
+}
+
+func getAttachments(c *Context, w http.ResponseWriter, r *http.Request) {
+	expense := fetchUserExpenseForAttachments(c, w, r)
+	if expense == nil {
+		return
+	}
+	attachments, err := c.Srv.Store.Expense().GetAttachments(expense.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if attachments == nil {
+		attachments = []model.Attachment{}
+	}
+	writeJSON(attachments, http.StatusOK, w)
+}
+
+// writeFileError writes the validation error of the uploaded file.
+func writeFileError(code string, w http.ResponseWriter) {
+	errs := url.Values{}
+	errs.Add("file", code)
+	payloadValidator{errs: errs}.writeErrorMessage(w)
+}
+
+// uploadAttachment attaches the file in the file field of the multipart form
+// to the expense. Responds with 413 when the file would take the user over
+// the attachment quota.
+func uploadAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	expense := fetchUserExpenseForAttachments(c, w, r)
+	if expense == nil {
+		return
+	}
+
+	// Leaving some room for the rest of the multipart body.
+	limit := int64(maxAttachmentSize + 1<<20)
+	if r.ContentLength > limit {
+		writeFileError(errorMaxSize, w)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	file, header, err := r.FormFile("file")
+	switch {
+	case err == http.ErrMissingFile || err == http.ErrNotMultipart:
+		writeFileError(errorIsRequired, w)
+		return
+	case err != nil:
+		log.Println("Error in reading the uploaded file: ", err.Error())
+		writeFileError(errorMaxSize, w)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxAttachmentSize {
+		writeFileError(errorMaxSize, w)
+		return
+	}
+
+	sniffed := make([]byte, 512)
+	n, err := io.ReadFull(file, sniffed)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		writeFileError(errorIsRequired, w)
+		return
+	}
+	contentType := http.DetectContentType(sniffed[:n])
+	if !attachmentContentTypes[contentType] {
+		writeFileError(errorInvalidContentType, w)
+		return
+	}
+
+	used, err := c.Srv.Store.Expense().AttachmentsSize(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if used+header.Size > c.Srv.AttachmentQuota {
+		res := errorResponse(errorQuotaExceeded)
+		res["quota"] = c.Srv.AttachmentQuota
+		res["used"] = used
+		writeJSONResponse(res, http.StatusRequestEntityTooLarge, w)
+		return
+	}
+
+	checksum := sha256.New()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	if _, err := io.Copy(checksum, file); err != nil {
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	attachment := &model.Attachment{
+		ExpenseID:   expense.ID,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Checksum:    hex.EncodeToString(checksum.Sum(nil)),
+		UserID:      c.User.ID,
+	}
+	if err := c.Srv.Store.Expense().StoreAttachment(attachment); err != nil {
+		log.Println("Error in saving attachment: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+
+	// Content is saved under the id of the attachment, the metadata is
+	// removed if it can't be saved.
+	_, err = file.Seek(0, io.SeekStart)
+	if err == nil {
+		err = c.Srv.Attachments.Put(attachment.ID, file)
+	}
+	if err != nil {
+		log.Println("Error in saving attachment content: ", err.Error())
+		if err := c.Srv.Store.Expense().DeleteAttachment(attachment); err != nil {
+			log.Println("Error in deleting attachment: ", err.Error())
+		}
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully saved attachment with id", attachment.ID)
+	writeJSON(attachment, http.StatusCreated, w)
+}
+
+func downloadAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	expense := fetchUserExpenseForAttachments(c, w, r)
+	if expense == nil {
+		return
+	}
+	attachment := fetchExpenseAttachment(c, w, r, expense)
+	if attachment == nil {
+		return
+	}
+
+	content, err := c.Srv.Attachments.Get(attachment.ID)
+	if err == store.ErrAttachmentNotFound {
+		writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		return
+	} else if err != nil {
+		log.Println("Error in reading attachment content: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Println("Error in writing attachment content: ", err.Error())
+	}
+}
+
+func deleteAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	expense := fetchUserExpenseForAttachments(c, w, r)
+	if expense == nil {
+		return
+	}
+	attachment := fetchExpenseAttachment(c, w, r, expense)
+	if attachment == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Expense().DeleteAttachment(attachment); err != nil {
+		log.Println("Error in deleting attachment: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	removeAttachmentContents(c, []model.Attachment{*attachment})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeAttachmentContents deletes the content of the attachments from the
+// AttachmentStore. Failures are only logged as the metadata is already gone.
+func removeAttachmentContents(c *Context, attachments []model.Attachment) {
+	for _, attachment := range attachments {
+		if err := c.Srv.Attachments.Delete(attachment.ID); err != nil {
+			log.Println("Error in deleting content of attachment", attachment.ID, err.Error())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// pngContent is the start of a png file, enough for sniffing the content type.
+var pngContent = []byte("\x89PNG\x0D\x0A\x1A\x0A receipt")
+
+func newUploadRequest(t *testing.T, url string, fileName string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Add("Authorization", "1234")
+	return req
+}
+
+func setupAttachmentServer(t *testing.T, testStore *MockStore) (*Server, func()) {
+	dir, err := ioutil.TempDir("", "attachments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(testStore)
+	if srv.Attachments, err = store.NewLocalAttachmentStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	return srv, func() { os.RemoveAll(dir) }
+}
+
+func TestUploadAttachment(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.expenseStore.On("AttachmentsSize", "b89505a4-a451-45e5-912e-4ef8c1441be6").Return(int64(1000), nil)
+	srv, cleanup := setupAttachmentServer(t, testStore)
+	defer cleanup()
+
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, newUploadRequest(t, "/api/expenses/9123/attachments/", "../receipt.png", pngContent))
+	assert.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var attachment model.Attachment
+	if err := json.Unmarshal(recorder.Body.Bytes(), &attachment); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "receipt.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(pngContent)), attachment.Size)
+	assert.Equal(t, 64, len(attachment.Checksum))
+
+	content, err := srv.Attachments.Get(attachment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(content)
+	content.Close()
+	assert.Equal(t, pngContent, data)
+
+	cases := []struct {
+		url      string
+		fileName string
+		content  []byte
+		status   int
+		expected string
+	}{
+		{"/api/expenses/9123/attachments/", "notes.txt", []byte("plain text"), http.StatusBadRequest, `{"errors":{"file":["invalid_content_type"]}}`},
+		{"/api/expenses/9124/attachments/", "receipt.png", pngContent, http.StatusUnauthorized, ""},
+		{"/api/expenses/9125/attachments/", "receipt.png", pngContent, http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, newUploadRequest(t, tc.url, tc.fileName, tc.content))
+		assert.Equal(t, tc.status, recorder.Code, tc.url)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.url)
+		}
+	}
+
+	req, err := http.NewRequest("POST", "/api/expenses/9123/attachments/", bytes.NewBufferString(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors":{"file":["is_required"]}}`, recorder.Body.String())
+
+	// Uploads are refused once the quota is used up.
+	srv.AttachmentQuota = 1010
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, newUploadRequest(t, "/api/expenses/9123/attachments/", "receipt.png", pngContent))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.JSONEq(t, `{"error":"quota_exceeded","quota":1010,"used":1000}`, recorder.Body.String())
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	attachment := &model.Attachment{ID: "a1", ExpenseID: "9123", FileName: "receipt.png", ContentType: "image/png", Size: int64(len(pngContent))}
+	testStore.expenseStore.On("GetAttachmentByID", "a1").Return(attachment, nil)
+	testStore.expenseStore.On("GetAttachmentByID", "a2").Return(&model.Attachment{ID: "a2", ExpenseID: "9124"}, nil)
+	testStore.expenseStore.On("GetAttachmentByID", "a3").Return((*model.Attachment)(nil), pg.ErrNoRows)
+	testStore.expenseStore.On("GetAttachments", "9123").Return([]model.Attachment{*attachment}, nil)
+	srv, cleanup := setupAttachmentServer(t, testStore)
+	defer cleanup()
+	if err := srv.Attachments.Put("a1", bytes.NewBuffer(pngContent)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/api/expenses/9123/attachments/", http.StatusOK},
+		{"GET", "/api/expenses/9123/attachments/a1/", http.StatusOK},
+		{"GET", "/api/expenses/9123/attachments/a2/", http.StatusNotFound},
+		{"GET", "/api/expenses/9123/attachments/a3/", http.StatusNotFound},
+		{"GET", "/api/expenses/9124/attachments/a2/", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url)
+	}
+
+	req, err := http.NewRequest("GET", "/api/expenses/9123/attachments/a1/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, pngContent, recorder.Body.Bytes())
+	assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=receipt.png`, recorder.Header().Get("Content-Disposition"))
+
+	// Deleting the expense removes the content of its attachments.
+	req, err = http.NewRequest("DELETE", "/api/expenses/9123/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	_, err = srv.Attachments.Get("a1")
+	assert.Equal(t, store.ErrAttachmentNotFound, err)
+}
+
+func TestAttachmentsDisabled(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, newUploadRequest(t, "/api/expenses/9123/attachments/", "receipt.png", pngContent))
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+}
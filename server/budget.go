@@ -0,0 +1,220 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+func (srv *Server) InitBudgetAPIs() {
+	srv.Routes.Budgets.Handle("/", srv.ApiWithTokenValidation(getBudgets)).Methods("GET")
+	srv.Routes.Budgets.Handle("/", srv.ApiWithTokenValidation(createBudget)).Methods("POST")
+	srv.Routes.Budgets.Handle("/status/", srv.ApiWithTokenValidation(getBudgetsStatus)).Methods("GET")
+	srv.Routes.Budgets.Handle("/{id}/", srv.ApiWithTokenValidation(getBudget)).Methods("GET")
+	srv.Routes.Budgets.Handle("/{id}/", srv.ApiWithTokenValidation(updateBudget)).Methods("PUT")
+	srv.Routes.Budgets.Handle("/{id}/", srv.ApiWithTokenValidation(deleteBudget)).Methods("DELETE")
+	srv.Routes.Budgets.Handle("/{id}/status/", srv.ApiWithTokenValidation(getBudgetStatus)).Methods("GET")
+}
+
+func getBudgets(c *Context, w http.ResponseWriter, r *http.Request) {
+	budgets, err := c.Srv.Store.Budget().GetBudgets(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if budgets == nil {
+		budgets = []model.Budget{}
+	}
+	writeJSON(budgets, http.StatusOK, w)
+}
+
+// loadBudgetPayload reads and validates the payload in the request. Writes
+// the error response and returns nil if it is not valid.
+func loadBudgetPayload(c *Context, w http.ResponseWriter, r *http.Request) *budgetPayload {
+	payload := &budgetPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return nil
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating budget references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return nil
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	return payload
+}
+
+func createBudget(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := loadBudgetPayload(c, w, r)
+	if payload == nil {
+		return
+	}
+
+	budget := &model.Budget{UserID: c.User.ID}
+	payload.apply(budget)
+	if err := c.Srv.Store.Budget().StoreBudget(budget); err != nil {
+		log.Println("Error in creating budget: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully created budget with id", budget.ID)
+	writeJSON(budget, http.StatusCreated, w)
+}
+
+// fetchUserBudget fetches the budget with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserBudget(c *Context, w http.ResponseWriter, r *http.Request) *model.Budget {
+	id := mux.Vars(r)["id"]
+	budget, err := c.Srv.Store.Budget().GetBudgetByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if budget.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return budget
+}
+
+func getBudget(c *Context, w http.ResponseWriter, r *http.Request) {
+	budget := fetchUserBudget(c, w, r)
+	if budget == nil {
+		return
+	}
+	writeJSON(budget, http.StatusOK, w)
+}
+
+func updateBudget(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	budget := fetchUserBudget(c, w, r)
+	if budget == nil {
+		return
+	}
+	payload := loadBudgetPayload(c, w, r)
+	if payload == nil {
+		return
+	}
+
+	payload.apply(budget)
+	if err := c.Srv.Store.Budget().UpdateBudget(budget); err != nil {
+		log.Println("Error in updating budget: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(budget, http.StatusOK, w)
+}
+
+func deleteBudget(c *Context, w http.ResponseWriter, r *http.Request) {
+	budget := fetchUserBudget(c, w, r)
+	if budget == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Budget().DeleteBudget(budget); err != nil {
+		log.Println("Error in deleting budget: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseStatusDate returns the date in the date parameter of the request, or
+// today if it is not given. Writes the error response and returns false if
+// the date is not valid.
+func parseStatusDate(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	value := r.URL.Query().Get("date")
+	if value == "" {
+		return time.Now(), true
+	}
+	day, err := time.Parse(store.DateFormat, value)
+	if err != nil {
+		errs := url.Values{}
+		errs.Add("date", store.ErrorInvalidDate)
+		payloadValidator{errs: errs}.writeErrorMessage(w)
+		return day, false
+	}
+	return day, true
+}
+
+// budgetStatus compares the budget against the spending in the month of the
+// given day. Spending since the start of the budget is used for rollover.
+func budgetStatus(c *Context, budget *model.Budget, day time.Time) (model.BudgetStatus, error) {
+	period := model.MonthStart(day)
+	filter := store.ReportFilter{
+		From:       period,
+		To:         period.AddDate(0, 1, -1),
+		AccountID:  budget.AccountID,
+		CategoryID: budget.CategoryID,
+		Currency:   budget.Currency,
+	}
+	if budget.Rollover && budget.StartDate.Before(period) {
+		filter.From = model.MonthStart(budget.StartDate)
+	}
+	spent, err := c.Srv.Store.Report().TotalsByPeriod(budget.UserID, "monthly", filter)
+	if err != nil {
+		return model.BudgetStatus{}, err
+	}
+	return budget.Status(day, spent), nil
+}
+
+func getBudgetStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	budget := fetchUserBudget(c, w, r)
+	if budget == nil {
+		return
+	}
+	day, ok := parseStatusDate(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := budgetStatus(c, budget, day)
+	if err != nil {
+		log.Println("Error in calculating budget status: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(status, http.StatusOK, w)
+}
+
+func getBudgetsStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	day, ok := parseStatusDate(w, r)
+	if !ok {
+		return
+	}
+	budgets, err := c.Srv.Store.Budget().GetBudgets(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+
+	statuses := []model.BudgetStatus{}
+	for i := range budgets {
+		status, err := budgetStatus(c, &budgets[i], day)
+		if err != nil {
+			log.Println("Error in calculating budget status: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+			return
+		}
+		statuses = append(statuses, status)
+	}
+	writeJSON(statuses, http.StatusOK, w)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+type budgetPayload struct {
+	CategoryID string          `json:"category_id"`
+	AccountID  string          `json:"account_id"`
+	Amount     json.RawMessage `json:"amount"`
+	Currency   string          `json:"currency"`
+	Rollover   bool            `json:"rollover"`
+	StartDate  time.Time       `json:"start_date"`
+	amount     model.Money
+	currency   string
+	payloadValidator
+}
+
+func (p *budgetPayload) isValid() bool {
+	p.errs = url.Values{}
+
+	// A budget is either for a category or for an account.
+	if p.CategoryID == "" && p.AccountID == "" {
+		p.errs.Add("category_id", errorIsRequired)
+		p.errs.Add("account_id", errorIsRequired)
+	} else if p.CategoryID != "" && p.AccountID != "" {
+		p.errs.Add("account_id", errorInvalidChoice)
+	}
+
+	p.amount = p.validateAmount(p.Amount)
+	if p.amount.IsNegative() {
+		p.errs.Add("amount", errorInvalidAmount)
+	}
+	p.validateCurrency(p.Currency)
+
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that the account or the category in the payload
+// belong to the user. If the payload doesn't have a currency, currency of the
+// account or the base currency of the user is used, one of which is required.
+func (p *budgetPayload) hasValidReferences(c *Context) (bool, error) {
+	account, err := p.validateAccount(c, p.AccountID)
+	if err != nil {
+		return false, err
+	}
+	if err := p.validateCategory(c, p.CategoryID); err != nil {
+		return false, err
+	}
+
+	p.currency = p.Currency
+	if p.currency == "" && account != nil {
+		p.currency = account.Currency
+	}
+	if p.currency == "" {
+		if err := c.loadUser(); err != nil {
+			return false, err
+		}
+		p.currency = c.User.BaseCurrency
+	}
+	if p.currency == "" {
+		p.errs.Add("currency", errorIsRequired)
+	}
+	return len(p.errs) == 0, nil
+}
+
+// apply copies the payload fields into the given budget. If start date is not
+// given a new budget starts from the current month and an existing budget
+// keeps its start date.
+func (p *budgetPayload) apply(budget *model.Budget) {
+	if budget.CategoryID != p.CategoryID {
+		budget.Category = nil
+	}
+	if budget.AccountID != p.AccountID {
+		budget.Account = nil
+	}
+	budget.CategoryID = p.CategoryID
+	budget.AccountID = p.AccountID
+	budget.Amount = p.amount
+	budget.Rollover = p.Rollover
+	budget.SetCurrency(p.currency)
+	if !p.StartDate.IsZero() {
+		budget.StartDate = model.MonthStart(p.StartDate)
+	} else if budget.StartDate.IsZero() {
+		budget.StartDate = model.MonthStart(time.Now())
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateBudget(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+		currency string
+	}{
+		{`{"category_id": "121", "amount": "500", "start_date": "2019-01-15T00:00:00Z"}`, http.StatusCreated, "", "INR"},
+		{`{"account_id": "111", "amount": "500"}`, http.StatusCreated, "", "EUR"},
+		{`{"category_id": "121", "amount": "500", "currency": "USD"}`, http.StatusCreated, "", "USD"},
+		{`{"amount": "500"}`, http.StatusBadRequest, `{"errors":{"category_id":["is_required"],"account_id":["is_required"]}}`, ""},
+		{`{"category_id": "121", "account_id": "111", "amount": "500"}`, http.StatusBadRequest, `{"errors":{"account_id":["invalid_choice"]}}`, ""},
+		{`{"category_id": "121", "amount": "-500"}`, http.StatusBadRequest, `{"errors":{"amount":["invalid_amount"]}}`, ""},
+		{`{"category_id": "122", "amount": "500"}`, http.StatusBadRequest, `{"errors":{"category_id":["invalid_choice"]}}`, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/budgets/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		}
+		if tc.currency != "" {
+			var budget model.Budget
+			if err := json.Unmarshal(recorder.Body.Bytes(), &budget); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.currency, budget.Currency, tc.payload)
+			assert.Equal(t, 1, budget.StartDate.Day(), "Budget doesn't start from the beginning of the month.")
+		}
+	}
+}
+
+func TestBudgetDetail(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.budgetStore.On("GetBudgetByID", "141").Return(&model.Budget{ID: "141", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	testStore.budgetStore.On("GetBudgetByID", "142").Return(&model.Budget{ID: "142", UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}, nil)
+	testStore.budgetStore.On("GetBudgetByID", "143").Return((*model.Budget)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/api/budgets/141/", http.StatusOK},
+		{"GET", "/api/budgets/142/", http.StatusUnauthorized},
+		{"GET", "/api/budgets/143/", http.StatusNotFound},
+		{"DELETE", "/api/budgets/142/", http.StatusUnauthorized},
+		{"DELETE", "/api/budgets/141/", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url)
+	}
+}
+
+func TestUpdateBudgetKeepsStartDate(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	startDate := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	testStore.budgetStore.On("GetBudgetByID", "141").Return(&model.Budget{ID: "141", CategoryID: "121", StartDate: startDate, UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	srv := NewServer(testStore)
+
+	update := func(payload string) model.Budget {
+		req, err := http.NewRequest("PUT", "/api/budgets/141/", bytes.NewBufferString(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, payload)
+		var budget model.Budget
+		if err := json.Unmarshal(recorder.Body.Bytes(), &budget); err != nil {
+			t.Fatal(err)
+		}
+		return budget
+	}
+	budget := update(`{"category_id": "121", "amount": "600"}`)
+	assert.True(t, startDate.Equal(budget.StartDate), "Start date of the budget is changed.")
+	budget = update(`{"category_id": "121", "amount": "600", "start_date": "2019-03-15T00:00:00Z"}`)
+	assert.True(t, time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC).Equal(budget.StartDate))
+}
+
+func TestBudgetStatus(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	budget := &model.Budget{
+		ID:         "141",
+		CategoryID: "121",
+		Amount:     model.NewMoney(10000, "EUR"),
+		Currency:   "EUR",
+		Rollover:   true,
+		StartDate:  time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+		UserID:     userID,
+	}
+	testStore.budgetStore.On("GetBudgetByID", "141").Return(budget, nil)
+	testStore.budgetStore.On("GetBudgets", userID).Return([]model.Budget{*budget}, nil)
+	filter := store.ReportFilter{
+		From:       time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:         time.Date(2019, 3, 31, 0, 0, 0, 0, time.UTC),
+		CategoryID: "121",
+		Currency:   "EUR",
+	}
+	testStore.reportStore.On("TotalsByPeriod", userID, "monthly", filter).Return([]model.ReportEntry{
+		{Key: "2019-02-01", Currency: "EUR", Total: model.NewMoney(4000, "EUR")},
+		{Key: "2019-03-01", Currency: "EUR", Total: model.NewMoney(17000, "EUR")},
+	}, nil)
+	srv := NewServer(testStore)
+
+	for _, url := range []string{"/api/budgets/141/status/?date=2019-03-10", "/api/budgets/status/?date=2019-03-10"} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, url)
+
+		body := bytes.TrimPrefix(recorder.Body.Bytes(), []byte("["))
+		body = bytes.TrimSuffix(body, []byte("]"))
+		var status model.BudgetStatus
+		if err := json.Unmarshal(body, &status); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "170.00", status.Spent.String(), url)
+		assert.Equal(t, "60.00", status.Rollover.String(), url)
+		assert.Equal(t, "-10.00", status.Remaining.String(), url)
+		assert.True(t, status.Overspent, url)
+	}
+
+	req, err := http.NewRequest("GET", "/api/budgets/status/?date=10-03-2019", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
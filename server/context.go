@@ -1,8 +1,47 @@
 package server
 
-import "github.com/ragsagar/wolff/model"
+import (
+	"log"
+	"net/http"
+
+	"github.com/ragsagar/wolff/model"
+)
 
 type Context struct {
-	Srv  *Server
+	Srv *Server
+	// User is the user of the request. Only the ID is set for the requests
+	// with JWT access tokens until loadUser is called.
 	User *model.User
+	// Token is the auth token used in the request. It is set only for the
+	// APIs with token validation.
+	Token *model.AuthToken
+
+	userLoaded bool
+}
+
+// loadUser fetches the user of the request from the store if only the ID
+// of the user is known.
+func (c *Context) loadUser() error {
+	if c.userLoaded {
+		return nil
+	}
+	user, err := c.Srv.Store.User().GetUserByID(c.User.ID)
+	if err != nil {
+		return err
+	}
+	c.User = user
+	c.userLoaded = true
+	return nil
+}
+
+// fetchRequestUser loads the user of the request for the handlers using more
+// than the ID of the user. Writes the error response and returns false if
+// the user can't be fetched.
+func fetchRequestUser(c *Context, w http.ResponseWriter) bool {
+	if err := c.loadUser(); err != nil {
+		log.Println("Error in fetching user: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return false
+	}
+	return true
 }
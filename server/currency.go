@@ -0,0 +1,51 @@
+package server
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+// currencyConverter converts amounts into a single currency using the
+// exchange rates in the store. Rates are cached for the lifetime of the converter.
+type currencyConverter struct {
+	store    store.Store
+	currency string
+	rates    map[string]float64
+}
+
+func newCurrencyConverter(store store.Store, currency string) *currencyConverter {
+	return &currencyConverter{store: store, currency: currency, rates: map[string]float64{}}
+}
+
+// convert returns the amount in converter's currency using the rate on the
+// given date. Returns nil if the amount has no currency or no rate is known.
+func (cc *currencyConverter) convert(amount model.Money, date time.Time) (*model.Money, error) {
+	if amount.Currency == "" {
+		return nil, nil
+	}
+	if amount.Currency == cc.currency {
+		converted := amount
+		return &converted, nil
+	}
+
+	key := amount.Currency + date.Format(model.ExchangeRateDateFormat)
+	rate, ok := cc.rates[key]
+	if !ok {
+		exchangeRate, err := cc.store.ExchangeRate().FindRate(amount.Currency, cc.currency, date)
+		if err != nil && err != pg.ErrNoRows {
+			return nil, err
+		}
+		if exchangeRate != nil {
+			rate = exchangeRate.Rate
+		}
+		cc.rates[key] = rate
+	}
+	if rate == 0 {
+		return nil, nil
+	}
+	converted := amount.Convert(rate, cc.currency)
+	return &converted, nil
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+func (srv *Server) InitDuplicateAPIs() {
+	srv.Routes.Expenses.Handle("/duplicates/", srv.ApiWithTokenValidation(getDuplicates)).Methods("GET")
+	srv.Routes.Expenses.Handle("/duplicates/merge/", srv.ApiWithTokenValidation(mergeDuplicates)).Methods("POST")
+	srv.Routes.Expenses.Handle("/duplicates/dismiss/", srv.ApiWithTokenValidation(dismissDuplicates)).Methods("POST")
+}
+
+// getDuplicates writes the groups of the user's expenses which seem to be
+// the same transaction, leaving out the dismissed ones.
+func getDuplicates(c *Context, w http.ResponseWriter, r *http.Request) {
+	expenses, err := c.Srv.Store.Expense().GetDuplicateCandidates(c.User.ID)
+	if err != nil {
+		log.Println("Error in fetching duplicate candidates: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	dismissals, err := c.Srv.Store.Expense().GetDuplicateDismissals(c.User.ID)
+	if err != nil {
+		log.Println("Error in fetching duplicate dismissals: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(model.GroupDuplicates(expenses, dismissals), http.StatusOK, w)
+}
+
+// mergeDuplicates merges the duplicates into the expense to keep and
+// removes them. Their attachments are moved to the kept expense.
+func mergeDuplicates(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &mergeDuplicatesPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating duplicate references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	keep := payload.keep
+	for i := range payload.duplicates {
+		keep.Merge(&payload.duplicates[i])
+	}
+	if err := c.Srv.Store.Expense().MergeExpenses(keep, payload.duplicates); err != nil {
+		log.Println("Error in merging duplicate expenses: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Merged", len(payload.duplicates), "duplicates into expense", keep.ID)
+	writeExpense(keep, w)
+}
+
+// dismissDuplicates marks the expenses as not duplicates of each other so
+// that they are not grouped again.
+func dismissDuplicates(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &dismissDuplicatesPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating duplicate references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	if err := c.Srv.Store.Expense().DismissDuplicates(payload.dismissals(c.User.ID)); err != nil {
+		log.Println("Error in dismissing duplicates: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findLikelyDuplicates returns the ids of the user's expenses which seem to
+// be the same transaction as the expense.
+func findLikelyDuplicates(c *Context, expense *model.Expense) ([]string, error) {
+	candidates, err := c.Srv.Store.Expense().GetPossibleDuplicates(expense)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for i := range candidates {
+		if model.IsLikelyDuplicate(expense, &candidates[i]) {
+			ids = append(ids, candidates[i].ID)
+		}
+	}
+	return ids, nil
+}
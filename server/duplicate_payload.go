@@ -0,0 +1,148 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+)
+
+// mergeDuplicatesPayload has the expense to keep and its duplicates which
+// are merged into it and removed.
+type mergeDuplicatesPayload struct {
+	Keep       string   `json:"keep"`
+	ExpenseIDs []string `json:"expense_ids"`
+	keep       *model.Expense
+	duplicates []model.Expense
+	payloadValidator
+}
+
+func (p *mergeDuplicatesPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Keep == "" {
+		p.errs.Add("keep", errorIsRequired)
+	}
+	p.ExpenseIDs = uniqueIDs(p.ExpenseIDs)
+	if len(p.ExpenseIDs) == 0 {
+		p.errs.Add("expense_ids", errorIsRequired)
+	}
+	for _, id := range p.ExpenseIDs {
+		if id == p.Keep {
+			p.errs.Add("expense_ids", errorInvalidChoice)
+			break
+		}
+	}
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that all the expenses belong to the user and are
+// likely duplicates of the expense to keep.
+func (p *mergeDuplicatesPayload) hasValidReferences(c *Context) (bool, error) {
+	keep, err := fetchExpenseOfUser(c, p.Keep)
+	if err != nil {
+		return false, err
+	}
+	if keep == nil {
+		p.errs.Add("keep", errorInvalidChoice)
+	}
+	p.keep = keep
+	p.duplicates, err = fetchExpensesOfUser(c, p.ExpenseIDs)
+	if err != nil {
+		return false, err
+	}
+	if p.duplicates == nil {
+		p.errs.Add("expense_ids", errorInvalidChoice)
+	} else if keep != nil {
+		for i := range p.duplicates {
+			if !model.IsLikelyDuplicate(keep, &p.duplicates[i]) {
+				p.errs.Add("expense_ids", errorInvalidChoice)
+				break
+			}
+		}
+	}
+	return len(p.errs) == 0, nil
+}
+
+// dismissDuplicatesPayload has the expenses which are not duplicates of
+// each other.
+type dismissDuplicatesPayload struct {
+	ExpenseIDs []string `json:"expense_ids"`
+	payloadValidator
+}
+
+func (p *dismissDuplicatesPayload) isValid() bool {
+	p.errs = url.Values{}
+	p.ExpenseIDs = uniqueIDs(p.ExpenseIDs)
+	if len(p.ExpenseIDs) == 0 {
+		p.errs.Add("expense_ids", errorIsRequired)
+	} else if len(p.ExpenseIDs) < 2 {
+		p.errs.Add("expense_ids", errorMinLength)
+	}
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that all the expenses belong to the user.
+func (p *dismissDuplicatesPayload) hasValidReferences(c *Context) (bool, error) {
+	expenses, err := fetchExpensesOfUser(c, p.ExpenseIDs)
+	if err != nil {
+		return false, err
+	}
+	if expenses == nil {
+		p.errs.Add("expense_ids", errorInvalidChoice)
+	}
+	return len(p.errs) == 0, nil
+}
+
+// dismissals returns the dismissal of each pair of the expenses.
+func (p *dismissDuplicatesPayload) dismissals(userID string) []model.DuplicateDismissal {
+	var dismissals []model.DuplicateDismissal
+	for i, id := range p.ExpenseIDs {
+		for _, other := range p.ExpenseIDs[i+1:] {
+			dismissals = append(dismissals, model.NewDuplicateDismissal(userID, id, other))
+		}
+	}
+	return dismissals
+}
+
+// fetchExpenseOfUser returns the expense with the id or nil if it doesn't
+// exist or belongs to another user.
+func fetchExpenseOfUser(c *Context, id string) (*model.Expense, error) {
+	expense, err := c.Srv.Store.Expense().GetByID(id)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if expense.UserID != c.User.ID {
+		return nil, nil
+	}
+	return expense, nil
+}
+
+// fetchExpensesOfUser returns the expenses with the ids or nil if any of
+// them doesn't exist or belongs to another user.
+func fetchExpensesOfUser(c *Context, ids []string) ([]model.Expense, error) {
+	expenses := make([]model.Expense, 0, len(ids))
+	for _, id := range ids {
+		expense, err := fetchExpenseOfUser(c, id)
+		if err != nil || expense == nil {
+			return nil, err
+		}
+		expenses = append(expenses, *expense)
+	}
+	return expenses, nil
+}
+
+// uniqueIDs returns the non empty ids without repetitions, keeping their
+// order.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const duplicateUserID = "b89505a4-a451-45e5-912e-4ef8c1441be6"
+
+func duplicateExpense(id, title string, day int) model.Expense {
+	return model.Expense{
+		ID:        id,
+		UserID:    duplicateUserID,
+		AccountID: "111",
+		Kind:      model.KindExpense,
+		Amount:    model.NewMoney(1000, "EUR"),
+		Title:     title,
+		Date:      time.Date(2019, 1, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestGetDuplicates(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.expenseStore.On("GetDuplicateCandidates", duplicateUserID).Return([]model.Expense{
+		duplicateExpense("a1", "Lidl", 10),
+		duplicateExpense("a2", "LIDL 1234", 12),
+		duplicateExpense("a3", "Rent", 11),
+		duplicateExpense("a4", "Coffee", 20),
+		duplicateExpense("a5", "Coffee", 21),
+	}, nil)
+	testStore.expenseStore.On("GetDuplicateDismissals", duplicateUserID).Return([]model.DuplicateDismissal{
+		model.NewDuplicateDismissal(duplicateUserID, "a5", "a4"),
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/api/expenses/duplicates/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var groups []model.DuplicateGroup
+	if err := json.Unmarshal(recorder.Body.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, groups, 1) && assert.Len(t, groups[0].Expenses, 2) {
+		assert.Equal(t, "a1", groups[0].Expenses[0].ID)
+		assert.Equal(t, "a2", groups[0].Expenses[1].ID)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	keep, _ := testStore.expenseStore.GetByID("9123")
+	duplicate := *keep
+	duplicate.ID = "9126"
+	duplicate.Tags = []model.Tag{{ID: "131", Name: "weekly"}}
+	testStore.expenseStore.On("GetByID", "9126").Return(&duplicate, nil)
+	// Expense with another amount is not a duplicate.
+	different := duplicate
+	different.ID = "9127"
+	different.Amount = model.NewMoney(20000, "")
+	testStore.expenseStore.On("GetByID", "9127").Return(&different, nil)
+	testStore.expenseStore.On("MergeExpenses", "9123", mock.Anything).Return(nil)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{}`, http.StatusBadRequest, `{"errors":{"keep":["is_required"],"expense_ids":["is_required"]}}`},
+		{`{"keep": "9123", "expense_ids": ["9123"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["invalid_choice"]}}`},
+		{`{"keep": "9124", "expense_ids": ["9126"]}`, http.StatusBadRequest, `{"errors":{"keep":["invalid_choice"]}}`},
+		{`{"keep": "9123", "expense_ids": ["9126", "9125"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["invalid_choice"]}}`},
+		{`{"keep": "9123", "expense_ids": ["9126", "9127"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["invalid_choice"]}}`},
+		{`{"keep": "9123", "expense_ids": ["9126"]}`, http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/duplicates/merge/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+			continue
+		}
+		var expense model.Expense
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "9123", expense.ID)
+		if assert.Len(t, expense.Tags, 1) {
+			assert.Equal(t, "weekly", expense.Tags[0].Name)
+		}
+	}
+	testStore.expenseStore.AssertCalled(t, "MergeExpenses", "9123", []model.Expense{duplicate})
+}
+
+func TestDismissDuplicates(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.expenseStore.On("DismissDuplicates", mock.Anything).Return(nil)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{}`, http.StatusBadRequest, `{"errors":{"expense_ids":["is_required"]}}`},
+		{`{"expense_ids": ["9123", "9123"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["min_length"]}}`},
+		{`{"expense_ids": ["9123", "9124"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["invalid_choice"]}}`},
+		{`{"expense_ids": ["9123", "9125"]}`, http.StatusBadRequest, `{"errors":{"expense_ids":["invalid_choice"]}}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/duplicates/dismiss/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+	}
+	testStore.expenseStore.AssertNotCalled(t, "DismissDuplicates", mock.Anything)
+
+	other := duplicateExpense("9126", "Nesto", 1)
+	testStore.expenseStore.On("GetByID", "9126").Return(&other, nil)
+	req, err := http.NewRequest("POST", "/api/expenses/duplicates/dismiss/", bytes.NewBufferString(`{"expense_ids": ["9126", "9123"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	testStore.expenseStore.AssertCalled(t, "DismissDuplicates", mock.MatchedBy(func(dismissals []model.DuplicateDismissal) bool {
+		return len(dismissals) == 1 && dismissals[0].ExpenseID == "9123" && dismissals[0].OtherID == "9126" &&
+			dismissals[0].UserID == duplicateUserID
+	}))
+}
+
+func TestCreateExpenseCheckDuplicates(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.expenseStore.On("GetPossibleDuplicates", "111").Return([]model.Expense{
+		duplicateExpense("a1", "Lidl Berlin", 14),
+		duplicateExpense("a2", "Rent", 15),
+	}, nil)
+
+	payload := `{"account_id": "111", "amount": "10", "title": "Lidl", "date": "2019-01-15T00:00:00Z"}`
+	cases := []struct {
+		url        string
+		status     int
+		expected   string
+		duplicates []string
+	}{
+		{"/api/expenses/", http.StatusOK, "", nil},
+		{"/api/expenses/?check_duplicates=true", http.StatusOK, "", []string{"a1"}},
+		{"/api/expenses/?check_duplicates=maybe", http.StatusBadRequest, `{"errors":{"check_duplicates":["invalid_boolean"]}}`, nil},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", tc.url, bytes.NewBufferString(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.url)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.url)
+			continue
+		}
+		var expense model.Expense
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.duplicates, expense.PossibleDuplicates, tc.url)
+	}
+	testStore.expenseStore.AssertNumberOfCalls(t, "GetPossibleDuplicates", 1)
+}
@@ -1,10 +1,14 @@
 package server
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-pg/pg"
 	"github.com/gorilla/mux"
@@ -15,9 +19,24 @@ import (
 func (srv *Server) InitExpenseAPIs() {
 	srv.Routes.Expenses.Handle("/", srv.ApiWithTokenValidation(createExpense)).Methods("POST")
 	srv.Routes.Expenses.Handle("/", srv.ApiWithTokenValidation(getExpenses)).Methods("GET")
+	srv.Routes.Expenses.Handle("/export.csv", srv.ApiWithTokenValidation(exportExpenses)).Methods("GET")
 	srv.Routes.Expenses.Handle("/accounts/", srv.ApiWithTokenValidation(getExpenseAccounts)).Methods("GET")
 	srv.Routes.Expenses.Handle("/accounts/", srv.ApiWithTokenValidation(createExpenseAccount)).Methods("POST")
+	srv.Routes.Expenses.Handle("/accounts/{id}/", srv.ApiWithTokenValidation(getExpenseAccount)).Methods("GET")
+	srv.Routes.Expenses.Handle("/accounts/{id}/", srv.ApiWithTokenValidation(updateExpenseAccount)).Methods("PUT")
 	srv.Routes.Expenses.Handle("/accounts/{id}/", srv.ApiWithTokenValidation(deleteExpenseAccount)).Methods("DELETE")
+	srv.Routes.Expenses.Handle("/accounts/{id}/balance/", srv.ApiWithTokenValidation(getAccountBalance)).Methods("GET")
+	srv.Routes.Expenses.Handle("/accounts/{id}/reconcile/", srv.ApiWithTokenValidation(reconcileAccount)).Methods("POST")
+	srv.Routes.Expenses.Handle("/categories/", srv.ApiWithTokenValidation(getExpenseCategories)).Methods("GET")
+	srv.Routes.Expenses.Handle("/categories/", srv.ApiWithTokenValidation(createExpenseCategory)).Methods("POST")
+	srv.Routes.Expenses.Handle("/categories/{id}/", srv.ApiWithTokenValidation(updateExpenseCategory)).Methods("PUT")
+	srv.Routes.Expenses.Handle("/categories/{id}/", srv.ApiWithTokenValidation(deleteExpenseCategory)).Methods("DELETE")
+	srv.Routes.Expense.Handle("/", srv.ApiWithTokenValidation(getExpense)).Methods("GET")
+	srv.Routes.Expense.Handle("/", srv.ApiWithTokenValidation(updateExpense)).Methods("PUT")
+	srv.Routes.Expense.Handle("/", srv.ApiWithTokenValidation(patchExpense)).Methods("PATCH")
+	srv.Routes.Expense.Handle("/", srv.ApiWithTokenValidation(deleteExpense)).Methods("DELETE")
+	srv.Routes.Income.Handle("/", srv.ApiWithTokenValidation(createIncome)).Methods("POST")
+	srv.Routes.Income.Handle("/", srv.ApiWithTokenValidation(getIncome)).Methods("GET")
 }
 
 func errorResponse(errorType string) map[string]interface{} {
@@ -29,8 +48,28 @@ func loadJSON(payload interface{}, body io.Reader) error {
 }
 
 func createExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	createTransaction(c, w, r, model.KindExpense)
+}
+
+func createIncome(c *Context, w http.ResponseWriter, r *http.Request) {
+	createTransaction(c, w, r, model.KindIncome)
+}
+
+// createTransaction creates an expense of the given kind unless the payload
+// has another kind. With check_duplicates parameter set, the ids of existing
+// expenses which seem to be the same transaction are included in the
+// response as a warning.
+func createTransaction(c *Context, w http.ResponseWriter, r *http.Request, kind string) {
 	defer r.Body.Close()
 	payload := &createExpensePayload{}
+	checkDuplicates := false
+	if value := r.URL.Query().Get("check_duplicates"); value != "" {
+		var err error
+		if checkDuplicates, err = strconv.ParseBool(value); err != nil {
+			payloadValidator{errs: url.Values{"check_duplicates": {store.ErrorInvalidBoolean}}}.writeErrorMessage(w)
+			return
+		}
+	}
 
 	if err := loadJSON(payload, r.Body); err != nil {
 		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
@@ -41,64 +80,329 @@ func createExpense(c *Context, w http.ResponseWriter, r *http.Request) {
 		payload.writeErrorMessage(w)
 		return
 	}
-	expense := model.Expense{
-		AccountID:  payload.AccountID,
-		Date:       payload.Date,
-		CategoryID: payload.CategoryID,
-		Amount:     payload.Amount,
-		UserID:     c.User.ID,
-		Title:      payload.Title,
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating expense references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+	expense := model.Expense{UserID: c.User.ID, Kind: kind}
+	payload.apply(&expense)
+	if err := applyUserRules(c, &expense); err != nil {
+		log.Println("Error in applying rules to expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	var duplicates []string
+	if checkDuplicates {
+		var err error
+		if duplicates, err = findLikelyDuplicates(c, &expense); err != nil {
+			log.Println("Error in finding duplicates of expense: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+			return
+		}
 	}
 	if err := c.Srv.Store.Expense().Store(&expense); err != nil {
-		// TODO: Log this properly
+		log.Println("Error in creating expense: ", err.Error())
 		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
 	}
 
+	expense.PossibleDuplicates = duplicates
+
 	jsonData, err := expense.ToJSON()
 	if err != nil {
-		// TODO: Log this properly
+		log.Println("Error in generating expense json: ", err.Error())
 		writeJSONResponse(errorResponse(errorJSONGeneration), http.StatusInternalServerError, w)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
-
 }
 
 func getExpenses(c *Context, w http.ResponseWriter, r *http.Request) {
+	listTransactions(c, w, r, "")
+}
+
+func getIncome(c *Context, w http.ResponseWriter, r *http.Request) {
+	listTransactions(c, w, r, model.KindIncome)
+}
+
+// listTransactions writes the user's expenses matching the filter in the url
+// along with the cursor to the next page. The total number of matching
+// expenses is included when total parameter is set. If kind is given only
+// the transactions of that kind are listed.
+func listTransactions(c *Context, w http.ResponseWriter, r *http.Request, kind string) {
 	filter := store.ExpenseFilter{}
-	filter.ParseURLValues(r.URL.Query())
+	errs := filter.ParseURLValues(r.URL.Query())
+	if kind != "" {
+		filter.SetKind(kind)
+	}
+	withTotal := false
+	if value := r.URL.Query().Get("total"); value != "" {
+		var err error
+		if withTotal, err = strconv.ParseBool(value); err != nil {
+			errs.Add("total", store.ErrorInvalidBoolean)
+		}
+	}
+	convert := false
+	if value := r.URL.Query().Get("convert"); value != "" {
+		var err error
+		if convert, err = strconv.ParseBool(value); err != nil {
+			errs.Add("convert", store.ErrorInvalidBoolean)
+		}
+	}
+	if len(errs) > 0 {
+		payloadValidator{errs: errs}.writeErrorMessage(w)
+		return
+	}
+	if convert && !fetchRequestUser(c, w) {
+		return
+	}
+	if convert && c.User.BaseCurrency == "" {
+		writeJSONResponse(errorResponse(errorBaseCurrencyNotSet), http.StatusBadRequest, w)
+		return
+	}
 	expenses, err := c.Srv.Store.Expense().GetExpenses(c.User.ID, filter)
 	if err != nil {
 		log.Println("Erorr in getting expenses: ", err.Error())
 		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
 		return
 	}
+	if convert {
+		converter := newCurrencyConverter(c.Srv.Store, c.User.BaseCurrency)
+		for i := range expenses {
+			if expenses[i].ConvertedAmount, err = converter.convert(expenses[i].Amount, expenses[i].Date); err != nil {
+				log.Println("Error in converting expense amount: ", err.Error())
+				writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+				return
+			}
+		}
+	}
+	if expenses == nil {
+		expenses = []model.Expense{}
+	}
+
+	var nextCursor *string
+	if cursor := filter.NextCursor(expenses); cursor != "" {
+		nextCursor = &cursor
+	}
+	res := map[string]interface{}{"items": expenses, "next_cursor": nextCursor}
+	if withTotal {
+		total, err := c.Srv.Store.Expense().CountExpenses(c.User.ID, filter)
+		if err != nil {
+			log.Println("Erorr in counting expenses: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+			return
+		}
+		res["total"] = total
+	}
+	writeJSON(res, http.StatusOK, w)
+}
+
+// exportExpenses streams the user's expenses matching the filter in the url
+// as csv. All the matching expenses are exported unless limit or page is given.
+func exportExpenses(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter := store.ExpenseFilter{}
+	if errs := filter.ParseURLValues(r.URL.Query()); len(errs) > 0 {
+		payloadValidator{errs: errs}.writeErrorMessage(w)
+		return
+	}
 
-	jsonData, err := json.Marshal(expenses)
+	writer := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="expenses.csv"`)
+		return writer.Write(model.ExpenseExportHeader)
+	}
+	err := c.Srv.Store.Expense().ExportExpenses(c.User.ID, filter, func(row *model.ExpenseExportRow) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(row.Record())
+	})
+	if err == nil && !started {
+		err = start()
+	}
 	if err != nil {
-		writeJSONResponse(errorResponse(errorJSONGeneration), http.StatusInternalServerError, w)
+		log.Println("Error in exporting expenses: ", err.Error())
+		if !started {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		// Rest of the rows are dropped if the response has already started.
 		return
 	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Println("Error in writing exported expenses: ", err.Error())
+	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if string(jsonData) == "null" {
-		w.Write([]byte("{}"))
-	} else {
-		w.Write(jsonData)
+// fetchUserExpense fetches the expense with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserExpense(c *Context, w http.ResponseWriter, r *http.Request) *model.Expense {
+	id := mux.Vars(r)["id"]
+	expense, err := c.Srv.Store.Expense().GetByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if expense.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return expense
+}
+
+func writeExpense(expense *model.Expense, w http.ResponseWriter) {
+	writeJSON(expense, http.StatusOK, w)
+}
+
+func getExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	expense := fetchUserExpense(c, w, r)
+	if expense == nil {
+		return
+	}
+	writeExpense(expense, w)
+}
+
+func updateExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	expense := fetchUserExpense(c, w, r)
+	if expense == nil {
+		return
+	}
+
+	payload := &createExpensePayload{currentAccountID: expense.AccountID}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating expense references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	payload.apply(expense)
+	saveExpense(c, expense, w)
+}
+
+func patchExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	expense := fetchUserExpense(c, w, r)
+	if expense == nil {
+		return
 	}
+
+	payload := &patchExpensePayload{currentAccountID: expense.AccountID}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating expense references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	payload.apply(expense)
+	saveExpense(c, expense, w)
+}
+
+// saveExpense updates the modified expense in db and writes it back in the response.
+func saveExpense(c *Context, expense *model.Expense, w http.ResponseWriter) {
+	if err := c.Srv.Store.Expense().UpdateExpense(expense); err != nil {
+		log.Println("Error in updating expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully updated expense with id", expense.ID)
+	writeExpense(expense, w)
 }
 
+// deleteExpense deletes the expense along with the content of its attachments.
 func deleteExpense(c *Context, w http.ResponseWriter, r *http.Request) {
-	// expense, err := c.Srv.Store.Expense().DeleteExpenseWithUserID(id, userId)
+	expense := fetchUserExpense(c, w, r)
+	if expense == nil {
+		return
+	}
+
+	var attachments []model.Attachment
+	if c.Srv.Attachments != nil {
+		var err error
+		if attachments, err = c.Srv.Store.Expense().GetAttachments(expense.ID); err != nil {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+			return
+		}
+	}
+	if err := c.Srv.Store.Expense().DeleteExpense(expense); err != nil {
+		log.Println("Error in deleting expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	removeAttachmentContents(c, attachments)
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// getExpenseAccounts lists the accounts of the user with their balances.
+// Archived accounts are listed only when archived parameter is set.
 func getExpenseAccounts(c *Context, w http.ResponseWriter, r *http.Request) {
+	includeArchived := false
+	if value := r.URL.Query().Get("archived"); value != "" {
+		var err error
+		if includeArchived, err = strconv.ParseBool(value); err != nil {
+			errs := url.Values{}
+			errs.Add("archived", store.ErrorInvalidBoolean)
+			payloadValidator{errs: errs}.writeErrorMessage(w)
+			return
+		}
+	}
 
-	expenseAccounts, err := c.Srv.Store.Expense().GetExpenseAccounts(c.User.ID)
+	expenseAccounts, err := c.Srv.Store.Expense().GetExpenseAccounts(c.User.ID, includeArchived)
 	if err != nil {
 		writeJSONResponse(errorResponse(errorDbFetch), http.StatusBadRequest, w)
 		return
 	}
+	balances, err := c.Srv.Store.Expense().GetAccountBalances(c.User.ID, model.TruncateDate(time.Now()))
+	if err != nil {
+		log.Println("Error in calculating account balances: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	balanceByAccount := make(map[string]model.Money, len(balances))
+	for _, balance := range balances {
+		balanceByAccount[balance.AccountID] = balance.Balance
+	}
+	for i := range expenseAccounts {
+		if balance, ok := balanceByAccount[expenseAccounts[i].ID]; ok {
+			expenseAccounts[i].Balance = &balance
+		}
+	}
 
 	jsonData, err := json.Marshal(expenseAccounts)
 	if err != nil {
@@ -123,7 +427,11 @@ func createExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the expense account in database.
-	expenseAccount := model.ExpenseAccount{Name: payload.Name, UserID: c.User.ID}
+	if payload.Currency == "" && !fetchRequestUser(c, w) {
+		return
+	}
+	expenseAccount := model.ExpenseAccount{UserID: c.User.ID}
+	payload.apply(c, &expenseAccount)
 	expenseAccount.PreSave()
 	err := c.Srv.Store.Expense().StoreAccount(expenseAccount)
 	if err != nil {
@@ -144,32 +452,120 @@ func createExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonData)
 }
 
-func deleteExpenseAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+func getExpenseCategories(c *Context, w http.ResponseWriter, r *http.Request) {
+	categories, err := c.Srv.Store.Expense().GetExpenseCategories(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if categories == nil {
+		categories = []model.ExpenseCategory{}
+	}
+	writeJSON(categories, http.StatusOK, w)
+}
+
+func createExpenseCategory(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &expenseCategoryPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	category := model.ExpenseCategory{Name: payload.Name, UserID: c.User.ID}
+	if err := c.Srv.Store.Expense().StoreCategory(&category); err != nil {
+		log.Println("Error in creating category: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully created category with id", category.ID)
+	writeExpenseCategory(&category, http.StatusCreated, w)
+}
+
+// fetchUserCategory fetches the category with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserCategory(c *Context, w http.ResponseWriter, r *http.Request) *model.ExpenseCategory {
 	id := mux.Vars(r)["id"]
-	expenseAccount, err := c.Srv.Store.Expense().GetAccountByID(id)
+	category, err := c.Srv.Store.Expense().GetCategoryByID(id)
 	if err != nil {
 		if err == pg.ErrNoRows {
 			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
 		} else {
 			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
 		}
-		return
+		return nil
 	}
-	if expenseAccount.UserID != c.User.ID {
+	if category.UserID != c.User.ID {
 		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return category
+}
+
+func updateExpenseCategory(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	category := fetchUserCategory(c, w, r)
+	if category == nil {
 		return
 	}
 
-	err = c.Srv.Store.Expense().DeleteAccount(expenseAccount)
-	if err != nil {
+	payload := &expenseCategoryPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	category.Name = payload.Name
+	if err := c.Srv.Store.Expense().UpdateCategory(category); err != nil {
+		log.Println("Error in updating category: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeExpenseCategory(category, http.StatusOK, w)
+}
+
+func deleteExpenseCategory(c *Context, w http.ResponseWriter, r *http.Request) {
+	category := fetchUserCategory(c, w, r)
+	if category == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Expense().DeleteCategory(category); err != nil {
+		log.Println("Error in deleting category: ", err.Error())
 		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func writeExpenseCategory(category *model.ExpenseCategory, status int, w http.ResponseWriter) {
+	writeJSON(category, status, w)
+}
+
 func writeJSONResponse(res map[string]interface{}, s int, w http.ResponseWriter) {
 	w.Header().Set("Content-type", "applciation/json")
 	w.WriteHeader(s)
 	json.NewEncoder(w).Encode(res)
 }
+
+// writeJSON writes the given value as json response with the status.
+func writeJSON(value interface{}, status int, w http.ResponseWriter) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		log.Println("Error in json creation: ", err.Error())
+		writeJSONResponse(errorResponse(errorJSONGeneration), http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonData)
+}
@@ -1,16 +1,29 @@
 package server
 
 import (
+	"encoding/json"
 	"net/url"
 	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
 )
 
 type createExpensePayload struct {
-	AccountID  string    `json:"account_id"`
-	Date       time.Time `json:"date"`
-	CategoryID string    `json:"category_id"`
-	Amount     float64   `json:"amount"`
-	Title      string    `json:"title"`
+	AccountID  string          `json:"account_id"`
+	Date       time.Time       `json:"date"`
+	CategoryID string          `json:"category_id"`
+	Amount     json.RawMessage `json:"amount"`
+	Currency   string          `json:"currency"`
+	Title      string          `json:"title"`
+	Kind       string          `json:"kind"`
+	// Tags replace the tags of the expense when present.
+	Tags    *[]string `json:"tags"`
+	amount  model.Money
+	tags    []model.Tag
+	account *model.ExpenseAccount
+	// currentAccountID is the account of the expense being updated.
+	currentAccountID string
 	payloadValidator
 }
 
@@ -26,23 +39,397 @@ func (e *createExpensePayload) isValid() bool {
 		e.errs.Add("date", errorIsRequired)
 	}
 
-	if e.Amount == 0 {
-		e.errs.Add("amount", errorIsRequired)
+	e.amount = e.validateAmount(e.Amount)
+	if e.amount.IsNegative() {
+		e.errs.Add("amount", errorInvalidAmount)
 	}
+	e.validateCurrency(e.Currency)
 
 	if e.Title == "" {
 		e.errs.Add("title", errorIsRequired)
 	}
 
+	if e.Kind != "" && !model.IsValidKind(e.Kind) {
+		e.errs.Add("kind", errorInvalidChoice)
+	}
+
+	if e.Tags != nil {
+		e.tags = e.validateTags(*e.Tags)
+	}
+
 	return len(e.errs) == 0
 }
 
+// hasValidReferences checks that the account and category in the payload belong to the user.
+func (e *createExpensePayload) hasValidReferences(c *Context) (bool, error) {
+	var err error
+	if e.account, err = e.validateTransactionAccount(c, "account_id", e.AccountID, e.currentAccountID); err != nil {
+		return false, err
+	}
+	if err := e.validateCategory(c, e.CategoryID); err != nil {
+		return false, err
+	}
+	return len(e.errs) == 0, nil
+}
+
+// apply copies the payload fields into the given expense. Currency of the
+// account is used if the payload doesn't have one and the kind of the expense
+// is kept if the payload doesn't have one.
+func (e *createExpensePayload) apply(expense *model.Expense) {
+	if expense.AccountID != e.AccountID {
+		expense.Account = nil
+	}
+	if expense.CategoryID != e.CategoryID {
+		expense.Category = nil
+	}
+	expense.AccountID = e.AccountID
+	expense.Date = e.Date
+	expense.CategoryID = e.CategoryID
+	expense.Amount = e.amount
+	expense.Title = e.Title
+	if e.Kind != "" {
+		expense.Kind = e.Kind
+	}
+	if e.Currency != "" {
+		expense.SetCurrency(e.Currency)
+	} else if e.account != nil {
+		expense.SetCurrency(e.account.Currency)
+	}
+	if e.Tags != nil {
+		expense.Tags = e.tags
+	}
+}
+
+// patchExpensePayload is used for partial updates of an expense. Only the
+// fields present in the request are validated and applied.
+type patchExpensePayload struct {
+	AccountID  *string          `json:"account_id"`
+	Date       *time.Time       `json:"date"`
+	CategoryID *string          `json:"category_id"`
+	Amount     *json.RawMessage `json:"amount"`
+	Currency   *string          `json:"currency"`
+	Title      *string          `json:"title"`
+	Kind       *string          `json:"kind"`
+	Tags       *[]string        `json:"tags"`
+	amount     model.Money
+	tags       []model.Tag
+	account    *model.ExpenseAccount
+	// currentAccountID is the account of the expense being patched.
+	currentAccountID string
+	payloadValidator
+}
+
+func (e *patchExpensePayload) isValid() bool {
+	e.errs = url.Values{}
+
+	if e.AccountID != nil && *e.AccountID == "" {
+		e.errs.Add("account_id", errorIsRequired)
+	}
+
+	if e.Date != nil && e.Date.IsZero() {
+		e.errs.Add("date", errorIsRequired)
+	}
+
+	if e.Amount != nil {
+		e.amount = e.validateAmount(*e.Amount)
+		if e.amount.IsNegative() {
+			e.errs.Add("amount", errorInvalidAmount)
+		}
+	}
+
+	if e.Currency != nil {
+		e.validateCurrency(*e.Currency)
+	}
+
+	if e.Title != nil && *e.Title == "" {
+		e.errs.Add("title", errorIsRequired)
+	}
+
+	if e.Kind != nil && !model.IsValidKind(*e.Kind) {
+		e.errs.Add("kind", errorInvalidChoice)
+	}
+
+	if e.Tags != nil {
+		e.tags = e.validateTags(*e.Tags)
+	}
+
+	return len(e.errs) == 0
+}
+
+// hasValidReferences checks that the account and category in the payload, if given, belong to the user.
+func (e *patchExpensePayload) hasValidReferences(c *Context) (bool, error) {
+	if e.AccountID != nil {
+		var err error
+		if e.account, err = e.validateTransactionAccount(c, "account_id", *e.AccountID, e.currentAccountID); err != nil {
+			return false, err
+		}
+	}
+	if e.CategoryID != nil {
+		if err := e.validateCategory(c, *e.CategoryID); err != nil {
+			return false, err
+		}
+	}
+	return len(e.errs) == 0, nil
+}
+
+// apply copies the fields present in the payload into the given expense.
+// Currency of the new account is used if the account is changed and the
+// payload doesn't have a currency.
+func (e *patchExpensePayload) apply(expense *model.Expense) {
+	accountChanged := e.AccountID != nil && *e.AccountID != expense.AccountID
+	if accountChanged {
+		expense.AccountID = *e.AccountID
+		expense.Account = nil
+	}
+	if e.Date != nil {
+		expense.Date = *e.Date
+	}
+	if e.CategoryID != nil && *e.CategoryID != expense.CategoryID {
+		expense.CategoryID = *e.CategoryID
+		expense.Category = nil
+	}
+	if e.Amount != nil {
+		expense.Amount = e.amount
+	}
+	if e.Currency != nil {
+		expense.Currency = *e.Currency
+	} else if accountChanged && e.account != nil {
+		expense.Currency = e.account.Currency
+	}
+	expense.Amount.Currency = expense.Currency
+	if e.Title != nil {
+		expense.Title = *e.Title
+	}
+	if e.Kind != nil {
+		expense.Kind = *e.Kind
+	}
+	if e.Tags != nil {
+		expense.Tags = e.tags
+	}
+}
+
+// validateAmount parses the amount and adds an error for amount field if it
+// is missing, zero or has more decimal places than model.Money can keep.
+func (p *payloadValidator) validateAmount(amount json.RawMessage) model.Money {
+	return p.validateAmountField("amount", amount)
+}
+
+// validateAmountField is validateAmount for an amount in the given field.
+func (p *payloadValidator) validateAmountField(field string, amount json.RawMessage) model.Money {
+	if len(amount) == 0 || string(amount) == "null" {
+		p.errs.Add(field, errorIsRequired)
+		return model.Money{}
+	}
+	var money model.Money
+	err := money.UnmarshalJSON(amount)
+	switch {
+	case err == model.ErrTooManyDecimals:
+		p.errs.Add(field, errorMaxDecimalPlaces)
+	case err != nil:
+		p.errs.Add(field, errorInvalidAmount)
+	case money.IsZero():
+		p.errs.Add(field, errorIsRequired)
+	}
+	return money
+}
+
+// validateBalance parses the balance in the given field, which can be zero or
+// negative unlike amounts. Missing balance is returned as zero.
+func (p *payloadValidator) validateBalance(field string, balance json.RawMessage) model.Money {
+	var money model.Money
+	err := money.UnmarshalJSON(balance)
+	switch {
+	case len(balance) == 0:
+	case err == model.ErrTooManyDecimals:
+		p.errs.Add(field, errorMaxDecimalPlaces)
+	case err != nil:
+		p.errs.Add(field, errorInvalidAmount)
+	}
+	return money
+}
+
+// validateTags adds an error for tags field if any of the names is empty or
+// too long. Returns the tags with the normalized names, without duplicates.
+// The tags are looked up or created by their name when the expense is saved.
+func (p *payloadValidator) validateTags(names []string) []model.Tag {
+	tags := []model.Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = model.NormalizeTagName(name)
+		switch {
+		case name == "":
+			p.errs.Add("tags", errorIsRequired)
+		case len(name) > model.TagNameMaxLength:
+			p.errs.Add("tags", errorMaxLength)
+		case !seen[name]:
+			seen[name] = true
+			tags = append(tags, model.Tag{Name: name})
+		}
+	}
+	return tags
+}
+
+// validateCurrency adds an error for currency field if it is not a valid currency code. Empty currency is allowed.
+func (p *payloadValidator) validateCurrency(currency string) {
+	if currency != "" && !model.IsValidCurrency(currency) {
+		p.errs.Add("currency", errorInvalidCurrency)
+	}
+}
+
+// validateAccount adds an error for account_id if the account with given
+// id doesn't exist or belongs to another user. Returns the account if it is valid.
+func (p *payloadValidator) validateAccount(c *Context, id string) (*model.ExpenseAccount, error) {
+	return p.validateAccountField(c, "account_id", id)
+}
+
+// validateAccountField is validateAccount for an account in the given field.
+func (p *payloadValidator) validateAccountField(c *Context, field string, id string) (*model.ExpenseAccount, error) {
+	if id == "" {
+		return nil, nil
+	}
+	account, err := c.Srv.Store.Expense().GetAccountByID(id)
+	if err == pg.ErrNoRows || (err == nil && account.UserID != c.User.ID) {
+		p.errs.Add(field, errorInvalidChoice)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// validateTransactionAccount is validateAccountField for the account of a
+// transaction. Archived accounts can't get new transactions, they are allowed
+// only if the transaction is already in the account with currentID.
+func (p *payloadValidator) validateTransactionAccount(c *Context, field string, id string, currentID string) (*model.ExpenseAccount, error) {
+	account, err := p.validateAccountField(c, field, id)
+	if account != nil && account.Archived && account.ID != currentID {
+		p.errs.Add(field, errorAccountArchived)
+	}
+	return account, err
+}
+
+// validateCategory adds an error for category_id if the category with given
+// id doesn't exist or belongs to another user. Empty id is allowed.
+func (p *payloadValidator) validateCategory(c *Context, id string) error {
+	if id == "" {
+		return nil
+	}
+	category, err := c.Srv.Store.Expense().GetCategoryByID(id)
+	if err == pg.ErrNoRows || (err == nil && category.UserID != c.User.ID) {
+		p.errs.Add("category_id", errorInvalidChoice)
+		return nil
+	}
+	return err
+}
+
 type createExpenseAccountPayload struct {
-	Name string
+	Name           string
+	Currency       string
+	OpeningBalance json.RawMessage `json:"opening_balance"`
+	OpeningDate    time.Time       `json:"opening_date"`
+	openingBalance model.Money
 	payloadValidator
 }
 
 func (p *createExpenseAccountPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Name == "" {
+		p.errs.Add("name", errorIsRequired)
+	}
+	p.validateCurrency(p.Currency)
+	p.openingBalance = p.validateBalance("opening_balance", p.OpeningBalance)
+	return len(p.errs) == 0
+}
+
+// apply copies the payload fields into the given account, defaulting the
+// currency to the base currency of the user.
+func (p *createExpenseAccountPayload) apply(c *Context, account *model.ExpenseAccount) {
+	account.Name = p.Name
+	account.Currency = p.Currency
+	if account.Currency == "" {
+		account.Currency = c.User.BaseCurrency
+	}
+	account.OpeningBalance = model.NewMoney(p.openingBalance.Amount, account.Currency)
+	account.OpeningDate = time.Time{}
+	if !p.OpeningDate.IsZero() {
+		account.OpeningDate = model.TruncateDate(p.OpeningDate)
+	}
+}
+
+// updateExpenseAccountPayload renames, archives and changes the opening
+// balance of an account. Currency of an account can't be changed as the
+// amounts of its transactions are in that currency.
+// The fields other than the name are optional and kept as they are when
+// missing.
+type updateExpenseAccountPayload struct {
+	Name           string          `json:"name"`
+	Archived       *bool           `json:"archived"`
+	OpeningBalance json.RawMessage `json:"opening_balance"`
+	OpeningDate    *time.Time      `json:"opening_date"`
+	openingBalance model.Money
+	payloadValidator
+}
+
+func (p *updateExpenseAccountPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Name == "" {
+		p.errs.Add("name", errorIsRequired)
+	}
+	if p.hasOpeningBalance() {
+		p.openingBalance = p.validateBalance("opening_balance", p.OpeningBalance)
+	}
+	return len(p.errs) == 0
+}
+
+func (p *updateExpenseAccountPayload) hasOpeningBalance() bool {
+	return len(p.OpeningBalance) > 0 && string(p.OpeningBalance) != "null"
+}
+
+// apply copies the fields present in the payload into the given account.
+func (p *updateExpenseAccountPayload) apply(account *model.ExpenseAccount) {
+	account.Name = p.Name
+	if p.Archived != nil {
+		account.Archived = *p.Archived
+	}
+	if p.hasOpeningBalance() {
+		account.OpeningBalance = model.NewMoney(p.openingBalance.Amount, account.Currency)
+	}
+	if p.OpeningDate != nil {
+		account.OpeningDate = time.Time{}
+		if !p.OpeningDate.IsZero() {
+			account.OpeningDate = model.TruncateDate(*p.OpeningDate)
+		}
+	}
+}
+
+// reconcilePayload is the statement balance of an account at the end of Date.
+type reconcilePayload struct {
+	Date    time.Time       `json:"date"`
+	Balance json.RawMessage `json:"balance"`
+	balance model.Money
+	payloadValidator
+}
+
+func (p *reconcilePayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Date.IsZero() {
+		p.errs.Add("date", errorIsRequired)
+	}
+	if len(p.Balance) == 0 || string(p.Balance) == "null" {
+		p.errs.Add("balance", errorIsRequired)
+	} else {
+		p.balance = p.validateBalance("balance", p.Balance)
+	}
+	return len(p.errs) == 0
+}
+
+type expenseCategoryPayload struct {
+	Name string `json:"name"`
+	payloadValidator
+}
+
+func (p *expenseCategoryPayload) isValid() bool {
 	p.errs = url.Values{}
 	if p.Name == "" {
 		p.errs.Add("name", errorIsRequired)
@@ -7,10 +7,13 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,38 +26,74 @@ func setupMockStoreData(t *testing.T) *MockStore {
 		t.Fatal(err)
 	}
 	expectedUser := model.User{
-		ID:        "b89505a4-a451-45e5-912e-4ef8c1441be6",
-		Email:     "testuser1@gmail.com",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Password:  hashedPassword,
-		Active:    true,
+		ID:           "b89505a4-a451-45e5-912e-4ef8c1441be6",
+		Email:        "testuser1@gmail.com",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		Password:     hashedPassword,
+		Active:       true,
+		BaseCurrency: "INR",
 	}
 
 	eAccount := &model.ExpenseAccount{
-		ID:   "111",
-		Name: "Grocery",
+		ID:       "111",
+		Name:     "Grocery",
+		Currency: "EUR",
+		UserID:   expectedUser.ID,
+	}
+	eAccount2 := &model.ExpenseAccount{
+		ID:     "112",
+		Name:   "Restaurant",
+		UserID: expectedUser.ID,
+	}
+	otherAccount := &model.ExpenseAccount{
+		ID:     "113",
+		Name:   "Grocery",
+		UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00",
 	}
 	eCategory := &model.ExpenseCategory{
-		ID:   "121",
-		Name: "Category1",
+		ID:     "121",
+		Name:   "Category1",
+		UserID: expectedUser.ID,
+	}
+	otherCategory := &model.ExpenseCategory{
+		ID:     "122",
+		Name:   "Category2",
+		UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00",
 	}
 	expense := model.Expense{
-		Amount:     100,
+		Amount:     model.NewMoney(10000, ""),
 		AccountID:  eAccount.ID,
 		Account:    eAccount,
 		Category:   eCategory,
 		CategoryID: eCategory.ID,
 		ID:         "9123",
+		UserID:     expectedUser.ID,
+		Title:      "Nesto",
+	}
+	otherExpense := model.Expense{
+		Amount: model.NewMoney(5000, ""),
+		ID:     "9124",
+		UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00",
 	}
 
-	t1 := model.AuthToken{Key: "1234", UserID: expectedUser.ID, User: &expectedUser}
+	t1 := model.AuthToken{ID: "s1", Key: "1234", Active: true, UserID: expectedUser.ID, User: &expectedUser}
 
 	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
 	testStore.tokenStore.On("Create", mock.Anything).Return(nil)
 	testStore.userStore.On("GetUserByEmail", expectedUser.Email).Return(&expectedUser, nil)
-	testStore.expenseStore.On("GetByID", "9123").Return(expense, nil)
+	testStore.expenseStore.On("GetByID", "9123").Return(&expense, nil)
+	testStore.expenseStore.On("GetByID", "9124").Return(&otherExpense, nil)
+	testStore.expenseStore.On("GetByID", "9125").Return((*model.Expense)(nil), pg.ErrNoRows)
+	testStore.expenseStore.On("GetAccountByID", "111").Return(eAccount, nil)
+	testStore.expenseStore.On("GetAccountByID", "112").Return(eAccount2, nil)
+	testStore.expenseStore.On("GetAccountByID", "113").Return(otherAccount, nil)
+	testStore.expenseStore.On("GetAccountByID", "114").Return((*model.ExpenseAccount)(nil), pg.ErrNoRows)
+	testStore.expenseStore.On("GetCategoryByID", "121").Return(eCategory, nil)
+	testStore.expenseStore.On("GetCategoryByID", "122").Return(otherCategory, nil)
+	testStore.expenseStore.On("GetCategoryByID", "123").Return((*model.ExpenseCategory)(nil), pg.ErrNoRows)
 	testStore.userStore.On("Store", mock.Anything).Return(nil)
+	testStore.rules.On("GetRules", expectedUser.ID).Return([]model.Rule{}, nil)
 	return testStore
 }
 
@@ -130,5 +169,624 @@ func TestGetExpense(t *testing.T) {
 	assert.Equal(t, "111", expense.AccountID)
 	assert.Equal(t, "b89505a4-a451-45e5-912e-4ef8c1441be6", expense.UserID)
 	assert.Equal(t, "121", expense.CategoryID)
-	assert.Equal(t, 100.0, expense.Amount)
+	assert.Equal(t, model.NewMoney(10000, ""), expense.Amount)
+}
+
+func TestExpenseDetail(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	// Fetch an expense owned by the user.
+	req, err := http.NewRequest("GET", "/api/expenses/9123/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	expense := model.Expense{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "9123", expense.ID)
+	assert.Equal(t, "Nesto", expense.Title)
+
+	// Expense of another user and non existing expense.
+	for id, status := range map[string]int{"9124": http.StatusUnauthorized, "9125": http.StatusNotFound} {
+		for _, method := range []string{"GET", "PUT", "PATCH", "DELETE"} {
+			req, err = http.NewRequest(method, "/api/expenses/"+id+"/", bytes.NewBufferString("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Authorization", "1234")
+			recorder = httptest.NewRecorder()
+			srv.Routes.Root.ServeHTTP(recorder, req)
+			assert.Equal(t, status, recorder.Code, "%s %s", method, id)
+		}
+	}
+
+	// PUT requires all the fields.
+	req, err = http.NewRequest("PUT", "/api/expenses/9123/", bytes.NewBufferString(`{"title": "Lulu"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	reqBody := map[string]interface{}{
+		"amount":      120.0,
+		"title":       "Lulu",
+		"category_id": "121",
+		"account_id":  "112",
+		"date":        "2006-01-02T15:04:05Z",
+	}
+	jsonData, _ := json.Marshal(reqBody)
+	req, err = http.NewRequest("PUT", "/api/expenses/9123/", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	expense = model.Expense{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Lulu", expense.Title)
+	assert.Equal(t, "112", expense.AccountID)
+	assert.Nil(t, expense.Account, "Stale account is returned after changing account_id.")
+	assert.Equal(t, model.NewMoney(12000, ""), expense.Amount)
+
+	// PATCH only touches the given fields.
+	req, err = http.NewRequest("PATCH", "/api/expenses/9123/", bytes.NewBufferString(`{"title": ""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Income is recorded with its kind, not with a negative amount.
+	req, err = http.NewRequest("PATCH", "/api/expenses/9123/", bytes.NewBufferString(`{"amount": -80}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req, err = http.NewRequest("PATCH", "/api/expenses/9123/", bytes.NewBufferString(`{"amount": 80}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	expense = model.Expense{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.NewMoney(8000, ""), expense.Amount)
+	assert.Equal(t, "Lulu", expense.Title)
+
+	req, err = http.NewRequest("DELETE", "/api/expenses/9123/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// Make sure the detail routes are not shadowing accounts routes.
+	testStore.expenseStore.On("GetExpenseAccounts", "b89505a4-a451-45e5-912e-4ef8c1441be6", false).Return([]model.ExpenseAccount(nil), nil)
+	testStore.expenseStore.On("GetAccountBalances", "b89505a4-a451-45e5-912e-4ef8c1441be6", mock.Anything).Return([]model.AccountBalance(nil), nil)
+	req, err = http.NewRequest("GET", "/api/expenses/accounts/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestExpenseCategories(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("POST", "/api/expenses/categories/", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req, err = http.NewRequest("POST", "/api/expenses/categories/", bytes.NewBufferString(`{"name": "Travel"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	category := model.ExpenseCategory{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &category); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, category.ID)
+	assert.Equal(t, "Travel", category.Name)
+	assert.Equal(t, "b89505a4-a451-45e5-912e-4ef8c1441be6", category.UserID)
+
+	req, err = http.NewRequest("GET", "/api/expenses/categories/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "[]", recorder.Body.String())
+
+	req, err = http.NewRequest("PUT", "/api/expenses/categories/121/", bytes.NewBufferString(`{"name": "Food"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	category = model.ExpenseCategory{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &category); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Food", category.Name)
+
+	for id, status := range map[string]int{"121": http.StatusNoContent, "122": http.StatusUnauthorized, "123": http.StatusNotFound} {
+		req, err = http.NewRequest("DELETE", "/api/expenses/categories/"+id+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder = httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, status, recorder.Code, "Deleting category %s", id)
+	}
+
+	// Expenses can't be created with categories of other users.
+	for _, categoryID := range []string{"122", "123"} {
+		reqBody := map[string]interface{}{
+			"amount":      100.0,
+			"title":       "Nesto",
+			"category_id": categoryID,
+			"account_id":  "111",
+			"date":        "2006-01-02T15:04:05Z",
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		req, err = http.NewRequest("POST", "/api/expenses/", bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder = httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response struct {
+			Errors map[string][]string
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"invalid_choice"}, response.Errors["category_id"])
+	}
+}
+
+func TestCreateExpenseWithInvalidAccount(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	for _, accountID := range []string{"113", "114"} {
+		reqBody := map[string]interface{}{
+			"amount":      100.0,
+			"title":       "Nesto",
+			"category_id": "121",
+			"account_id":  accountID,
+			"date":        "2006-01-02T15:04:05Z",
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		req, err := http.NewRequest("POST", "/api/expenses/", bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response struct {
+			Errors map[string][]string
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"invalid_choice"}, response.Errors["account_id"])
+		assert.NotContains(t, response.Errors, "category_id")
+	}
+
+	// Moving an existing expense to another user's account is not allowed either.
+	req, err := http.NewRequest("PATCH", "/api/expenses/9123/", bytes.NewBufferString(`{"account_id": "113"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestCreateExpenseAmountValidation(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		amount   interface{}
+		expected []string
+	}{
+		{12.345, []string{"max_decimal_places"}},
+		{"abc", []string{"invalid_amount"}},
+		{-12.3, []string{"invalid_amount"}},
+		{0, []string{"is_required"}},
+		{"12.30", nil},
+		{12.3, nil},
+	}
+	for _, tc := range cases {
+		reqBody := map[string]interface{}{
+			"amount":      tc.amount,
+			"title":       "Nesto",
+			"category_id": "121",
+			"account_id":  "111",
+			"date":        "2006-01-02T15:04:05Z",
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		req, err := http.NewRequest("POST", "/api/expenses/", bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		if tc.expected == nil {
+			assert.Equal(t, http.StatusOK, recorder.Code, "Amount %v", tc.amount)
+			expense := model.Expense{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, model.NewMoney(1230, ""), expense.Amount)
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "Amount %v", tc.amount)
+		var response struct {
+			Errors map[string][]string
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.expected, response.Errors["amount"], "Amount %v", tc.amount)
+	}
+}
+
+func TestExpenseCurrency(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		currency string
+		status   int
+		expected string
+	}{
+		{"", http.StatusOK, "EUR"},
+		{"USD", http.StatusOK, "USD"},
+		{"usd", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		reqBody := map[string]interface{}{
+			"amount":     "10",
+			"title":      "Nesto",
+			"account_id": "111",
+			"currency":   tc.currency,
+			"date":       "2006-01-02T15:04:05Z",
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		req, err := http.NewRequest("POST", "/api/expenses/", bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, "Currency %q", tc.currency)
+		if tc.status != http.StatusOK {
+			continue
+		}
+		expense := model.Expense{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.expected, expense.Currency)
+	}
+}
+
+func TestPatchExpenseAccountCurrency(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	expense := model.Expense{ID: "9130", AccountID: "112", Amount: model.NewMoney(1000, ""), Title: "Lulu", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}
+	testStore.expenseStore.On("GetByID", "9130").Return(&expense, nil)
+
+	cases := []struct {
+		payload  string
+		expected string
+	}{
+		// Currency given in the payload is kept.
+		{`{"account_id": "111", "currency": "USD"}`, "USD"},
+		// Moving to another account takes its currency.
+		{`{"account_id": "112"}`, ""},
+		{`{"account_id": "111"}`, "EUR"},
+		// Currency is left alone when the account doesn't change.
+		{`{"account_id": "111", "title": "Nesto"}`, "EUR"},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("PATCH", "/api/expenses/9130/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.payload)
+		patched := model.Expense{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &patched); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.expected, patched.Currency, tc.payload)
+	}
+}
+
+func TestGetExpensesConverted(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	date := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+	expenses := []model.Expense{
+		{ID: "1", Amount: model.NewMoney(1000, "EUR"), Currency: "EUR", Date: date},
+		{ID: "2", Amount: model.NewMoney(1000, "INR"), Currency: "INR", Date: date},
+		{ID: "3", Amount: model.NewMoney(1000, "USD"), Currency: "USD", Date: date},
+		{ID: "4", Amount: model.NewMoney(1000, ""), Date: date},
+	}
+	testStore.expenseStore.On("GetExpenses", "b89505a4-a451-45e5-912e-4ef8c1441be6", mock.Anything).Return(expenses, nil)
+	testStore.rateStore.On("FindRate", "EUR", "INR", date).Return(&model.ExchangeRate{Rate: 79.5}, nil)
+	testStore.rateStore.On("FindRate", "USD", "INR", date).Return((*model.ExchangeRate)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/expenses/?convert=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var envelope struct {
+		Items []struct {
+			ID              string       `json:"id"`
+			ConvertedAmount *model.Money `json:"converted_amount"`
+		}
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	response := envelope.Items
+	assert.Equal(t, 4, len(response))
+	assert.Equal(t, "795.00", response[0].ConvertedAmount.String())
+	assert.Equal(t, "10.00", response[1].ConvertedAmount.String())
+	assert.Nil(t, response[2].ConvertedAmount, "Converted amount without exchange rate.")
+	assert.Nil(t, response[3].ConvertedAmount, "Converted amount without currency.")
+	testStore.rateStore.AssertExpectations(t)
+}
+
+func TestGetExpensesInvalidFilter(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/expenses/?from=yesterday&sort=random&convert=maybe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var response struct {
+		Errors map[string][]string
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"invalid_date"}, response.Errors["from"])
+	assert.Equal(t, []string{"invalid_choice"}, response.Errors["sort"])
+	assert.Equal(t, []string{"invalid_boolean"}, response.Errors["convert"])
+}
+
+func TestGetExpensesEnvelope(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	date := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+	expenses := []model.Expense{
+		{ID: "2", Amount: model.NewMoney(1000, "INR"), Date: date},
+		{ID: "1", Amount: model.NewMoney(1000, "INR"), Date: date},
+	}
+	testStore.expenseStore.On("GetExpenses", userID, mock.Anything).Return(expenses, nil)
+	testStore.expenseStore.On("CountExpenses", userID, mock.Anything).Return(5, nil)
+	srv := NewServer(testStore)
+
+	var response struct {
+		Items      []model.Expense
+		NextCursor *string `json:"next_cursor"`
+		Total      *int
+	}
+	get := func(url string) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		response.Items, response.NextCursor, response.Total = nil, nil, nil
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get("/api/expenses/?limit=2&total=true")
+	assert.Equal(t, 2, len(response.Items))
+	if assert.NotNil(t, response.NextCursor, "Next cursor is not returned for a full page.") {
+		assert.NotEmpty(t, *response.NextCursor)
+	}
+	if assert.NotNil(t, response.Total) {
+		assert.Equal(t, 5, *response.Total)
+	}
+
+	get("/api/expenses/?limit=3")
+	assert.Nil(t, response.NextCursor, "Next cursor is returned for the last page.")
+	assert.Nil(t, response.Total, "Total is returned without asking.")
+
+	get("/api/expenses/?sort=amount&limit=2")
+	assert.Nil(t, response.NextCursor, "Next cursor is returned for order without cursor support.")
+}
+
+func TestGetExpensesEmptyList(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.expenseStore.On("GetExpenses", "b89505a4-a451-45e5-912e-4ef8c1441be6", mock.Anything).Return([]model.Expense(nil), nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/expenses/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"items": [], "next_cursor": null}`, recorder.Body.String())
+}
+
+func TestCreateIncome(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		url      string
+		kind     string
+		status   int
+		expected string
+	}{
+		{"/api/expenses/", "", http.StatusOK, model.KindExpense},
+		{"/api/income/", "", http.StatusOK, model.KindIncome},
+		{"/api/expenses/", "income", http.StatusOK, model.KindIncome},
+		{"/api/expenses/", "refund", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		reqBody := map[string]interface{}{
+			"amount":     "10",
+			"title":      "Salary",
+			"account_id": "111",
+			"kind":       tc.kind,
+			"date":       "2006-01-02T15:04:05Z",
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		req, err := http.NewRequest("POST", tc.url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, "%s kind %q", tc.url, tc.kind)
+		if tc.status != http.StatusOK {
+			assert.JSONEq(t, `{"errors":{"kind":["invalid_choice"]}}`, recorder.Body.String())
+			continue
+		}
+		expense := model.Expense{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.expected, expense.Kind, "%s kind %q", tc.url, tc.kind)
+	}
+}
+
+func TestGetIncome(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	income := []model.Expense{{ID: "1", Kind: model.KindIncome}}
+	testStore.expenseStore.On("GetExpenses", "b89505a4-a451-45e5-912e-4ef8c1441be6", mock.Anything).Return(income, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/income/?kind=expense", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	filter := testStore.expenseStore.Calls[0].Arguments.Get(1).(store.ExpenseFilter)
+	expected := store.ExpenseFilter{}
+	expected.ParseURLValues(url.Values{})
+	expected.SetKind(model.KindIncome)
+	assert.Equal(t, expected, filter, "Listing is not limited to income.")
+}
+
+func TestExportExpenses(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	rows := []model.ExpenseExportRow{
+		{ID: "1", Date: time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC), Kind: model.KindExpense, Title: "Lunch, with \"team\"",
+			Amount: model.NewMoney(1250, "EUR"), Currency: "EUR", AccountName: "Cash", CategoryName: "Food", Tags: "team, work"},
+		{ID: "2", Date: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), Kind: model.KindIncome, Title: "Salary",
+			Amount: model.NewMoney(500000, "EUR"), Currency: "EUR", AccountName: "Bank", Reconciled: true},
+	}
+	testStore.expenseStore.On("ExportExpenses", userID, mock.Anything).Return(rows, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/expenses/export.csv?all_time=1&kind=all", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	expected := "date,kind,title,amount,currency,account,category,tags,reconciled,id\n" +
+		"2019-01-02,expense,\"Lunch, with \"\"team\"\"\",12.50,EUR,Cash,Food,\"team, work\",false,1\n" +
+		"2019-01-03,income,Salary,5000.00,EUR,Bank,,,true,2\n"
+	assert.Equal(t, expected, recorder.Body.String())
+
+	req, err = http.NewRequest("GET", "/api/expenses/export.csv?sort=colour", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors":{"sort":["invalid_choice"]}}`, recorder.Body.String())
 }
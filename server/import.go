@@ -0,0 +1,154 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/statement"
+)
+
+// maxImportSize is the maximum size of an uploaded statement in bytes.
+const maxImportSize = 5 << 20
+
+func (srv *Server) InitImportAPIs() {
+	srv.Routes.Expenses.Handle("/import/", srv.ApiWithTokenValidation(importExpenses)).Methods("POST")
+}
+
+// importItem is an expense read from the statement in the import preview.
+// DuplicateOf is the id of the existing expense it seems to duplicate.
+type importItem struct {
+	Line        int            `json:"line"`
+	Expense     *model.Expense `json:"expense"`
+	DuplicateOf *string        `json:"duplicate_of"`
+}
+
+// importExpenses reads the transactions of the bank statement in the file
+// field of the multipart form into the account. Responds with the preview of
+// the expenses along with the duplicates of existing ones, unless confirm is
+// set in which case the expenses are saved. Duplicates are left out unless
+// include_duplicates is set.
+func importExpenses(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if r.ContentLength > maxImportSize {
+		writeFileError(errorMaxSize, w)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, _, err := r.FormFile("file")
+	switch {
+	case err == http.ErrMissingFile || err == http.ErrNotMultipart:
+		writeFileError(errorIsRequired, w)
+		return
+	case err != nil:
+		log.Println("Error in reading the uploaded statement: ", err.Error())
+		writeFileError(errorMaxSize, w)
+		return
+	}
+	defer file.Close()
+
+	payload := &importPayload{}
+	payload.load(r.MultipartForm.Value)
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating import references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	transactions, err := statement.Parse(payload.Format, file, payload.options())
+	if lineErr, ok := err.(*statement.LineError); ok {
+		res := payloadValidator{errs: map[string][]string{"file": {lineErr.Code}}}.getErrorMessage()
+		res["line"] = lineErr.Line
+		writeJSONResponse(res, http.StatusBadRequest, w)
+		return
+	} else if err != nil {
+		log.Println("Error in parsing the statement: ", err.Error())
+		writeFileError(statement.ErrorInvalidFile, w)
+		return
+	}
+
+	// Rules are applied before the default category so that they can
+	// categorise the expenses.
+	expenses := make([]model.Expense, len(transactions))
+	pointers := make([]*model.Expense, len(transactions))
+	for i, t := range transactions {
+		expenses[i] = t.Expense(payload.account)
+		pointers[i] = &expenses[i]
+	}
+	if err := applyUserRules(c, pointers...); err != nil {
+		log.Println("Error in applying rules to imported expenses: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	for i := range expenses {
+		if expenses[i].CategoryID == "" {
+			expenses[i].CategoryID = payload.CategoryID
+		}
+	}
+	duplicates, err := findImportDuplicates(c, payload.account, expenses)
+	if err != nil {
+		log.Println("Error in finding duplicates of imported expenses: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+
+	if !payload.Confirm {
+		items := make([]importItem, len(expenses))
+		count := 0
+		for i := range expenses {
+			items[i] = importItem{Line: transactions[i].Line, Expense: &expenses[i]}
+			if duplicates[i] != "" {
+				items[i].DuplicateOf = &duplicates[i]
+				count++
+			}
+		}
+		writeJSON(map[string]interface{}{"items": items, "duplicates": count}, http.StatusOK, w)
+		return
+	}
+
+	imported := []model.Expense{}
+	for i := range expenses {
+		if duplicates[i] == "" || payload.IncludeDuplicates {
+			imported = append(imported, expenses[i])
+		}
+	}
+	if len(imported) > 0 {
+		if err := c.Srv.Store.Expense().StoreExpenses(imported); err != nil {
+			log.Println("Error in saving imported expenses: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+			return
+		}
+	}
+	log.Printf("Imported %d expenses into account %s", len(imported), payload.account.ID)
+	res := map[string]interface{}{"items": imported, "imported": len(imported), "skipped": len(expenses) - len(imported)}
+	writeJSON(res, http.StatusCreated, w)
+}
+
+// findImportDuplicates returns the id of the existing expense in the account
+// each of the imported expenses duplicates, or an empty string if it is new.
+func findImportDuplicates(c *Context, account *model.ExpenseAccount, expenses []model.Expense) ([]string, error) {
+	if len(expenses) == 0 {
+		return nil, nil
+	}
+	from, to := expenses[0].Date, expenses[0].Date
+	for _, expense := range expenses {
+		if expense.Date.Before(from) {
+			from = expense.Date
+		}
+		if expense.Date.After(to) {
+			to = expense.Date
+		}
+	}
+	existing, err := c.Srv.Store.Expense().GetAccountExpenses(account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return statement.FindDuplicates(expenses, existing), nil
+}
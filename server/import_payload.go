@@ -0,0 +1,120 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/statement"
+	"github.com/ragsagar/wolff/store"
+)
+
+// importPayload is read from the fields of the multipart form uploading a
+// bank statement. Column fields map the columns of CSV files using the names
+// in the header row, either amount_column or debit_column and credit_column
+// should be given.
+type importPayload struct {
+	Format            string
+	AccountID         string
+	CategoryID        string
+	DateOrder         string
+	DecimalComma      bool
+	DateColumn        string
+	AmountColumn      string
+	DebitColumn       string
+	CreditColumn      string
+	TitleColumn       string
+	Delimiter         rune
+	Confirm           bool
+	IncludeDuplicates bool
+	account           *model.ExpenseAccount
+	payloadValidator
+}
+
+// load reads the payload from the form values. Errors of the values which
+// can't be parsed are reported by isValid.
+func (p *importPayload) load(values url.Values) {
+	p.errs = url.Values{}
+	p.Format = values.Get("format")
+	p.AccountID = values.Get("account_id")
+	p.CategoryID = values.Get("category_id")
+	p.DateOrder = values.Get("date_order")
+	p.DateColumn = values.Get("date_column")
+	p.AmountColumn = values.Get("amount_column")
+	p.DebitColumn = values.Get("debit_column")
+	p.CreditColumn = values.Get("credit_column")
+	p.TitleColumn = values.Get("title_column")
+	var ok bool
+	if p.Delimiter, ok = statement.ParseDelimiter(values.Get("delimiter")); !ok {
+		p.errs.Add("delimiter", errorInvalidChoice)
+	}
+	p.DecimalComma = p.parseBool(values, "decimal_comma")
+	p.Confirm = p.parseBool(values, "confirm")
+	p.IncludeDuplicates = p.parseBool(values, "include_duplicates")
+}
+
+func (p *importPayload) parseBool(values url.Values, field string) bool {
+	value := values.Get(field)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.errs.Add(field, store.ErrorInvalidBoolean)
+	}
+	return b
+}
+
+func (p *importPayload) isValid() bool {
+	if p.errs == nil {
+		p.errs = url.Values{}
+	}
+	if p.Format == "" {
+		p.errs.Add("format", errorIsRequired)
+	} else if !statement.IsValidFormat(p.Format) {
+		p.errs.Add("format", errorInvalidChoice)
+	}
+	if p.AccountID == "" {
+		p.errs.Add("account_id", errorIsRequired)
+	}
+	if p.DateOrder != "" && !statement.IsValidDateOrder(p.DateOrder) {
+		p.errs.Add("date_order", errorInvalidChoice)
+	}
+	if p.Format == statement.FormatCSV {
+		if p.DateColumn == "" {
+			p.errs.Add("date_column", errorIsRequired)
+		}
+		if p.AmountColumn == "" && p.DebitColumn == "" && p.CreditColumn == "" {
+			p.errs.Add("amount_column", errorIsRequired)
+		}
+	}
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that the account and category belong to the user.
+func (p *importPayload) hasValidReferences(c *Context) (bool, error) {
+	var err error
+	if p.account, err = p.validateTransactionAccount(c, "account_id", p.AccountID, ""); err != nil {
+		return false, err
+	}
+	if err := p.validateCategory(c, p.CategoryID); err != nil {
+		return false, err
+	}
+	return len(p.errs) == 0, nil
+}
+
+// options returns the options for parsing the statement.
+func (p *importPayload) options() statement.Options {
+	return statement.Options{
+		DateOrder:    p.DateOrder,
+		DecimalComma: p.DecimalComma,
+		Mapping: statement.CSVMapping{
+			Date:      p.DateColumn,
+			Amount:    p.AmountColumn,
+			Debit:     p.DebitColumn,
+			Credit:    p.CreditColumn,
+			Title:     p.TitleColumn,
+			Delimiter: p.Delimiter,
+		},
+	}
+}
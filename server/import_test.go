@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const importCSV = "Date,Description,Amount\n" +
+	"2019-01-02,Grocery store,-12.50\n" +
+	"2019-01-02,Coffee,-3.00\n" +
+	"2019-01-03,Salary,1500.00\n"
+
+func newImportRequest(t *testing.T, fields map[string]string, content string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	if content != "" {
+		part, err := writer.CreateFormFile("file", "statement")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/api/expenses/import/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Add("Authorization", "1234")
+	return req
+}
+
+func csvImportFields() map[string]string {
+	return map[string]string{
+		"format":        "csv",
+		"account_id":    "111",
+		"category_id":   "121",
+		"date_column":   "date",
+		"amount_column": "amount",
+		"title_column":  "description",
+	}
+}
+
+func TestImportExpensesPreview(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	from := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	existing := []model.Expense{
+		{ID: "9200", AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(300, "EUR"), Date: from},
+	}
+	testStore.expenseStore.On("GetAccountExpenses", "111", from, from.AddDate(0, 0, 1)).Return(existing, nil)
+	setupRules(testStore, model.Rule{ID: "151", TitleContains: "grocery", TagNames: []string{"food"}})
+	srv := NewServer(testStore)
+
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, newImportRequest(t, csvImportFields(), importCSV))
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response struct {
+		Items      []importItem
+		Duplicates int
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 3, len(response.Items)) {
+		grocery := response.Items[0]
+		assert.Equal(t, 2, grocery.Line)
+		assert.Equal(t, "Grocery store", grocery.Expense.Title)
+		assert.Equal(t, model.KindExpense, grocery.Expense.Kind)
+		assert.Equal(t, "12.50", grocery.Expense.Amount.String())
+		assert.Equal(t, "EUR", grocery.Expense.Currency)
+		assert.Equal(t, "121", grocery.Expense.CategoryID)
+		assert.True(t, grocery.Expense.HasTag("food"), "Rules are not applied to imported expenses.")
+		assert.Nil(t, grocery.DuplicateOf)
+		if assert.NotNil(t, response.Items[1].DuplicateOf) {
+			assert.Equal(t, "9200", *response.Items[1].DuplicateOf)
+		}
+		assert.Equal(t, model.KindIncome, response.Items[2].Expense.Kind)
+	}
+	assert.Equal(t, 1, response.Duplicates)
+	testStore.expenseStore.AssertNotCalled(t, "StoreExpenses", mock.Anything)
+}
+
+func TestImportExpensesConfirm(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	from := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	existing := []model.Expense{
+		{ID: "9200", AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(300, "EUR"), Date: from},
+	}
+	testStore.expenseStore.On("GetAccountExpenses", "111", from, from.AddDate(0, 0, 1)).Return(existing, nil)
+	testStore.expenseStore.On("StoreExpenses", mock.Anything).Return(nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		includeDuplicates string
+		imported          int
+		skipped           int
+	}{
+		{"", 2, 1},
+		{"true", 3, 0},
+	}
+	for _, tc := range cases {
+		fields := csvImportFields()
+		fields["confirm"] = "true"
+		fields["include_duplicates"] = tc.includeDuplicates
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, newImportRequest(t, fields, importCSV))
+		assert.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+		var response struct {
+			Items    []model.Expense
+			Imported int
+			Skipped  int
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.imported, response.Imported)
+		assert.Equal(t, tc.skipped, response.Skipped)
+		assert.Equal(t, tc.imported, len(response.Items))
+		for _, expense := range response.Items {
+			assert.NotEmpty(t, expense.ID)
+			assert.Equal(t, "b89505a4-a451-45e5-912e-4ef8c1441be6", expense.UserID)
+		}
+	}
+	testStore.expenseStore.AssertNumberOfCalls(t, "StoreExpenses", 2)
+}
+
+func TestImportExpensesInvalid(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	withFields := func(values map[string]string) map[string]string {
+		fields := csvImportFields()
+		for name, value := range values {
+			fields[name] = value
+		}
+		return fields
+	}
+	cases := []struct {
+		fields   map[string]string
+		content  string
+		expected string
+	}{
+		{csvImportFields(), "", `{"errors":{"file":["is_required"]}}`},
+		{map[string]string{"format": "csv"}, importCSV, `{"errors":{"account_id":["is_required"],"date_column":["is_required"],"amount_column":["is_required"]}}`},
+		{withFields(map[string]string{"format": "xls", "date_order": "ydm", "confirm": "sure"}), importCSV, `{"errors":{"format":["invalid_choice"],"date_order":["invalid_choice"],"confirm":["invalid_boolean"]}}`},
+		{withFields(map[string]string{"account_id": "113", "category_id": "122"}), importCSV, `{"errors":{"account_id":["invalid_choice"],"category_id":["invalid_choice"]}}`},
+		{withFields(map[string]string{"amount_column": "value"}), importCSV, `{"errors":{"file":["missing_column"]},"line":1}`},
+		{csvImportFields(), importCSV + "2019-01-04,Refund,abc\n", `{"errors":{"file":["invalid_amount"]},"line":5}`},
+		{withFields(map[string]string{"format": "ofx"}), importCSV, `{"errors":{"file":["invalid_file"]},"line":0}`},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, newImportRequest(t, tc.fields, tc.content))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, tc.expected)
+		assert.JSONEq(t, tc.expected, recorder.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/mail"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+func (srv *Server) InitPasswordAPIs() {
+	srv.Routes.Users.Handle("/password/", srv.ApiWithTokenValidation(changePassword)).Methods("POST")
+	srv.Routes.Users.Handle("/password/reset/", srv.OpenAPI(requestPasswordReset)).Methods("POST")
+	srv.Routes.Users.Handle("/password/reset/confirm/", srv.OpenAPI(confirmPasswordReset)).Methods("POST")
+}
+
+// changePassword sets the new password of the user after checking the old
+// one. Other sessions of the user are revoked.
+func changePassword(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &changePasswordPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if !fetchRequestUser(c, w) {
+		return
+	}
+	if !model.CheckPasswordHash(payload.OldPassword, c.User.Password) {
+		payload.errs.Add("old_password", errorInvalidPassword)
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	user := *c.User
+	if err := user.SetPassword(payload.NewPassword); err != nil {
+		log.Println("Error in hashing password: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	user.UpdatedAt = time.Now()
+	if err := c.Srv.Store.User().UpdateUser(user); err != nil {
+		log.Println("Error in updating password: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	*c.User = user
+
+	ids, err := c.Srv.Store.AuthToken().RevokeOthersForUser(user.ID, c.Token.ID)
+	if err != nil {
+		log.Println("Error in revoking tokens: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	c.Srv.denySessions(ids...)
+	writeJSON(map[string]int{"revoked": len(ids)}, http.StatusOK, w)
+}
+
+// requestPasswordReset mails a password reset token to the user with the
+// email. The response is the same whether the user exists or not.
+func requestPasswordReset(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if c.Srv.Mailer == nil {
+		writeJSONResponse(errorResponse(errorMailNotConfigured), http.StatusNotImplemented, w)
+		return
+	}
+	payload := &passwordResetPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	user, err := c.Srv.Store.User().GetUserByEmail(payload.Email)
+	if err == pg.ErrNoRows {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	} else if err != nil {
+		log.Println("Error in fetching user by email. ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+
+	// The reset is sent in the background so that the response time doesn't
+	// reveal whether the user exists.
+	go sendPasswordReset(c.Srv, user)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset saves a new password reset token of the user and emails
+// it. Errors are only logged as they are not reported to the client.
+func sendPasswordReset(srv *Server, user *model.User) {
+	reset, token := model.NewPasswordReset(user.ID, time.Now())
+	if err := srv.Store.User().StorePasswordReset(reset); err != nil {
+		log.Println("Error in saving password reset: ", err.Error())
+		return
+	}
+	if err := srv.Mailer.Send(passwordResetMessage(srv, user, token)); err != nil {
+		log.Println("Error in sending password reset email: ", err.Error())
+	}
+}
+
+// passwordResetMessage returns the email with the password reset token.
+func passwordResetMessage(srv *Server, user *model.User, token string) mail.Message {
+	link := token
+	if srv.PasswordResetURL != "" {
+		link = srv.PasswordResetURL + token
+	}
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"Use the following to set a new password for your account. It can be used once within %d minutes.\n\n"+
+		"%s\n\n"+
+		"If you didn't ask for it, you can ignore this email.\n",
+		user.Name, int(model.PasswordResetLifetime.Minutes()), link)
+	return mail.Message{To: user.Email, Subject: "Reset your password", Body: body}
+}
+
+// confirmPasswordReset sets the new password of the user of the reset
+// token. All the sessions of the user are revoked.
+func confirmPasswordReset(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &confirmPasswordResetPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	passwordHash, err := model.HashPassword(payload.NewPassword)
+	if err != nil {
+		log.Println("Error in hashing password: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	user, err := c.Srv.Store.User().ResetPassword(payload.Token, passwordHash, time.Now())
+	if err == store.ErrInvalidResetToken {
+		payload.errs.Add("token", errorInvalidToken)
+		payload.writeErrorMessage(w)
+		return
+	} else if err != nil {
+		log.Println("Error in resetting password: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+
+	ids, err := c.Srv.Store.AuthToken().RevokeAllForUser(user.ID)
+	if err != nil {
+		log.Println("Error in revoking tokens: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	c.Srv.denySessions(ids...)
+	w.WriteHeader(http.StatusNoContent)
+}
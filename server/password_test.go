@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/mail"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMailer passes the sent messages to the test through the channel.
+type testMailer struct {
+	sent chan mail.Message
+}
+
+func (m *testMailer) Send(msg mail.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func TestChangePassword(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.tokenStore.On("RevokeOthersForUser", "b89505a4-a451-45e5-912e-4ef8c1441be6", "s1").Return([]string{"s2"}, nil)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{}`, http.StatusBadRequest, `{"errors":{"old_password":["is_required"],"new_password":["is_required"]}}`},
+		{`{"old_password": "password", "new_password": "short"}`, http.StatusBadRequest, `{"errors":{"new_password":["min_length"]}}`},
+		{`{"old_password": "wrong", "new_password": "new password"}`, http.StatusBadRequest, `{"errors":{"old_password":["invalid_password"]}}`},
+		{`{"old_password": "password", "new_password": "new password"}`, http.StatusOK, `{"revoked": 1}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/users/password/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+	}
+	user, _ := testStore.userStore.GetUserByEmail("testuser1@gmail.com")
+	assert.True(t, model.CheckPasswordHash("new password", user.Password))
+	assert.True(t, srv.Denylist.IsRevoked("s2", user.UpdatedAt))
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.userStore.On("GetUserByEmail", "unknown@gmail.com").Return((*model.User)(nil), pg.ErrNoRows)
+	testStore.userStore.On("StorePasswordReset", "b89505a4-a451-45e5-912e-4ef8c1441be6").Return(nil)
+
+	request := func(payload string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/users/password/reset/", bytes.NewBufferString(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := request(`{"email": "testuser1@gmail.com"}`)
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+	assert.JSONEq(t, `{"error":"mail_not_configured"}`, recorder.Body.String())
+
+	mailer := &testMailer{sent: make(chan mail.Message, 10)}
+	srv.Mailer = mailer
+	srv.PasswordResetURL = "https://wolff.example.com/reset?token="
+	recorder = request(`{}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors":{"email":["is_required"]}}`, recorder.Body.String())
+
+	// Unknown emails get the same response without an email.
+	assert.Equal(t, http.StatusAccepted, request(`{"email": "unknown@gmail.com"}`).Code)
+
+	assert.Equal(t, http.StatusAccepted, request(`{"email": "testuser1@gmail.com"}`).Code)
+	select {
+	case msg := <-mailer.sent:
+		assert.Equal(t, "testuser1@gmail.com", msg.To)
+		assert.Contains(t, msg.Body, "https://wolff.example.com/reset?token=")
+	case <-time.After(time.Second):
+		t.Fatal("Password reset email is not sent.")
+	}
+	assert.Empty(t, mailer.sent, "Email is sent for an unknown user.")
+	testStore.userStore.AssertNumberOfCalls(t, "StorePasswordReset", 1)
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	user, _ := testStore.userStore.GetUserByEmail("testuser1@gmail.com")
+	testStore.userStore.On("ResetPassword", "valid").Return(user, nil).Once()
+	testStore.userStore.On("ResetPassword", "valid").Return((*model.User)(nil), store.ErrInvalidResetToken)
+	testStore.tokenStore.On("RevokeAllForUser", user.ID).Return([]string{"s1"}, nil)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{}`, http.StatusBadRequest, `{"errors":{"token":["is_required"],"new_password":["is_required"]}}`},
+		{`{"token": "valid", "new_password": "new password"}`, http.StatusNoContent, ``},
+		{`{"token": "valid", "new_password": "other password"}`, http.StatusBadRequest, `{"errors":{"token":["invalid_token"]}}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/users/password/reset/confirm/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		} else {
+			assert.Empty(t, strings.TrimSpace(recorder.Body.String()))
+		}
+	}
+	assert.True(t, model.CheckPasswordHash("new password", user.Password))
+	testStore.tokenStore.AssertCalled(t, "RevokeAllForUser", user.ID)
+}
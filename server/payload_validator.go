@@ -17,6 +17,25 @@ const errorJSONGeneration = "error_in_generating_json"
 const errorNotAuthorized = "not_authorized"
 const errorNotFound = "not_found"
 const errorEmailNotUnique = "email_not_unique"
+const errorInvalidChoice = "invalid_choice"
+const errorInvalidRange = "invalid_range"
+const errorInvalidAmount = "invalid_amount"
+const errorMaxDecimalPlaces = "max_decimal_places"
+const errorInvalidCurrency = "invalid_currency"
+const errorBaseCurrencyNotSet = "base_currency_not_set"
+const errorAccountInUse = "account_in_use"
+const errorAccountArchived = "account_archived"
+const errorNameNotUnique = "name_not_unique"
+const errorMaxSize = "max_size"
+const errorInvalidContentType = "invalid_content_type"
+const errorQuotaExceeded = "quota_exceeded"
+const errorAttachmentsDisabled = "attachments_not_configured"
+const errorInvalidPattern = "invalid_pattern"
+const errorInvalidRefreshToken = "invalid_refresh_token"
+const errorRefreshTokenReused = "refresh_token_reused"
+const errorInvalidPassword = "invalid_password"
+const errorInvalidToken = "invalid_token"
+const errorMailNotConfigured = "mail_not_configured"
 
 type payloadValidator struct {
 	errs url.Values
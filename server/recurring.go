@@ -0,0 +1,145 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+)
+
+func (srv *Server) InitRecurringExpenseAPIs() {
+	srv.Routes.Expenses.Handle("/recurring/", srv.ApiWithTokenValidation(getRecurringExpenses)).Methods("GET")
+	srv.Routes.Expenses.Handle("/recurring/", srv.ApiWithTokenValidation(createRecurringExpense)).Methods("POST")
+	srv.Routes.Expenses.Handle("/recurring/{id}/", srv.ApiWithTokenValidation(getRecurringExpense)).Methods("GET")
+	srv.Routes.Expenses.Handle("/recurring/{id}/", srv.ApiWithTokenValidation(updateRecurringExpense)).Methods("PUT")
+	srv.Routes.Expenses.Handle("/recurring/{id}/", srv.ApiWithTokenValidation(deleteRecurringExpense)).Methods("DELETE")
+}
+
+func getRecurringExpenses(c *Context, w http.ResponseWriter, r *http.Request) {
+	recurring, err := c.Srv.Store.Recurring().GetRecurringExpenses(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if recurring == nil {
+		recurring = []model.RecurringExpense{}
+	}
+	writeJSON(recurring, http.StatusOK, w)
+}
+
+// loadRecurringExpensePayload reads and validates the payload in the request
+// for creating a recurring expense or updating the current one. Writes the
+// error response and returns nil if it is not valid.
+func loadRecurringExpensePayload(c *Context, w http.ResponseWriter, r *http.Request, current *model.RecurringExpense) *recurringExpensePayload {
+	payload := &recurringExpensePayload{}
+	if current != nil {
+		payload.currentAccountID = current.AccountID
+	}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return nil
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating recurring expense references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return nil
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	return payload
+}
+
+func createRecurringExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := loadRecurringExpensePayload(c, w, r, nil)
+	if payload == nil {
+		return
+	}
+
+	recurring := &model.RecurringExpense{UserID: c.User.ID}
+	payload.apply(recurring)
+	if err := c.Srv.Store.Recurring().StoreRecurring(recurring); err != nil {
+		log.Println("Error in creating recurring expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully created recurring expense with id", recurring.ID)
+	writeRecurringExpense(recurring, http.StatusCreated, w)
+}
+
+// fetchUserRecurringExpense fetches the recurring expense with id in the url
+// and makes sure it belongs to the user. Writes the error response and
+// returns nil otherwise.
+func fetchUserRecurringExpense(c *Context, w http.ResponseWriter, r *http.Request) *model.RecurringExpense {
+	id := mux.Vars(r)["id"]
+	recurring, err := c.Srv.Store.Recurring().GetRecurringByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if recurring.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return recurring
+}
+
+func getRecurringExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	recurring := fetchUserRecurringExpense(c, w, r)
+	if recurring == nil {
+		return
+	}
+	writeRecurringExpense(recurring, http.StatusOK, w)
+}
+
+// updateRecurringExpense replaces the recurring expense and reschedules it.
+// Expenses already created from it are not changed.
+func updateRecurringExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	recurring := fetchUserRecurringExpense(c, w, r)
+	if recurring == nil {
+		return
+	}
+	payload := loadRecurringExpensePayload(c, w, r, recurring)
+	if payload == nil {
+		return
+	}
+
+	payload.apply(recurring)
+	recurring.Reschedule()
+	if err := c.Srv.Store.Recurring().UpdateRecurring(recurring); err != nil {
+		log.Println("Error in updating recurring expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeRecurringExpense(recurring, http.StatusOK, w)
+}
+
+func deleteRecurringExpense(c *Context, w http.ResponseWriter, r *http.Request) {
+	recurring := fetchUserRecurringExpense(c, w, r)
+	if recurring == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Recurring().DeleteRecurring(recurring); err != nil {
+		log.Println("Error in deleting recurring expense: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRecurringExpense(recurring *model.RecurringExpense, status int, w http.ResponseWriter) {
+	writeJSON(recurring, status, w)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+type recurringExpensePayload struct {
+	AccountID  string          `json:"account_id"`
+	CategoryID string          `json:"category_id"`
+	Amount     json.RawMessage `json:"amount"`
+	Currency   string          `json:"currency"`
+	Title      string          `json:"title"`
+	Frequency  string          `json:"frequency"`
+	DayOfMonth int             `json:"day_of_month"`
+	StartDate  time.Time       `json:"start_date"`
+	EndDate    *time.Time      `json:"end_date"`
+	amount     model.Money
+	account    *model.ExpenseAccount
+	// currentAccountID is the account of the recurring expense being updated.
+	currentAccountID string
+	payloadValidator
+}
+
+func (p *recurringExpensePayload) isValid() bool {
+	p.errs = url.Values{}
+
+	if p.AccountID == "" {
+		p.errs.Add("account_id", errorIsRequired)
+	}
+
+	p.amount = p.validateAmount(p.Amount)
+	p.validateCurrency(p.Currency)
+
+	if p.Title == "" {
+		p.errs.Add("title", errorIsRequired)
+	}
+
+	if p.Frequency == "" {
+		p.errs.Add("frequency", errorIsRequired)
+	} else if !model.IsValidFrequency(p.Frequency) {
+		p.errs.Add("frequency", errorInvalidChoice)
+	}
+
+	// Day of month is used only by monthly expenses.
+	if p.DayOfMonth < 0 || p.DayOfMonth > 31 || (p.DayOfMonth != 0 && p.Frequency != model.FrequencyMonthly) {
+		p.errs.Add("day_of_month", errorInvalidChoice)
+	}
+
+	if p.StartDate.IsZero() {
+		p.errs.Add("start_date", errorIsRequired)
+	} else if p.EndDate != nil && p.EndDate.Before(p.StartDate) {
+		p.errs.Add("end_date", errorInvalidRange)
+	}
+
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that the account and category in the payload belong to the user.
+func (p *recurringExpensePayload) hasValidReferences(c *Context) (bool, error) {
+	var err error
+	if p.account, err = p.validateTransactionAccount(c, "account_id", p.AccountID, p.currentAccountID); err != nil {
+		return false, err
+	}
+	if err := p.validateCategory(c, p.CategoryID); err != nil {
+		return false, err
+	}
+	return len(p.errs) == 0, nil
+}
+
+// apply copies the payload fields into the given recurring expense. Currency
+// of the account is used if the payload doesn't have one. The dates are
+// truncated to the day.
+func (p *recurringExpensePayload) apply(recurring *model.RecurringExpense) {
+	if recurring.AccountID != p.AccountID {
+		recurring.Account = nil
+	}
+	if recurring.CategoryID != p.CategoryID {
+		recurring.Category = nil
+	}
+	recurring.AccountID = p.AccountID
+	recurring.CategoryID = p.CategoryID
+	recurring.Amount = p.amount
+	recurring.Title = p.Title
+	if p.Currency != "" {
+		recurring.SetCurrency(p.Currency)
+	} else if p.account != nil {
+		recurring.SetCurrency(p.account.Currency)
+	}
+	recurring.Frequency = p.Frequency
+	recurring.DayOfMonth = p.DayOfMonth
+	recurring.StartDate = model.TruncateDate(p.StartDate)
+	recurring.EndDate = nil
+	if p.EndDate != nil {
+		endDate := model.TruncateDate(*p.EndDate)
+		recurring.EndDate = &endDate
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateRecurringExpense(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{
+			`{"account_id": "111", "category_id": "121", "amount": "950", "title": "Rent", "frequency": "monthly", "day_of_month": 31, "start_date": "2019-01-15T10:00:00Z"}`,
+			http.StatusCreated,
+			"",
+		},
+		{
+			`{"account_id": "113", "amount": "950", "title": "Rent", "frequency": "daily", "day_of_month": 5, "start_date": "2019-01-15T00:00:00Z"}`,
+			http.StatusBadRequest,
+			`{"errors":{"frequency":["invalid_choice"],"day_of_month":["invalid_choice"]}}`,
+		},
+		{
+			`{"account_id": "113", "amount": "950", "title": "Rent", "frequency": "weekly", "start_date": "2019-01-15T00:00:00Z"}`,
+			http.StatusBadRequest,
+			`{"errors":{"account_id":["invalid_choice"]}}`,
+		},
+		{
+			`{"account_id": "111", "amount": "950", "title": "Rent", "frequency": "weekly", "start_date": "2019-01-15T00:00:00Z", "end_date": "2019-01-01T00:00:00Z"}`,
+			http.StatusBadRequest,
+			`{"errors":{"end_date":["invalid_range"]}}`,
+		},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/recurring/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		}
+	}
+}
+
+func TestCreateRecurringExpenseSchedule(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	payload := `{"account_id": "111", "amount": "950", "title": "Rent", "frequency": "monthly", "day_of_month": 31, "start_date": "2019-02-15T10:00:00Z"}`
+	req, err := http.NewRequest("POST", "/api/expenses/recurring/", bytes.NewBufferString(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	var recurring model.RecurringExpense
+	if err := json.Unmarshal(recorder.Body.Bytes(), &recurring); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, time.Date(2019, 2, 15, 0, 0, 0, 0, time.UTC), recurring.StartDate, "Start date is not truncated to the day.")
+	assert.Equal(t, time.Date(2019, 2, 28, 0, 0, 0, 0, time.UTC), recurring.NextDate)
+	assert.Equal(t, "EUR", recurring.Currency, "Currency of the account is not used.")
+	assert.Equal(t, "b89505a4-a451-45e5-912e-4ef8c1441be6", recurring.UserID)
+}
+
+func TestRecurringExpenseDetail(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	recurring := &model.RecurringExpense{ID: "131", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6", Frequency: model.FrequencyWeekly}
+	otherRecurring := &model.RecurringExpense{ID: "132", UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}
+	testStore.recurring.On("GetRecurringByID", "131").Return(recurring, nil)
+	testStore.recurring.On("GetRecurringByID", "132").Return(otherRecurring, nil)
+	testStore.recurring.On("GetRecurringByID", "133").Return((*model.RecurringExpense)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/api/expenses/recurring/131/", http.StatusOK},
+		{"GET", "/api/expenses/recurring/132/", http.StatusUnauthorized},
+		{"GET", "/api/expenses/recurring/133/", http.StatusNotFound},
+		{"DELETE", "/api/expenses/recurring/132/", http.StatusUnauthorized},
+		{"DELETE", "/api/expenses/recurring/131/", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url)
+	}
+}
+
+func TestUpdateRecurringExpenseReschedules(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	recurring := &model.RecurringExpense{
+		ID:         "131",
+		UserID:     "b89505a4-a451-45e5-912e-4ef8c1441be6",
+		Frequency:  model.FrequencyMonthly,
+		DayOfMonth: 1,
+		StartDate:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		NextDate:   time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	testStore.recurring.On("GetRecurringByID", "131").Return(recurring, nil)
+	srv := NewServer(testStore)
+
+	payload := `{"account_id": "112", "amount": "950", "title": "Rent", "frequency": "monthly", "day_of_month": 15, "start_date": "2019-01-01T00:00:00Z"}`
+	req, err := http.NewRequest("PUT", "/api/expenses/recurring/131/", bytes.NewBufferString(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC), recurring.NextDate)
+	assert.Equal(t, 15, recurring.DayOfMonth)
+}
+
+func TestRunScheduler(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.recurring.On("CreateDueExpenses", mock.Anything).Return(2, nil)
+	srv := NewServer(testStore)
+
+	stop := make(chan struct{})
+	close(stop)
+	srv.RunScheduler(time.Hour, stop)
+	testStore.recurring.AssertNumberOfCalls(t, "CreateDueExpenses", 1)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+func (srv *Server) InitReportAPIs() {
+	srv.Routes.Reports.Handle("/categories/", srv.ApiWithTokenValidation(getCategoryReport)).Methods("GET")
+	srv.Routes.Reports.Handle("/accounts/", srv.ApiWithTokenValidation(getAccountReport)).Methods("GET")
+	srv.Routes.Reports.Handle("/{period:daily|weekly|monthly|yearly}/", srv.ApiWithTokenValidation(getPeriodReport)).Methods("GET")
+	srv.Routes.Reports.Handle("/cashflow/{period:daily|weekly|monthly|yearly}/", srv.ApiWithTokenValidation(getCashFlowReport)).Methods("GET")
+}
+
+// parseReportFilter reads the report filter from the request. Writes the
+// error response and returns false if the query parameters are not valid.
+func parseReportFilter(c *Context, w http.ResponseWriter, r *http.Request) (store.ReportFilter, bool) {
+	filter := store.ReportFilter{}
+	errs := filter.ParseURLValues(r.URL.Query())
+	convert := false
+	if value := r.URL.Query().Get("convert"); value != "" {
+		var err error
+		if convert, err = strconv.ParseBool(value); err != nil {
+			errs.Add("convert", store.ErrorInvalidBoolean)
+		}
+	}
+	if len(errs) > 0 {
+		payloadValidator{errs: errs}.writeErrorMessage(w)
+		return filter, false
+	}
+	if convert {
+		if !fetchRequestUser(c, w) {
+			return filter, false
+		}
+		if c.User.BaseCurrency == "" {
+			writeJSONResponse(errorResponse(errorBaseCurrencyNotSet), http.StatusBadRequest, w)
+			return filter, false
+		}
+		filter.Currency = c.User.BaseCurrency
+	}
+	return filter, true
+}
+
+func writeReport(entries []model.ReportEntry, err error, w http.ResponseWriter) {
+	if err != nil {
+		log.Println("Error in generating report: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if entries == nil {
+		entries = []model.ReportEntry{}
+	}
+	writeJSON(entries, http.StatusOK, w)
+}
+
+func getCategoryReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReportFilter(c, w, r)
+	if !ok {
+		return
+	}
+	entries, err := c.Srv.Store.Report().TotalsByCategory(c.User.ID, filter)
+	writeReport(entries, err, w)
+}
+
+func getAccountReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReportFilter(c, w, r)
+	if !ok {
+		return
+	}
+	entries, err := c.Srv.Store.Report().TotalsByAccount(c.User.ID, filter)
+	writeReport(entries, err, w)
+}
+
+func getPeriodReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReportFilter(c, w, r)
+	if !ok {
+		return
+	}
+	period := mux.Vars(r)["period"]
+	entries, err := c.Srv.Store.Report().TotalsByPeriod(c.User.ID, period, filter)
+	writeReport(entries, err, w)
+}
+
+// getCashFlowReport writes the income, expense and net totals of each period.
+func getCashFlowReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReportFilter(c, w, r)
+	if !ok {
+		return
+	}
+	period := mux.Vars(r)["period"]
+	entries, err := c.Srv.Store.Report().CashFlowByPeriod(c.User.ID, period, filter)
+	if err != nil {
+		log.Println("Error in generating cash flow report: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if entries == nil {
+		entries = []model.CashFlowEntry{}
+	}
+	writeJSON(entries, http.StatusOK, w)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReports(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	entries := []model.ReportEntry{
+		{Key: "121", Name: "Category1", Currency: "EUR", Total: model.NewMoney(15050, "EUR"), Count: 2},
+	}
+	filter := store.ReportFilter{
+		From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	convertedFilter := filter
+	convertedFilter.Currency = "INR"
+	testStore.reportStore.On("TotalsByCategory", userID, filter).Return(entries, nil)
+	testStore.reportStore.On("TotalsByAccount", userID, convertedFilter).Return([]model.ReportEntry(nil), nil)
+	testStore.reportStore.On("TotalsByPeriod", userID, "weekly", store.ReportFilter{}).Return(entries, nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		url      string
+		status   int
+		expected string
+	}{
+		{"/api/reports/categories/?from=2019-01-01&to=2019-01-31", http.StatusOK, `[{"key":"121","name":"Category1","currency":"EUR","total":150.50,"count":2}]`},
+		{"/api/reports/accounts/?from=2019-01-01&to=2019-01-31&convert=1", http.StatusOK, `[]`},
+		{"/api/reports/categories/?from=2019-01-01&to=2019-01-31&convert=false", http.StatusOK, `[{"key":"121","name":"Category1","currency":"EUR","total":150.50,"count":2}]`},
+		{"/api/reports/categories/?convert=maybe", http.StatusBadRequest, `{"errors":{"convert":["invalid_boolean"]}}`},
+		{"/api/reports/weekly/", http.StatusOK, `[{"key":"121","name":"Category1","currency":"EUR","total":150.50,"count":2}]`},
+		{"/api/reports/categories/?from=2019-31-01", http.StatusBadRequest, `{"errors":{"from":["invalid_date"]}}`},
+		{"/api/reports/categories/?from=2019-02-01&to=2019-01-01", http.StatusBadRequest, `{"errors":{"to":["invalid_range"]}}`},
+		{"/api/reports/hourly/", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.url)
+		if tc.expected != "" {
+			var expected, actual interface{}
+			json.Unmarshal([]byte(tc.expected), &expected)
+			json.Unmarshal(recorder.Body.Bytes(), &actual)
+			assert.Equal(t, expected, actual, tc.url)
+		}
+	}
+	testStore.reportStore.AssertExpectations(t)
+}
+
+func TestCashFlowReport(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	entries := []model.CashFlowEntry{{
+		Key:      "2019-01-01",
+		Currency: "INR",
+		Income:   model.NewMoney(500000, "INR"),
+		Expense:  model.NewMoney(120050, "INR"),
+		Net:      model.NewMoney(379950, "INR"),
+		Count:    3,
+	}}
+	testStore.reportStore.On("CashFlowByPeriod", "b89505a4-a451-45e5-912e-4ef8c1441be6", "monthly", store.ReportFilter{Currency: "INR"}).Return(entries, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("GET", "/api/reports/cashflow/monthly/?convert=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[{"key":"2019-01-01","currency":"INR","income":5000.00,"expense":1200.50,"net":3799.50,"count":3}]`, recorder.Body.String())
+}
@@ -11,6 +11,13 @@ type Routes struct {
 	Users     *mux.Router
 	AuthToken *mux.Router
 	Expenses  *mux.Router
+	Expense   *mux.Router
+	Income    *mux.Router
+	Reports   *mux.Router
+	Budgets   *mux.Router
+	Transfers *mux.Router
+	Tags      *mux.Router
+	Rules     *mux.Router
 }
 
 // NewRoutes returns new Routes object by passing in a mux.Router
@@ -19,5 +26,13 @@ func NewRoutes(root *mux.Router) *Routes {
 	routes.ApiRoot = root.PathPrefix("/api").Subrouter()
 	routes.Users = routes.ApiRoot.PathPrefix("/users").Subrouter()
 	routes.Expenses = routes.ApiRoot.PathPrefix("/expenses").Subrouter()
+	// Restricting id to uuid characters so that it won't shadow other routes like /expenses/accounts/
+	routes.Expense = routes.Expenses.PathPrefix("/{id:[0-9a-fA-F-]+}").Subrouter()
+	routes.Income = routes.ApiRoot.PathPrefix("/income").Subrouter()
+	routes.Reports = routes.ApiRoot.PathPrefix("/reports").Subrouter()
+	routes.Budgets = routes.ApiRoot.PathPrefix("/budgets").Subrouter()
+	routes.Transfers = routes.ApiRoot.PathPrefix("/transfers").Subrouter()
+	routes.Tags = routes.ApiRoot.PathPrefix("/tags").Subrouter()
+	routes.Rules = routes.ApiRoot.PathPrefix("/rules").Subrouter()
 	return routes
 }
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+func (srv *Server) InitRuleAPIs() {
+	srv.Routes.Rules.Handle("/", srv.ApiWithTokenValidation(getRules)).Methods("GET")
+	srv.Routes.Rules.Handle("/", srv.ApiWithTokenValidation(createRule)).Methods("POST")
+	srv.Routes.Rules.Handle("/apply/", srv.ApiWithTokenValidation(applyRules)).Methods("POST")
+	srv.Routes.Rules.Handle("/{id}/", srv.ApiWithTokenValidation(getRule)).Methods("GET")
+	srv.Routes.Rules.Handle("/{id}/", srv.ApiWithTokenValidation(updateRule)).Methods("PUT")
+	srv.Routes.Rules.Handle("/{id}/", srv.ApiWithTokenValidation(deleteRule)).Methods("DELETE")
+}
+
+func getRules(c *Context, w http.ResponseWriter, r *http.Request) {
+	rules, err := c.Srv.Store.Rule().GetRules(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if rules == nil {
+		rules = []model.Rule{}
+	}
+	writeJSON(rules, http.StatusOK, w)
+}
+
+// loadRulePayload reads and validates the payload in the request. Writes
+// the error response and returns nil if it is not valid.
+func loadRulePayload(c *Context, w http.ResponseWriter, r *http.Request) *rulePayload {
+	payload := &rulePayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return nil
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating rule references: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return nil
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	return payload
+}
+
+func createRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := loadRulePayload(c, w, r)
+	if payload == nil {
+		return
+	}
+
+	rule := &model.Rule{UserID: c.User.ID}
+	payload.apply(rule)
+	if err := c.Srv.Store.Rule().StoreRule(rule); err != nil {
+		log.Println("Error in creating rule: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully created rule with id", rule.ID)
+	writeJSON(rule, http.StatusCreated, w)
+}
+
+// fetchUserRule fetches the rule with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserRule(c *Context, w http.ResponseWriter, r *http.Request) *model.Rule {
+	id := mux.Vars(r)["id"]
+	rule, err := c.Srv.Store.Rule().GetRuleByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if rule.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return rule
+}
+
+func getRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	rule := fetchUserRule(c, w, r)
+	if rule == nil {
+		return
+	}
+	writeJSON(rule, http.StatusOK, w)
+}
+
+func updateRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	rule := fetchUserRule(c, w, r)
+	if rule == nil {
+		return
+	}
+	payload := loadRulePayload(c, w, r)
+	if payload == nil {
+		return
+	}
+
+	payload.apply(rule)
+	if err := c.Srv.Store.Rule().UpdateRule(rule); err != nil {
+		log.Println("Error in updating rule: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(rule, http.StatusOK, w)
+}
+
+func deleteRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	rule := fetchUserRule(c, w, r)
+	if rule == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Rule().DeleteRule(rule); err != nil {
+		log.Println("Error in deleting rule: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyUserRules applies the first matching rule of the user to each of the
+// new expenses. Categories chosen for the expenses are kept.
+func applyUserRules(c *Context, expenses ...*model.Expense) error {
+	rules, err := c.Srv.Store.Rule().GetRules(c.User.ID)
+	if err != nil {
+		return err
+	}
+	for _, expense := range expenses {
+		model.ApplyRules(rules, expense, false)
+	}
+	return nil
+}
+
+// ruleChange is an expense changed by applying a rule.
+type ruleChange struct {
+	ExpenseID  string   `json:"expense_id"`
+	Title      string   `json:"title"`
+	RuleID     string   `json:"rule_id"`
+	CategoryID string   `json:"category_id"`
+	Tags       []string `json:"tags"`
+}
+
+// applyRules applies the user's rules to the existing expenses matching the
+// filter in the url and responds with the changed expenses. Nothing is saved
+// when dry_run is set. Categories of the expenses are replaced only when
+// overwrite is set.
+func applyRules(c *Context, w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	filter := store.ExpenseFilter{}
+	errs := filter.ParseURLValues(values)
+	options := map[string]bool{"dry_run": false, "overwrite": false}
+	for name := range options {
+		if value := values.Get(name); value != "" {
+			var err error
+			if options[name], err = strconv.ParseBool(value); err != nil {
+				errs.Add(name, store.ErrorInvalidBoolean)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		payloadValidator{errs: errs}.writeErrorMessage(w)
+		return
+	}
+
+	rules, err := c.Srv.Store.Rule().GetRules(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	expenses, err := c.Srv.Store.Expense().GetAllExpenses(c.User.ID, filter)
+	if err != nil {
+		log.Println("Error in getting expenses for rules: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+
+	changed := []model.Expense{}
+	changes := []ruleChange{}
+	for i := range expenses {
+		expense := &expenses[i]
+		rule := model.ApplyRules(rules, expense, options["overwrite"])
+		if rule == nil {
+			continue
+		}
+		change := ruleChange{ExpenseID: expense.ID, Title: expense.Title, RuleID: rule.ID, CategoryID: expense.CategoryID, Tags: []string{}}
+		for _, tag := range expense.Tags {
+			change.Tags = append(change.Tags, tag.Name)
+		}
+		changes = append(changes, change)
+		changed = append(changed, *expense)
+	}
+
+	if !options["dry_run"] && len(changed) > 0 {
+		if err := c.Srv.Store.Expense().UpdateExpenses(changed); err != nil {
+			log.Println("Error in saving expenses changed by rules: ", err.Error())
+			writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+			return
+		}
+	}
+	res := map[string]interface{}{"items": changes, "count": len(changes), "dry_run": options["dry_run"]}
+	writeJSON(res, http.StatusOK, w)
+}
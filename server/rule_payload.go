@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// rulePayload is used for creating and updating rules. At least one of the
+// conditions and one of the actions should be given, the missing ones are
+// reported on title_contains and category_id.
+type rulePayload struct {
+	Name          string          `json:"name"`
+	Position      int             `json:"position"`
+	TitleContains string          `json:"title_contains"`
+	TitlePattern  string          `json:"title_pattern"`
+	MinAmount     json.RawMessage `json:"min_amount"`
+	MaxAmount     json.RawMessage `json:"max_amount"`
+	AccountID     string          `json:"account_id"`
+	CategoryID    string          `json:"category_id"`
+	Tags          []string        `json:"tags"`
+	minAmount     *model.Money
+	maxAmount     *model.Money
+	tags          []model.Tag
+	payloadValidator
+}
+
+func (p *rulePayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Name == "" {
+		p.errs.Add("name", errorIsRequired)
+	}
+	if p.TitlePattern != "" {
+		if _, err := regexp.Compile(p.TitlePattern); err != nil {
+			p.errs.Add("title_pattern", errorInvalidPattern)
+		}
+	}
+	p.minAmount = p.validateOptionalAmount("min_amount", p.MinAmount)
+	p.maxAmount = p.validateOptionalAmount("max_amount", p.MaxAmount)
+	if p.minAmount != nil && p.maxAmount != nil && p.maxAmount.Cmp(*p.minAmount) < 0 {
+		p.errs.Add("max_amount", errorInvalidRange)
+	}
+	p.tags = p.validateTags(p.Tags)
+
+	if p.TitleContains == "" && p.TitlePattern == "" && p.AccountID == "" && p.minAmount == nil && p.maxAmount == nil {
+		p.errs.Add("title_contains", errorIsRequired)
+	}
+	if p.CategoryID == "" && len(p.Tags) == 0 {
+		p.errs.Add("category_id", errorIsRequired)
+	}
+	return len(p.errs) == 0
+}
+
+// validateOptionalAmount validates the amount in the field like
+// validateAmountField if it is given. Returns nil if it is missing.
+func (p *rulePayload) validateOptionalAmount(field string, amount json.RawMessage) *model.Money {
+	if len(amount) == 0 || string(amount) == "null" {
+		return nil
+	}
+	money := p.validateAmountField(field, amount)
+	return &money
+}
+
+// hasValidReferences checks that the account and category in the payload belong to the user.
+func (p *rulePayload) hasValidReferences(c *Context) (bool, error) {
+	if _, err := p.validateAccount(c, p.AccountID); err != nil {
+		return false, err
+	}
+	if err := p.validateCategory(c, p.CategoryID); err != nil {
+		return false, err
+	}
+	return len(p.errs) == 0, nil
+}
+
+// apply copies the payload fields into the given rule.
+func (p *rulePayload) apply(rule *model.Rule) {
+	rule.Name = p.Name
+	rule.Position = p.Position
+	rule.TitleContains = p.TitleContains
+	rule.TitlePattern = p.TitlePattern
+	rule.MinAmount = p.minAmount
+	rule.MaxAmount = p.maxAmount
+	rule.AccountID = p.AccountID
+	rule.CategoryID = p.CategoryID
+	rule.TagNames = []string{}
+	for _, tag := range p.tags {
+		rule.TagNames = append(rule.TagNames, tag.Name)
+	}
+}
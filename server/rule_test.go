@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupRules replaces the rules of the test user with the given rules.
+func setupRules(testStore *MockStore, rules ...model.Rule) {
+	testStore.rules = new(MockRuleStore)
+	testStore.rules.On("GetRules", "b89505a4-a451-45e5-912e-4ef8c1441be6").Return(rules, nil)
+}
+
+func TestCreateRule(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{"name": "Taxi", "title_contains": "uber", "category_id": "121", "tags": ["Travel", "travel"]}`, http.StatusCreated, ""},
+		{`{"name": "Big", "min_amount": "100", "max_amount": "500", "account_id": "111", "tags": ["big"]}`, http.StatusCreated, ""},
+		{`{}`, http.StatusBadRequest, `{"errors":{"name":["is_required"],"title_contains":["is_required"],"category_id":["is_required"]}}`},
+		{`{"name": "Bad", "title_pattern": "(", "min_amount": "500", "max_amount": "100", "tags": ["x"]}`, http.StatusBadRequest, `{"errors":{"title_pattern":["invalid_pattern"],"max_amount":["invalid_range"]}}`},
+		{`{"name": "Other", "account_id": "113", "category_id": "122"}`, http.StatusBadRequest, `{"errors":{"account_id":["invalid_choice"],"category_id":["invalid_choice"]}}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/rules/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		}
+	}
+}
+
+func TestRuleDetail(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.rules.On("GetRuleByID", "151").Return(&model.Rule{ID: "151", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	testStore.rules.On("GetRuleByID", "152").Return(&model.Rule{ID: "152", UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}, nil)
+	testStore.rules.On("GetRuleByID", "153").Return((*model.Rule)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{"GET", "/api/rules/", "", http.StatusOK},
+		{"GET", "/api/rules/151/", "", http.StatusOK},
+		{"GET", "/api/rules/152/", "", http.StatusUnauthorized},
+		{"GET", "/api/rules/153/", "", http.StatusNotFound},
+		{"PUT", "/api/rules/151/", `{"name": "Taxi", "title_pattern": "(?i)uber|lyft", "category_id": "121"}`, http.StatusOK},
+		{"PUT", "/api/rules/151/", `{"name": "Taxi"}`, http.StatusBadRequest},
+		{"PUT", "/api/rules/152/", `{"name": "Taxi", "title_contains": "uber", "category_id": "121"}`, http.StatusUnauthorized},
+		{"DELETE", "/api/rules/152/", "", http.StatusUnauthorized},
+		{"DELETE", "/api/rules/151/", "", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url+" "+tc.body)
+	}
+}
+
+func TestCreateExpenseAppliesRules(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	setupRules(testStore, model.Rule{ID: "151", TitleContains: "uber", CategoryID: "121", TagNames: []string{"travel"}})
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		category string
+		tags     int
+	}{
+		{`{"account_id": "111", "amount": "25", "title": "Uber trip", "date": "2019-01-02T00:00:00Z"}`, "121", 1},
+		{`{"account_id": "111", "amount": "25", "title": "Uber trip", "date": "2019-01-02T00:00:00Z", "tags": ["work"]}`, "121", 2},
+		{`{"account_id": "111", "amount": "25", "title": "Groceries", "date": "2019-01-02T00:00:00Z"}`, "", 0},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		var expense model.Expense
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.category, expense.CategoryID, tc.payload)
+		assert.Equal(t, tc.tags, len(expense.Tags), tc.payload)
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	setupRules(testStore, model.Rule{ID: "151", TitleContains: "uber", CategoryID: "121", TagNames: []string{"travel"}})
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	newExpenses := func() []model.Expense {
+		return []model.Expense{
+			{ID: "1", Title: "Uber trip", UserID: userID},
+			{ID: "2", Title: "Uber eats", CategoryID: "123", UserID: userID, Tags: []model.Tag{{ID: "161", Name: "travel"}}},
+			{ID: "3", Title: "Groceries", UserID: userID},
+		}
+	}
+	testStore.expenseStore.On("GetAllExpenses", userID, mock.Anything).Return(newExpenses(), nil).Once()
+	testStore.expenseStore.On("GetAllExpenses", userID, mock.Anything).Return(newExpenses(), nil).Once()
+	testStore.expenseStore.On("GetAllExpenses", userID, mock.Anything).Return(newExpenses(), nil).Once()
+	testStore.expenseStore.On("UpdateExpenses", mock.Anything).Return(nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		url     string
+		changed []string
+		updates int
+	}{
+		{"/api/rules/apply/?all_time=1&dry_run=true", []string{"1"}, 0},
+		{"/api/rules/apply/?all_time=1&overwrite=true&dry_run=true", []string{"1", "2"}, 0},
+		{"/api/rules/apply/?all_time=1", []string{"1"}, 1},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.url)
+		var response struct {
+			Items []ruleChange
+			Count int
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		changed := []string{}
+		for _, change := range response.Items {
+			changed = append(changed, change.ExpenseID)
+			assert.Equal(t, "151", change.RuleID)
+			assert.Equal(t, "121", change.CategoryID)
+			assert.Equal(t, []string{"travel"}, change.Tags)
+		}
+		assert.Equal(t, tc.changed, changed, tc.url)
+		assert.Equal(t, len(tc.changed), response.Count, tc.url)
+		testStore.expenseStore.AssertNumberOfCalls(t, "UpdateExpenses", tc.updates)
+	}
+
+	req, err := http.NewRequest("POST", "/api/rules/apply/?dry_run=maybe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors":{"dry_run":["invalid_boolean"]}}`, recorder.Body.String())
+}
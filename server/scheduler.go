@@ -0,0 +1,38 @@
+package server
+
+import (
+	"log"
+	"time"
+)
+
+// schedulerInterval is how often the scheduled jobs are run.
+const schedulerInterval = time.Hour
+
+// RunScheduler runs the scheduled jobs every interval until stop is closed.
+// The jobs are run once right away to catch up on what was missed while the
+// server was down.
+func (srv *Server) RunScheduler(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	srv.createDueExpenses(time.Now())
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			srv.createDueExpenses(now)
+		}
+	}
+}
+
+// createDueExpenses creates the expenses of the recurring expenses which are due.
+func (srv *Server) createDueExpenses(now time.Time) {
+	count, err := srv.Store.Recurring().CreateDueExpenses(now)
+	if err != nil {
+		log.Println("Error in creating recurring expenses: ", err.Error())
+	}
+	if count > 0 {
+		log.Println("Created", count, "expenses from recurring expenses")
+	}
+}
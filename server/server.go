@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/jwt"
+	"github.com/ragsagar/wolff/mail"
 	"github.com/ragsagar/wolff/model"
 	"github.com/ragsagar/wolff/store"
 )
@@ -15,16 +17,47 @@ import (
 type Server struct {
 	Routes *Routes
 	Store  store.Store
+	// Attachments keeps the content of the attachments of expenses.
+	// Attachment apis are disabled when it is nil.
+	Attachments store.AttachmentStore
+	// AttachmentQuota is the total size of attachments allowed for each user in bytes.
+	AttachmentQuota int64
+	// JWT signs the access tokens when set. Access tokens are then JWTs
+	// validated without looking up the auth token in the store, the keys
+	// of auth tokens are still accepted.
+	JWT *jwt.Signer
+	// Denylist has the sessions revoked through this server, whose JWT
+	// access tokens are rejected until they expire.
+	Denylist *jwt.Denylist
+	// Mailer sends the password reset tokens. Password reset is disabled
+	// when it is nil.
+	Mailer mail.Mailer
+	// PasswordResetURL is the link in the password reset emails, the token
+	// is appended to it. Only the token is sent when it is empty.
+	PasswordResetURL string
 }
 
 func NewServer(store store.Store) *Server {
 	router := mux.NewRouter()
 	srv := &Server{
-		Routes: NewRoutes(router),
-		Store:  store,
+		Routes:          NewRoutes(router),
+		Store:           store,
+		AttachmentQuota: defaultAttachmentQuota,
+		Denylist:        jwt.NewDenylist(),
 	}
 	srv.InitUsers()
+	srv.InitSessionAPIs()
+	srv.InitPasswordAPIs()
 	srv.InitExpenseAPIs()
+	srv.InitReportAPIs()
+	srv.InitRecurringExpenseAPIs()
+	srv.InitBudgetAPIs()
+	srv.InitTransferAPIs()
+	srv.InitTagAPIs()
+	srv.InitAttachmentAPIs()
+	srv.InitImportAPIs()
+	srv.InitRuleAPIs()
+	srv.InitDuplicateAPIs()
 	return srv
 }
 
@@ -46,6 +79,7 @@ func (srv *Server) OpenAPI(hf handlerFunc) *handler {
 
 func (srv *Server) Run(addr string) {
 	log.Println("Starting server at", addr)
+	go srv.RunScheduler(schedulerInterval, nil)
 	s := &http.Server{
 		Addr:           addr,
 		Handler:        srv.Routes.Root,
@@ -69,7 +103,7 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Println(r.RemoteAddr, r.Method, r.URL)
 	if h.doTokenValidation {
 		auth_header := r.Header.Get("Authorization")
-		user, err := validateToken(h.srv.Store, auth_header)
+		authToken, err := validateToken(h.srv, auth_header)
 		if err != nil {
 			log.Println(err)
 			errorData := map[string]string{
@@ -81,21 +115,67 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			//http.Error(w, string(responseJson), http.StatusUnauthorized)
 			return
 		}
-		c.User = user
+		c.Token = authToken
+		c.User = authToken.User
+		c.userLoaded = c.User != nil
+		if c.User == nil {
+			c.User = &model.User{ID: authToken.UserID}
+		}
+		// LastUsedAt of the sessions using JWTs is updated only on refresh.
+		if now := time.Now(); authToken.Key != "" && authToken.NeedsTouch(now) {
+			if err := h.srv.Store.AuthToken().Touch(authToken, now); err != nil {
+				log.Println("Error in updating token last used time: ", err.Error())
+			}
+		}
 	}
 	// Call the http handler func
 	h.hf(c, w, r)
 }
 
-func validateToken(store store.Store, token string) (*model.User, error) {
+// validateToken returns the auth token with the given key if it is not
+// revoked. JWT access tokens are verified without looking up the store, the
+// returned token has only the IDs of the session and the user.
+func validateToken(srv *Server, token string) (*model.AuthToken, error) {
 	if token == "" {
 		return nil, errors.New("Token missing")
 	}
-	// TODO: Check token in db
-	authToken, err := store.AuthToken().Find(token)
+	if srv.JWT != nil && jwt.IsJWT(token) {
+		return validateJWT(srv, token, time.Now())
+	}
+	authToken, err := srv.Store.AuthToken().Find(token)
 	if err != nil {
 		return nil, errors.New("Token not found")
 	}
+	if !authToken.Active {
+		return nil, errors.New("Token revoked")
+	}
+
+	return authToken, nil
+}
 
-	return authToken.User, nil
+// validateJWT verifies the JWT access token and returns its session built
+// from the claims.
+func validateJWT(srv *Server, token string, now time.Time) (*model.AuthToken, error) {
+	claims, err := srv.JWT.Verify(token, now)
+	if err != nil {
+		return nil, errors.New("Invalid token: " + err.Error())
+	}
+	if srv.Denylist.IsRevoked(claims.SessionID, now) {
+		return nil, errors.New("Token revoked")
+	}
+	return &model.AuthToken{
+		ID:     claims.SessionID,
+		UserID: claims.Subject,
+		Expiry: time.Unix(claims.ExpiresAt, 0),
+		Active: true,
+	}, nil
+}
+
+// denySessions adds the revoked sessions to the denylist until their JWT
+// access tokens expire.
+func (srv *Server) denySessions(ids ...string) {
+	until := time.Now().Add(model.AccessTokenLifetime)
+	for _, id := range ids {
+		srv.Denylist.Revoke(id, until)
+	}
 }
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/jwt"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+)
+
+func (srv *Server) InitSessionAPIs() {
+	srv.Routes.Users.Handle("/token/refresh/", srv.OpenAPI(refreshToken)).Methods("POST")
+	srv.Routes.Users.Handle("/sessions/", srv.ApiWithTokenValidation(getSessions)).Methods("GET")
+	srv.Routes.Users.Handle("/sessions/{id}/", srv.ApiWithTokenValidation(deleteSession)).Methods("DELETE")
+}
+
+// requestDevice returns the details of the client making the request.
+func requestDevice(r *http.Request, name string) model.Device {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return model.Device{Name: name, UserAgent: r.UserAgent(), IP: ip}
+}
+
+// tokenResponse returns the response with the access token and refresh
+// token of the newly created or refreshed token. The access token is a JWT
+// if the server has a signer, otherwise the key of the token.
+func tokenResponse(c *Context, authToken *model.AuthToken) (map[string]interface{}, error) {
+	accessToken := authToken.Key
+	if c.Srv.JWT != nil {
+		var err error
+		accessToken, err = c.Srv.JWT.Sign(jwt.Claims{
+			Subject:   authToken.UserID,
+			SessionID: authToken.ID,
+			ID:        model.GenerateUUID(),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: authToken.Expiry.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		"user_id":       authToken.UserID,
+		"auth_token":    accessToken,
+		"refresh_token": authToken.Refresh.Key,
+		"expires_in":    int(model.AccessTokenLifetime.Seconds()),
+	}, nil
+}
+
+// writeTokenResponse writes the tokenResponse with the status.
+func writeTokenResponse(c *Context, authToken *model.AuthToken, status int, w http.ResponseWriter) {
+	response, err := tokenResponse(c, authToken)
+	if err != nil {
+		log.Println("Error in signing access token: ", err.Error())
+		writeJSONResponse(errorResponse(errorJSONGeneration), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(response, status, w)
+}
+
+// refreshToken issues a new key and refresh token for the session of the
+// refresh token in the payload. Reusing a refresh token revokes its session.
+func refreshToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &refreshTokenPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	authToken, err := c.Srv.Store.AuthToken().Refresh(payload.RefreshToken, time.Now())
+	switch err {
+	case nil:
+	case store.ErrInvalidRefreshToken:
+		writeJSONResponse(errorResponse(errorInvalidRefreshToken), http.StatusUnauthorized, w)
+		return
+	case store.ErrRefreshTokenReused:
+		log.Println("Refresh token reused, revoked session", authToken.ID)
+		c.Srv.denySessions(authToken.ID)
+		writeJSONResponse(errorResponse(errorRefreshTokenReused), http.StatusUnauthorized, w)
+		return
+	default:
+		log.Println("Error in refreshing token: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeTokenResponse(c, authToken, http.StatusOK, w)
+}
+
+// getSessions writes the active tokens of the user. The token used in the
+// request is marked as current.
+func getSessions(c *Context, w http.ResponseWriter, r *http.Request) {
+	sessions, err := c.Srv.Store.AuthToken().GetSessions(c.User.ID)
+	if err != nil {
+		log.Println("Error in fetching sessions: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if sessions == nil {
+		sessions = []model.AuthToken{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == c.Token.ID
+	}
+	writeJSON(sessions, http.StatusOK, w)
+}
+
+// deleteSession revokes the token with the session id in the url.
+func deleteSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	session, err := c.Srv.Store.AuthToken().GetSessionByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return
+	}
+	if session.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return
+	}
+
+	if err := c.Srv.Store.AuthToken().Revoke(session); err != nil {
+		log.Println("Error in revoking session: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	c.Srv.denySessions(session.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
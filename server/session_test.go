@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/jwt"
+	"github.com/ragsagar/wolff/model"
+	"github.com/ragsagar/wolff/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSessions(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	testStore.tokenStore.On("GetSessions", "b89505a4-a451-45e5-912e-4ef8c1441be6").Return([]model.AuthToken{
+		{ID: "s2", Key: "secretkey", DeviceName: "Tablet", UserAgent: "wolff-ios/2.1", IP: "10.0.0.2"},
+		{ID: "s1", Key: "1234", DeviceName: "Phone"},
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/api/users/sessions/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.NotContains(t, body, "secretkey")
+	assert.Contains(t, body, `"device_name":"Tablet","user_agent":"wolff-ios/2.1","ip":"10.0.0.2"`)
+	assert.Contains(t, body, `"current":false`)
+	assert.Contains(t, body, `"current":true`)
+}
+
+func TestDeleteSession(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	own := &model.AuthToken{ID: "s2", Key: "5678", Active: true, UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}
+	other := &model.AuthToken{ID: "s3", Key: "9012", Active: true, UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}
+	testStore.tokenStore.On("GetSessionByID", "s2").Return(own, nil)
+	testStore.tokenStore.On("GetSessionByID", "s3").Return(other, nil)
+	testStore.tokenStore.On("GetSessionByID", "s4").Return((*model.AuthToken)(nil), pg.ErrNoRows)
+	testStore.tokenStore.On("Revoke", "s2").Return(nil)
+
+	cases := []struct {
+		id     string
+		status int
+	}{
+		{"s4", http.StatusNotFound},
+		{"s3", http.StatusUnauthorized},
+		{"s2", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("DELETE", "/api/users/sessions/"+tc.id+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.id)
+	}
+	assert.False(t, own.Active)
+	assert.True(t, other.Active)
+	testStore.tokenStore.AssertNumberOfCalls(t, "Revoke", 1)
+}
+
+func TestTokenLastUsedAt(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	token, _ := testStore.tokenStore.Find("1234")
+
+	request := func() {
+		req, err := http.NewRequest("GET", "/api/users/profile/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	token.LastUsedAt = time.Now().Add(-time.Hour)
+	request()
+	assert.WithinDuration(t, time.Now(), token.LastUsedAt, time.Minute)
+
+	// Not updated again within the interval.
+	lastUsed := time.Now().Add(-time.Minute)
+	token.LastUsedAt = lastUsed
+	request()
+	assert.Equal(t, lastUsed, token.LastUsedAt)
+}
+
+func TestLoginIssuesRefreshToken(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	payload := `{"email": "testuser1@gmail.com", "password": "password", "device_name": "Phone"}`
+	req, err := http.NewRequest("POST", "/api/users/login/", bytes.NewBufferString(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.JSONEq(t, `{"user_id": "b89505a4-a451-45e5-912e-4ef8c1441be6", "auth_token": "1234", "refresh_token": "abcd", "expires_in": 900}`, recorder.Body.String())
+}
+
+func TestRefreshToken(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	refreshed := &model.AuthToken{
+		ID:      "s1",
+		Key:     "4321",
+		UserID:  "b89505a4-a451-45e5-912e-4ef8c1441be6",
+		Active:  true,
+		Refresh: &model.RefreshToken{Key: "dcba"},
+	}
+	testStore.tokenStore.On("Refresh", "abcd").Return(refreshed, nil)
+	testStore.tokenStore.On("Refresh", "used").Return(&model.AuthToken{ID: "s2"}, store.ErrRefreshTokenReused)
+	testStore.tokenStore.On("Refresh", "wrong").Return((*model.AuthToken)(nil), store.ErrInvalidRefreshToken)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+	}{
+		{`{}`, http.StatusBadRequest, `{"errors":{"refresh_token":["is_required"]}}`},
+		{`{"refresh_token": "wrong"}`, http.StatusUnauthorized, `{"error":"invalid_refresh_token"}`},
+		{`{"refresh_token": "used"}`, http.StatusUnauthorized, `{"error":"refresh_token_reused"}`},
+		{`{"refresh_token": "abcd"}`, http.StatusOK, `{"user_id": "b89505a4-a451-45e5-912e-4ef8c1441be6", "auth_token": "4321", "refresh_token": "dcba", "expires_in": 900}`},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/users/token/refresh/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+	}
+	// Access tokens of the session of the reused refresh token are denied.
+	assert.True(t, srv.Denylist.IsRevoked("s2", time.Now()))
+}
+
+func TestJWTAccessTokens(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+	signer, err := jwt.NewSigner(map[string]string{"k1": "0123456789abcdef0123456789abcdef"}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.JWT = signer
+	user, _ := testStore.userStore.GetUserByEmail("testuser1@gmail.com")
+	testStore.userStore.On("GetUserByID", user.ID).Return(user, nil)
+	testStore.tokenStore.On("Revoke", "s1").Return(nil)
+
+	request := func(method, url, token string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Add("Authorization", token)
+		}
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := request("POST", "/api/users/login/", "", `{"email": "testuser1@gmail.com", "password": "password"}`)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var response struct {
+		AuthToken string `json:"auth_token"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, jwt.IsJWT(response.AuthToken))
+
+	// JWTs are validated without finding the token or the user in the store.
+	testStore.expenseStore.On("GetExpenses", user.ID, mock.Anything).Return([]model.Expense(nil), nil)
+	assert.Equal(t, http.StatusOK, request("GET", "/api/expenses/", response.AuthToken, "").Code)
+	testStore.tokenStore.AssertNotCalled(t, "Find", response.AuthToken)
+	testStore.userStore.AssertNotCalled(t, "GetUserByID", user.ID)
+	// The user is fetched only by the handlers needing more than its ID.
+	assert.Equal(t, http.StatusOK, request("GET", "/api/users/profile/", response.AuthToken, "").Code)
+	testStore.userStore.AssertCalled(t, "GetUserByID", user.ID)
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/users/profile/", response.AuthToken+"x", "").Code)
+	// Keys of the auth tokens are still accepted.
+	assert.Equal(t, http.StatusOK, request("GET", "/api/users/profile/", "1234", "").Code)
+
+	// Logging out denies the JWTs of the session.
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/users/logout/", response.AuthToken, "").Code)
+	testStore.tokenStore.AssertCalled(t, "Revoke", "s1")
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/users/profile/", response.AuthToken, "").Code)
+}
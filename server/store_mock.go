@@ -1,6 +1,8 @@
 package server
 
 import (
+	"time"
+
 	"github.com/ragsagar/wolff/model"
 	"github.com/ragsagar/wolff/store"
 	"github.com/stretchr/testify/mock"
@@ -10,6 +12,13 @@ type MockStore struct {
 	userStore    *MockUserStore
 	tokenStore   *MockAuthTokenStore
 	expenseStore *MockExpenseStore
+	rateStore    *MockExchangeRateStore
+	reportStore  *MockReportStore
+	recurring    *MockRecurringExpenseStore
+	budgetStore  *MockBudgetStore
+	transfers    *MockTransferStore
+	tags         *MockTagStore
+	rules        *MockRuleStore
 }
 
 func NewMockStore() *MockStore {
@@ -17,6 +26,13 @@ func NewMockStore() *MockStore {
 		userStore:    new(MockUserStore),
 		tokenStore:   new(MockAuthTokenStore),
 		expenseStore: new(MockExpenseStore),
+		rateStore:    new(MockExchangeRateStore),
+		reportStore:  new(MockReportStore),
+		recurring:    new(MockRecurringExpenseStore),
+		budgetStore:  new(MockBudgetStore),
+		transfers:    new(MockTransferStore),
+		tags:         new(MockTagStore),
+		rules:        new(MockRuleStore),
 	}
 }
 
@@ -32,6 +48,34 @@ func (m MockStore) Expense() store.ExpenseStore {
 	return m.expenseStore
 }
 
+func (m MockStore) ExchangeRate() store.ExchangeRateStore {
+	return m.rateStore
+}
+
+func (m MockStore) Report() store.ReportStore {
+	return m.reportStore
+}
+
+func (m MockStore) Recurring() store.RecurringExpenseStore {
+	return m.recurring
+}
+
+func (m MockStore) Budget() store.BudgetStore {
+	return m.budgetStore
+}
+
+func (m MockStore) Transfer() store.TransferStore {
+	return m.transfers
+}
+
+func (m MockStore) Tag() store.TagStore {
+	return m.tags
+}
+
+func (m MockStore) Rule() store.RuleStore {
+	return m.rules
+}
+
 type MockUserStore struct {
 	mock.Mock
 }
@@ -56,6 +100,20 @@ func (m *MockUserStore) UpdateUser(user model.User) error {
 	return nil
 }
 
+func (m *MockUserStore) StorePasswordReset(reset *model.PasswordReset) error {
+	args := m.Called(reset.UserID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) ResetPassword(token string, passwordHash string, now time.Time) (*model.User, error) {
+	args := m.Called(token)
+	user, _ := args.Get(0).(*model.User)
+	if user != nil {
+		user.Password = passwordHash
+	}
+	return user, args.Error(1)
+}
+
 type MockAuthTokenStore struct {
 	mock.Mock
 }
@@ -65,44 +123,423 @@ func (m *MockAuthTokenStore) Find(token string) (*model.AuthToken, error) {
 	return args.Get(0).(*model.AuthToken), args.Error(1)
 }
 
-func (m *MockAuthTokenStore) Create(user *model.User) (*model.AuthToken, error) {
+func (m *MockAuthTokenStore) Create(user *model.User, device model.Device) (*model.AuthToken, error) {
 	args := m.Called(user)
-	token := &model.AuthToken{UserID: user.ID, User: user, Key: "1234"}
+	token := model.NewAuthToken(user, device)
+	token.PreSave()
+	token.ID = "s1"
+	token.Key = "1234"
+	token.Refresh.Key = "abcd"
+	token.Refresh.SessionID = token.ID
 	return token, args.Error(0)
 }
 
+func (m *MockAuthTokenStore) Refresh(key string, now time.Time) (*model.AuthToken, error) {
+	args := m.Called(key)
+	return args.Get(0).(*model.AuthToken), args.Error(1)
+}
+
+func (m *MockAuthTokenStore) Touch(authToken *model.AuthToken, now time.Time) error {
+	authToken.LastUsedAt = now
+	return nil
+}
+
+func (m *MockAuthTokenStore) GetSessions(userID string) ([]model.AuthToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.AuthToken), args.Error(1)
+}
+
+func (m *MockAuthTokenStore) GetSessionByID(id string) (*model.AuthToken, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.AuthToken), args.Error(1)
+}
+
+func (m *MockAuthTokenStore) Revoke(authToken *model.AuthToken) error {
+	args := m.Called(authToken.ID)
+	if args.Error(0) == nil {
+		authToken.Active = false
+	}
+	return args.Error(0)
+}
+
+func (m *MockAuthTokenStore) RevokeAllForUser(userID string) ([]string, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAuthTokenStore) RevokeOthersForUser(userID, sessionID string) ([]string, error) {
+	args := m.Called(userID, sessionID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 type MockExpenseStore struct {
 	mock.Mock
 }
 
-func (m MockExpenseStore) GetByID(id string) (*model.Expense, error) {
+func (m *MockExpenseStore) GetByID(id string) (*model.Expense, error) {
 	args := m.Called(id)
 	return args.Get(0).(*model.Expense), args.Error(1)
 }
 
-func (m MockExpenseStore) Store(expense *model.Expense) error {
+func (m *MockExpenseStore) Store(expense *model.Expense) error {
 	expense.PreSave()
 	return nil
 }
 
-func (m MockExpenseStore) GetExpenses(userId string, filter store.ExpenseFilter) ([]model.Expense, error) {
-	return nil, nil
+func (m *MockExpenseStore) GetExpenses(userId string, filter store.ExpenseFilter) ([]model.Expense, error) {
+	args := m.Called(userId, filter)
+	return args.Get(0).([]model.Expense), args.Error(1)
 }
 
-func (m MockExpenseStore) StoreAccount(expenseAccount model.ExpenseAccount) error {
+func (m *MockExpenseStore) CountExpenses(userId string, filter store.ExpenseFilter) (int, error) {
+	args := m.Called(userId, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExpenseStore) StoreExpenses(expenses []model.Expense) error {
+	for i := range expenses {
+		expenses[i].PreSave()
+	}
+	args := m.Called(expenses)
+	return args.Error(0)
+}
+
+func (m *MockExpenseStore) UpdateExpenses(expenses []model.Expense) error {
+	for i := range expenses {
+		expenses[i].PreUpdate()
+	}
+	args := m.Called(expenses)
+	return args.Error(0)
+}
+
+func (m *MockExpenseStore) GetAllExpenses(userId string, filter store.ExpenseFilter) ([]model.Expense, error) {
+	args := m.Called(userId, filter)
+	return args.Get(0).([]model.Expense), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetAccountExpenses(accountID string, from, to time.Time) ([]model.Expense, error) {
+	args := m.Called(accountID, from, to)
+	return args.Get(0).([]model.Expense), args.Error(1)
+}
+
+func (m *MockExpenseStore) ExportExpenses(userId string, filter store.ExpenseFilter, fn func(*model.ExpenseExportRow) error) error {
+	args := m.Called(userId, filter)
+	rows := args.Get(0).([]model.ExpenseExportRow)
+	for i := range rows {
+		if err := fn(&rows[i]); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockExpenseStore) StoreAccount(expenseAccount model.ExpenseAccount) error {
 	expenseAccount.PreSave()
 	return nil
 }
 
-func (m MockExpenseStore) GetExpenseAccounts(userId string) ([]model.ExpenseAccount, error) {
-	// args := m.Called(userId)
-	return nil, nil
+func (m *MockExpenseStore) GetExpenseAccounts(userId string, includeArchived bool) ([]model.ExpenseAccount, error) {
+	args := m.Called(userId, includeArchived)
+	return args.Get(0).([]model.ExpenseAccount), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetAccountByID(id string) (*model.ExpenseAccount, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.ExpenseAccount), args.Error(1)
+}
+
+func (m *MockExpenseStore) UpdateAccount(expenseAccount *model.ExpenseAccount) error {
+	expenseAccount.PreUpdate()
+	return nil
+}
+
+func (m *MockExpenseStore) DeleteAccount(expenseAccount *model.ExpenseAccount, moveTo *model.ExpenseAccount) error {
+	moveToID := ""
+	if moveTo != nil {
+		moveToID = moveTo.ID
+	}
+	args := m.Called(expenseAccount.ID, moveToID)
+	return args.Error(0)
+}
+
+func (m *MockExpenseStore) AccountBalance(account *model.ExpenseAccount, day time.Time) (*model.AccountBalance, error) {
+	args := m.Called(account.ID, day)
+	return args.Get(0).(*model.AccountBalance), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetAccountBalances(userId string, day time.Time) ([]model.AccountBalance, error) {
+	args := m.Called(userId, day)
+	return args.Get(0).([]model.AccountBalance), args.Error(1)
+}
+
+func (m *MockExpenseStore) ReconcileAccount(account *model.ExpenseAccount, day time.Time) (int, error) {
+	args := m.Called(account.ID, day)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExpenseStore) StoreAttachment(attachment *model.Attachment) error {
+	attachment.PreSave()
+	return nil
+}
+
+func (m *MockExpenseStore) GetAttachments(expenseID string) ([]model.Attachment, error) {
+	args := m.Called(expenseID)
+	return args.Get(0).([]model.Attachment), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetAttachmentByID(id string) (*model.Attachment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Attachment), args.Error(1)
+}
+
+func (m *MockExpenseStore) DeleteAttachment(attachment *model.Attachment) error {
+	return nil
+}
+
+func (m *MockExpenseStore) AttachmentsSize(userId string) (int64, error) {
+	args := m.Called(userId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetDuplicateCandidates(userId string) ([]model.Expense, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.Expense), args.Error(1)
+}
+
+func (m *MockExpenseStore) GetPossibleDuplicates(expense *model.Expense) ([]model.Expense, error) {
+	args := m.Called(expense.AccountID)
+	return args.Get(0).([]model.Expense), args.Error(1)
 }
 
-func (m MockExpenseStore) GetAccountByID(id string) (*model.ExpenseAccount, error) {
+func (m *MockExpenseStore) GetDuplicateDismissals(userId string) ([]model.DuplicateDismissal, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.DuplicateDismissal), args.Error(1)
+}
+
+func (m *MockExpenseStore) DismissDuplicates(dismissals []model.DuplicateDismissal) error {
+	args := m.Called(dismissals)
+	return args.Error(0)
+}
+
+func (m *MockExpenseStore) MergeExpenses(keep *model.Expense, duplicates []model.Expense) error {
+	keep.PreUpdate()
+	args := m.Called(keep.ID, duplicates)
+	return args.Error(0)
+}
+
+func (m *MockExpenseStore) UpdateExpense(expense *model.Expense) error {
+	expense.PreUpdate()
+	return nil
+}
+
+func (m *MockExpenseStore) DeleteExpense(expense *model.Expense) error {
+	return nil
+}
+
+func (m *MockExpenseStore) StoreCategory(category *model.ExpenseCategory) error {
+	category.PreSave()
+	return nil
+}
+
+func (m *MockExpenseStore) GetExpenseCategories(userId string) ([]model.ExpenseCategory, error) {
 	return nil, nil
 }
 
-func (m MockExpenseStore) DeleteAccount(expenseAccount *model.ExpenseAccount) error {
+func (m *MockExpenseStore) GetCategoryByID(id string) (*model.ExpenseCategory, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.ExpenseCategory), args.Error(1)
+}
+
+func (m *MockExpenseStore) UpdateCategory(category *model.ExpenseCategory) error {
+	return nil
+}
+
+func (m *MockExpenseStore) DeleteCategory(category *model.ExpenseCategory) error {
+	return nil
+}
+
+type MockExchangeRateStore struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateStore) StoreRates(rates []model.ExchangeRate) error {
+	return nil
+}
+
+func (m *MockExchangeRateStore) FindRate(from, to string, date time.Time) (*model.ExchangeRate, error) {
+	args := m.Called(from, to, date)
+	return args.Get(0).(*model.ExchangeRate), args.Error(1)
+}
+
+type MockReportStore struct {
+	mock.Mock
+}
+
+func (m *MockReportStore) TotalsByCategory(userId string, filter store.ReportFilter) ([]model.ReportEntry, error) {
+	args := m.Called(userId, filter)
+	return args.Get(0).([]model.ReportEntry), args.Error(1)
+}
+
+func (m *MockReportStore) TotalsByAccount(userId string, filter store.ReportFilter) ([]model.ReportEntry, error) {
+	args := m.Called(userId, filter)
+	return args.Get(0).([]model.ReportEntry), args.Error(1)
+}
+
+func (m *MockReportStore) TotalsByPeriod(userId string, period string, filter store.ReportFilter) ([]model.ReportEntry, error) {
+	args := m.Called(userId, period, filter)
+	return args.Get(0).([]model.ReportEntry), args.Error(1)
+}
+
+func (m *MockReportStore) CashFlowByPeriod(userId string, period string, filter store.ReportFilter) ([]model.CashFlowEntry, error) {
+	args := m.Called(userId, period, filter)
+	return args.Get(0).([]model.CashFlowEntry), args.Error(1)
+}
+
+type MockRecurringExpenseStore struct {
+	mock.Mock
+}
+
+func (m *MockRecurringExpenseStore) StoreRecurring(recurring *model.RecurringExpense) error {
+	recurring.PreSave()
+	return nil
+}
+
+func (m *MockRecurringExpenseStore) GetRecurringExpenses(userId string) ([]model.RecurringExpense, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.RecurringExpense), args.Error(1)
+}
+
+func (m *MockRecurringExpenseStore) GetRecurringByID(id string) (*model.RecurringExpense, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.RecurringExpense), args.Error(1)
+}
+
+func (m *MockRecurringExpenseStore) UpdateRecurring(recurring *model.RecurringExpense) error {
+	recurring.PreUpdate()
+	return nil
+}
+
+func (m *MockRecurringExpenseStore) DeleteRecurring(recurring *model.RecurringExpense) error {
+	return nil
+}
+
+func (m *MockRecurringExpenseStore) CreateDueExpenses(today time.Time) (int, error) {
+	args := m.Called(today)
+	return args.Int(0), args.Error(1)
+}
+
+type MockBudgetStore struct {
+	mock.Mock
+}
+
+func (m *MockBudgetStore) StoreBudget(budget *model.Budget) error {
+	budget.PreSave()
+	return nil
+}
+
+func (m *MockBudgetStore) GetBudgets(userId string) ([]model.Budget, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.Budget), args.Error(1)
+}
+
+func (m *MockBudgetStore) GetBudgetByID(id string) (*model.Budget, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Budget), args.Error(1)
+}
+
+func (m *MockBudgetStore) UpdateBudget(budget *model.Budget) error {
+	budget.PreUpdate()
+	return nil
+}
+
+func (m *MockBudgetStore) DeleteBudget(budget *model.Budget) error {
+	return nil
+}
+
+type MockTransferStore struct {
+	mock.Mock
+}
+
+func (m *MockTransferStore) StoreTransfer(transfer *model.Transfer) error {
+	transfer.PreSave()
+	return nil
+}
+
+func (m *MockTransferStore) GetTransfers(userId string) ([]model.Transfer, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.Transfer), args.Error(1)
+}
+
+func (m *MockTransferStore) GetTransferByID(id string) (*model.Transfer, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Transfer), args.Error(1)
+}
+
+func (m *MockTransferStore) UpdateTransfer(transfer *model.Transfer) error {
+	transfer.PreUpdate()
+	return nil
+}
+
+func (m *MockTransferStore) DeleteTransfer(transfer *model.Transfer) error {
+	return nil
+}
+
+type MockTagStore struct {
+	mock.Mock
+}
+
+func (m *MockTagStore) GetTags(userId string) ([]model.Tag, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.Tag), args.Error(1)
+}
+
+func (m *MockTagStore) GetTagByID(id string) (*model.Tag, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Tag), args.Error(1)
+}
+
+func (m *MockTagStore) GetTagByName(userId string, name string) (*model.Tag, error) {
+	args := m.Called(userId, name)
+	return args.Get(0).(*model.Tag), args.Error(1)
+}
+
+func (m *MockTagStore) UpdateTag(tag *model.Tag) error {
+	tag.PreUpdate()
+	return nil
+}
+
+func (m *MockTagStore) MergeTags(tag *model.Tag, into *model.Tag) error {
+	return nil
+}
+
+func (m *MockTagStore) DeleteTag(tag *model.Tag) error {
+	return nil
+}
+
+type MockRuleStore struct {
+	mock.Mock
+}
+
+func (m *MockRuleStore) StoreRule(rule *model.Rule) error {
+	rule.PreSave()
+	return nil
+}
+
+func (m *MockRuleStore) GetRules(userId string) ([]model.Rule, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]model.Rule), args.Error(1)
+}
+
+func (m *MockRuleStore) GetRuleByID(id string) (*model.Rule, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *MockRuleStore) UpdateRule(rule *model.Rule) error {
+	rule.PreUpdate()
+	return nil
+}
+
+func (m *MockRuleStore) DeleteRule(rule *model.Rule) error {
 	return nil
 }
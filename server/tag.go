@@ -0,0 +1,130 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+)
+
+func (srv *Server) InitTagAPIs() {
+	srv.Routes.Tags.Handle("/", srv.ApiWithTokenValidation(getTags)).Methods("GET")
+	srv.Routes.Tags.Handle("/{id}/", srv.ApiWithTokenValidation(renameTag)).Methods("PUT")
+	srv.Routes.Tags.Handle("/{id}/", srv.ApiWithTokenValidation(deleteTag)).Methods("DELETE")
+	srv.Routes.Tags.Handle("/{id}/merge/", srv.ApiWithTokenValidation(mergeTag)).Methods("POST")
+}
+
+func getTags(c *Context, w http.ResponseWriter, r *http.Request) {
+	tags, err := c.Srv.Store.Tag().GetTags(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if tags == nil {
+		tags = []model.Tag{}
+	}
+	writeJSON(tags, http.StatusOK, w)
+}
+
+// fetchUserTag fetches the tag with id in the url and makes sure it belongs
+// to the user. Writes the error response and returns nil otherwise.
+func fetchUserTag(c *Context, w http.ResponseWriter, r *http.Request) *model.Tag {
+	id := mux.Vars(r)["id"]
+	tag, err := c.Srv.Store.Tag().GetTagByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if tag.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return tag
+}
+
+func renameTag(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	tag := fetchUserTag(c, w, r)
+	if tag == nil {
+		return
+	}
+	payload := &tagPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c, tag); err != nil {
+		log.Println("Error in validating tag name: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	tag.Name = payload.Name
+	if err := c.Srv.Store.Tag().UpdateTag(tag); err != nil {
+		log.Println("Error in updating tag: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(tag, http.StatusOK, w)
+}
+
+// mergeTag moves the expenses of the tag in the url to the tag in the payload
+// and deletes it. Responds with the tag merged into.
+func mergeTag(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	tag := fetchUserTag(c, w, r)
+	if tag == nil {
+		return
+	}
+	payload := &mergeTagPayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+	if valid, err := payload.hasValidReferences(c, tag); err != nil {
+		log.Println("Error in validating tag: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	if err := c.Srv.Store.Tag().MergeTags(tag, payload.into); err != nil {
+		log.Println("Error in merging tags: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(payload.into, http.StatusOK, w)
+}
+
+func deleteTag(c *Context, w http.ResponseWriter, r *http.Request) {
+	tag := fetchUserTag(c, w, r)
+	if tag == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Tag().DeleteTag(tag); err != nil {
+		log.Println("Error in deleting tag: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
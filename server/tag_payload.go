@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+)
+
+// tagPayload renames a tag. The name should not be used by another tag of
+// the user, those tags can be merged instead.
+type tagPayload struct {
+	Name string `json:"name"`
+	payloadValidator
+}
+
+func (p *tagPayload) isValid() bool {
+	p.errs = url.Values{}
+	p.Name = model.NormalizeTagName(p.Name)
+	if p.Name == "" {
+		p.errs.Add("name", errorIsRequired)
+	} else if len(p.Name) > model.TagNameMaxLength {
+		p.errs.Add("name", errorMaxLength)
+	}
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that the user has no other tag with the name.
+func (p *tagPayload) hasValidReferences(c *Context, tag *model.Tag) (bool, error) {
+	other, err := c.Srv.Store.Tag().GetTagByName(c.User.ID, p.Name)
+	if err != nil && err != pg.ErrNoRows {
+		return false, err
+	}
+	if err == nil && other.ID != tag.ID {
+		p.errs.Add("name", errorNameNotUnique)
+	}
+	return len(p.errs) == 0, nil
+}
+
+// mergeTagPayload has the id of the tag into which another tag is merged.
+type mergeTagPayload struct {
+	Into string `json:"into"`
+	into *model.Tag
+	payloadValidator
+}
+
+func (p *mergeTagPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Into == "" {
+		p.errs.Add("into", errorIsRequired)
+	}
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that the tag to merge into belongs to the user
+// and it is not the tag being merged.
+func (p *mergeTagPayload) hasValidReferences(c *Context, tag *model.Tag) (bool, error) {
+	into, err := c.Srv.Store.Tag().GetTagByID(p.Into)
+	if err != nil && err != pg.ErrNoRows {
+		return false, err
+	}
+	if err == pg.ErrNoRows || into.UserID != c.User.ID || into.ID == tag.ID {
+		p.errs.Add("into", errorInvalidChoice)
+	} else {
+		p.into = into
+	}
+	return len(p.errs) == 0, nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExpenseWithTags(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+		tags     []string
+	}{
+		{`{"account_id": "111", "amount": "10", "title": "Lunch", "date": "2019-01-15T00:00:00Z", "tags": ["Reimbursable", " business-trip-berlin ", "reimbursable"]}`, http.StatusOK, "", []string{"reimbursable", "business-trip-berlin"}},
+		{`{"account_id": "111", "amount": "10", "title": "Lunch", "date": "2019-01-15T00:00:00Z", "tags": [" "]}`, http.StatusBadRequest, `{"errors":{"tags":["is_required"]}}`, nil},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/expenses/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+			continue
+		}
+		var expense model.Expense
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, tag := range expense.Tags {
+			names = append(names, tag.Name)
+		}
+		assert.Equal(t, tc.tags, names)
+	}
+}
+
+func TestPatchExpenseTags(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	// Tags are kept unless they are in the payload.
+	for _, tc := range []struct {
+		payload string
+		tags    int
+	}{
+		{`{"title": "Groceries"}`, 0},
+		{`{"tags": ["weekly"]}`, 1},
+		{`{"title": "Groceries"}`, 1},
+		{`{"tags": []}`, 0},
+	} {
+		req, err := http.NewRequest("PATCH", "/api/expenses/9123/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.payload)
+		var expense model.Expense
+		if err := json.Unmarshal(recorder.Body.Bytes(), &expense); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tc.tags, len(expense.Tags), tc.payload)
+	}
+}
+
+func TestTagAPIs(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	userID := "b89505a4-a451-45e5-912e-4ef8c1441be6"
+	testStore.tags.On("GetTagByID", "161").Return(&model.Tag{ID: "161", Name: "reimbursable", UserID: userID}, nil)
+	testStore.tags.On("GetTagByID", "162").Return(&model.Tag{ID: "162", Name: "work", UserID: userID}, nil)
+	testStore.tags.On("GetTagByID", "163").Return(&model.Tag{ID: "163", Name: "work", UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}, nil)
+	testStore.tags.On("GetTagByID", "164").Return((*model.Tag)(nil), pg.ErrNoRows)
+	testStore.tags.On("GetTagByName", userID, "work").Return(&model.Tag{ID: "162", Name: "work", UserID: userID}, nil)
+	testStore.tags.On("GetTagByName", userID, "reimbursable").Return(&model.Tag{ID: "161", Name: "reimbursable", UserID: userID}, nil)
+	testStore.tags.On("GetTagByName", userID, "to-claim").Return((*model.Tag)(nil), pg.ErrNoRows)
+	testStore.tags.On("GetTags", userID).Return([]model.Tag(nil), nil)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method   string
+		url      string
+		payload  string
+		status   int
+		expected string
+	}{
+		{"GET", "/api/tags/", "", http.StatusOK, `[]`},
+		{"PUT", "/api/tags/161/", `{"name": " To-Claim"}`, http.StatusOK, ""},
+		{"PUT", "/api/tags/161/", `{"name": "Reimbursable"}`, http.StatusOK, ""},
+		{"PUT", "/api/tags/161/", `{"name": "work"}`, http.StatusBadRequest, `{"errors":{"name":["name_not_unique"]}}`},
+		{"PUT", "/api/tags/161/", `{"name": ""}`, http.StatusBadRequest, `{"errors":{"name":["is_required"]}}`},
+		{"PUT", "/api/tags/163/", `{"name": "to-claim"}`, http.StatusUnauthorized, ""},
+		{"PUT", "/api/tags/164/", `{"name": "to-claim"}`, http.StatusNotFound, ""},
+		{"POST", "/api/tags/161/merge/", `{"into": "162"}`, http.StatusOK, ""},
+		{"POST", "/api/tags/161/merge/", `{"into": "161"}`, http.StatusBadRequest, `{"errors":{"into":["invalid_choice"]}}`},
+		{"POST", "/api/tags/161/merge/", `{"into": "163"}`, http.StatusBadRequest, `{"errors":{"into":["invalid_choice"]}}`},
+		{"POST", "/api/tags/161/merge/", `{"into": "164"}`, http.StatusBadRequest, `{"errors":{"into":["invalid_choice"]}}`},
+		{"POST", "/api/tags/161/merge/", `{}`, http.StatusBadRequest, `{"errors":{"into":["is_required"]}}`},
+		{"DELETE", "/api/tags/163/", "", http.StatusUnauthorized, ""},
+		{"DELETE", "/api/tags/161/", "", http.StatusNoContent, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url+" "+tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.method+" "+tc.url+" "+tc.payload)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/gorilla/mux"
+	"github.com/ragsagar/wolff/model"
+)
+
+func (srv *Server) InitTransferAPIs() {
+	srv.Routes.Transfers.Handle("/", srv.ApiWithTokenValidation(getTransfers)).Methods("GET")
+	srv.Routes.Transfers.Handle("/", srv.ApiWithTokenValidation(createTransfer)).Methods("POST")
+	srv.Routes.Transfers.Handle("/{id}/", srv.ApiWithTokenValidation(getTransfer)).Methods("GET")
+	srv.Routes.Transfers.Handle("/{id}/", srv.ApiWithTokenValidation(updateTransfer)).Methods("PUT")
+	srv.Routes.Transfers.Handle("/{id}/", srv.ApiWithTokenValidation(deleteTransfer)).Methods("DELETE")
+}
+
+func getTransfers(c *Context, w http.ResponseWriter, r *http.Request) {
+	transfers, err := c.Srv.Store.Transfer().GetTransfers(c.User.ID)
+	if err != nil {
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
+	}
+	if transfers == nil {
+		transfers = []model.Transfer{}
+	}
+	writeJSON(transfers, http.StatusOK, w)
+}
+
+// loadTransferPayload reads and validates the payload in the request for
+// creating a transfer or updating the current one. Writes the error response
+// and returns nil if it is not valid.
+func loadTransferPayload(c *Context, w http.ResponseWriter, r *http.Request, current *model.Transfer) *transferPayload {
+	payload := &transferPayload{current: current}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return nil
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	if valid, err := payload.hasValidReferences(c); err != nil {
+		log.Println("Error in validating transfer accounts: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return nil
+	} else if !valid {
+		payload.writeErrorMessage(w)
+		return nil
+	}
+	return payload
+}
+
+func createTransfer(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := loadTransferPayload(c, w, r, nil)
+	if payload == nil {
+		return
+	}
+
+	transfer := &model.Transfer{UserID: c.User.ID}
+	payload.apply(transfer)
+	if err := c.Srv.Store.Transfer().StoreTransfer(transfer); err != nil {
+		log.Println("Error in creating transfer: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	log.Println("Successfully created transfer with id", transfer.ID)
+	writeJSON(transfer, http.StatusCreated, w)
+}
+
+// fetchUserTransfer fetches the transfer with id in the url and makes sure it
+// belongs to the user. Writes the error response and returns nil otherwise.
+func fetchUserTransfer(c *Context, w http.ResponseWriter, r *http.Request) *model.Transfer {
+	id := mux.Vars(r)["id"]
+	transfer, err := c.Srv.Store.Transfer().GetTransferByID(id)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			writeJSONResponse(errorResponse(errorNotFound), http.StatusNotFound, w)
+		} else {
+			writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		}
+		return nil
+	}
+	if transfer.UserID != c.User.ID {
+		writeJSONResponse(errorResponse(errorNotAuthorized), http.StatusUnauthorized, w)
+		return nil
+	}
+	return transfer
+}
+
+func getTransfer(c *Context, w http.ResponseWriter, r *http.Request) {
+	transfer := fetchUserTransfer(c, w, r)
+	if transfer == nil {
+		return
+	}
+	writeJSON(transfer, http.StatusOK, w)
+}
+
+func updateTransfer(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	transfer := fetchUserTransfer(c, w, r)
+	if transfer == nil {
+		return
+	}
+	payload := loadTransferPayload(c, w, r, transfer)
+	if payload == nil {
+		return
+	}
+
+	payload.apply(transfer)
+	if err := c.Srv.Store.Transfer().UpdateTransfer(transfer); err != nil {
+		log.Println("Error in updating transfer: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	writeJSON(transfer, http.StatusOK, w)
+}
+
+func deleteTransfer(c *Context, w http.ResponseWriter, r *http.Request) {
+	transfer := fetchUserTransfer(c, w, r)
+	if transfer == nil {
+		return
+	}
+
+	if err := c.Srv.Store.Transfer().DeleteTransfer(transfer); err != nil {
+		log.Println("Error in deleting transfer: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbDelete), http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
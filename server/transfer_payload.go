@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+type transferPayload struct {
+	FromAccountID string          `json:"from_account_id"`
+	ToAccountID   string          `json:"to_account_id"`
+	Amount        json.RawMessage `json:"amount"`
+	ToAmount      json.RawMessage `json:"to_amount"`
+	Date          time.Time       `json:"date"`
+	Title         string          `json:"title"`
+	amount        model.Money
+	toAmount      *model.Money
+	fromAccount   *model.ExpenseAccount
+	toAccount     *model.ExpenseAccount
+	// current is the transfer being updated, nil when creating one.
+	current *model.Transfer
+	payloadValidator
+}
+
+func (p *transferPayload) isValid() bool {
+	p.errs = url.Values{}
+
+	if p.FromAccountID == "" {
+		p.errs.Add("from_account_id", errorIsRequired)
+	}
+	if p.ToAccountID == "" {
+		p.errs.Add("to_account_id", errorIsRequired)
+	} else if p.ToAccountID == p.FromAccountID {
+		p.errs.Add("to_account_id", errorInvalidChoice)
+	}
+
+	p.amount = p.validateAmountField("amount", p.Amount)
+	if p.amount.IsNegative() {
+		p.errs.Add("amount", errorInvalidAmount)
+	}
+	p.toAmount = nil
+	if len(p.ToAmount) > 0 && string(p.ToAmount) != "null" {
+		toAmount := p.validateAmountField("to_amount", p.ToAmount)
+		if toAmount.IsNegative() {
+			p.errs.Add("to_amount", errorInvalidAmount)
+		}
+		p.toAmount = &toAmount
+	}
+
+	if p.Date.IsZero() {
+		p.errs.Add("date", errorIsRequired)
+	}
+
+	return len(p.errs) == 0
+}
+
+// hasValidReferences checks that both the accounts belong to the user and
+// are not archived. The amount received is required when the accounts are in
+// different currencies.
+func (p *transferPayload) hasValidReferences(c *Context) (bool, error) {
+	var currentFromID, currentToID string
+	if p.current != nil {
+		currentFromID, currentToID = p.current.FromAccountID, p.current.ToAccountID
+	}
+	var err error
+	if p.fromAccount, err = p.validateTransactionAccount(c, "from_account_id", p.FromAccountID, currentFromID); err != nil {
+		return false, err
+	}
+	if p.toAccount, err = p.validateTransactionAccount(c, "to_account_id", p.ToAccountID, currentToID); err != nil {
+		return false, err
+	}
+	if p.fromAccount != nil && p.toAccount != nil && p.fromAccount.Currency != p.toAccount.Currency && p.toAmount == nil {
+		p.errs.Add("to_amount", errorIsRequired)
+	}
+	return len(p.errs) == 0, nil
+}
+
+// apply copies the payload fields into the given transfer. The amounts are
+// in the currencies of the accounts and the same amount is received when
+// both accounts are in the same currency.
+func (p *transferPayload) apply(transfer *model.Transfer) {
+	if transfer.FromAccountID != p.FromAccountID {
+		transfer.FromAccount = nil
+	}
+	if transfer.ToAccountID != p.ToAccountID {
+		transfer.ToAccount = nil
+	}
+	transfer.FromAccountID = p.FromAccountID
+	transfer.ToAccountID = p.ToAccountID
+	transfer.Amount = p.amount
+	transfer.ToAmount = p.amount
+	if p.toAmount != nil && p.fromAccount.Currency != p.toAccount.Currency {
+		transfer.ToAmount = *p.toAmount
+	}
+	transfer.SetCurrencies(p.fromAccount.Currency, p.toAccount.Currency)
+	transfer.Date = p.Date
+	transfer.Title = p.Title
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		payload  string
+		status   int
+		expected string
+		toAmount string
+	}{
+		{`{"from_account_id": "111", "to_account_id": "112", "amount": "100", "to_amount": "8900", "date": "2019-01-15T00:00:00Z"}`, http.StatusCreated, "", "8900.00"},
+		{`{"from_account_id": "111", "to_account_id": "112", "amount": "100", "date": "2019-01-15T00:00:00Z"}`, http.StatusBadRequest, `{"errors":{"to_amount":["is_required"]}}`, ""},
+		{`{"from_account_id": "111", "to_account_id": "111", "amount": "100", "date": "2019-01-15T00:00:00Z"}`, http.StatusBadRequest, `{"errors":{"to_account_id":["invalid_choice"]}}`, ""},
+		{`{"from_account_id": "111", "to_account_id": "113", "amount": "100", "date": "2019-01-15T00:00:00Z"}`, http.StatusBadRequest, `{"errors":{"to_account_id":["invalid_choice"]}}`, ""},
+		{`{"from_account_id": "114", "to_account_id": "112", "amount": "100", "date": "2019-01-15T00:00:00Z"}`, http.StatusBadRequest, `{"errors":{"from_account_id":["invalid_choice"]}}`, ""},
+		{`{"from_account_id": "111", "to_account_id": "112", "amount": "-100", "to_amount": "8900", "date": "2019-01-15T00:00:00Z"}`, http.StatusBadRequest, `{"errors":{"amount":["invalid_amount"]}}`, ""},
+		{`{"amount": "100"}`, http.StatusBadRequest, `{"errors":{"from_account_id":["is_required"],"to_account_id":["is_required"],"date":["is_required"]}}`, ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("POST", "/api/transfers/", bytes.NewBufferString(tc.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.payload)
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, recorder.Body.String(), tc.payload)
+		}
+		if tc.toAmount != "" {
+			var transfer model.Transfer
+			if err := json.Unmarshal(recorder.Body.Bytes(), &transfer); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "EUR", transfer.Currency, tc.payload)
+			assert.Equal(t, "100.00", transfer.Amount.String(), tc.payload)
+			assert.Equal(t, tc.toAmount, transfer.ToAmount.String(), tc.payload)
+		}
+	}
+}
+
+func TestTransferDetail(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.transfers.On("GetTransferByID", "151").Return(&model.Transfer{ID: "151", UserID: "b89505a4-a451-45e5-912e-4ef8c1441be6"}, nil)
+	testStore.transfers.On("GetTransferByID", "152").Return(&model.Transfer{ID: "152", UserID: "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"}, nil)
+	testStore.transfers.On("GetTransferByID", "153").Return((*model.Transfer)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	cases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/api/transfers/151/", http.StatusOK},
+		{"GET", "/api/transfers/152/", http.StatusUnauthorized},
+		{"GET", "/api/transfers/153/", http.StatusNotFound},
+		{"DELETE", "/api/transfers/152/", http.StatusUnauthorized},
+		{"DELETE", "/api/transfers/151/", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("Authorization", "1234")
+		recorder := httptest.NewRecorder()
+		srv.Routes.Root.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, tc.method+" "+tc.url)
+	}
+}
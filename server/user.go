@@ -3,6 +3,7 @@ package server
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-pg/pg"
 	"github.com/ragsagar/wolff/model"
@@ -12,7 +13,10 @@ import (
 func (srv *Server) InitUsers() {
 	srv.Routes.Users.Handle("/", srv.OpenAPI(createUser)).Methods("POST")
 	srv.Routes.Users.Handle("/login/", srv.OpenAPI(loginUser)).Methods("POST")
+	srv.Routes.Users.Handle("/logout/", srv.ApiWithTokenValidation(logoutUser)).Methods("POST")
+	srv.Routes.Users.Handle("/logout/all/", srv.ApiWithTokenValidation(logoutUserEverywhere)).Methods("POST")
 	srv.Routes.Users.Handle("/profile/", srv.ApiWithTokenValidation(getUserProfile)).Methods("GET")
+	srv.Routes.Users.Handle("/profile/", srv.ApiWithTokenValidation(updateUserProfile)).Methods("PATCH")
 }
 
 func loginUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -28,28 +32,56 @@ func loginUser(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := c.Srv.Store.User().GetUserByEmail(payload.Email)
-	if err != nil {
+	if err == pg.ErrNoRows {
+		writeJSONResponse(errorResponse("Invalid email or password"), http.StatusBadRequest, w)
+		return
+	} else if err != nil {
 		log.Println("Error in fetching user by email. ", err.Error())
 		writeJSONResponse(errorResponse(errorDbFetch), http.StatusInternalServerError, w)
+		return
 	}
 	if !model.CheckPasswordHash(payload.Password, user.Password) {
 		writeJSONResponse(errorResponse("Invalid email or password"), http.StatusBadRequest, w)
 		return
 	}
 
-	authToken, err := c.Srv.Store.AuthToken().Create(user)
+	authToken, err := c.Srv.Store.AuthToken().Create(user, requestDevice(r, payload.DeviceName))
 	if err != nil {
 		log.Println("Error in generating token.")
 		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
 		return
 	}
-	response := map[string]interface{}{
-		"user_id":    user.ID,
-		"auth_token": authToken.Key}
-	writeJSONResponse(response, http.StatusCreated, w)
+	writeTokenResponse(c, authToken, http.StatusCreated, w)
+}
+
+// logoutUser revokes the token used in the request.
+func logoutUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	if err := c.Srv.Store.AuthToken().Revoke(c.Token); err != nil {
+		log.Println("Error in revoking token: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	c.Srv.denySessions(c.Token.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logoutUserEverywhere revokes all the tokens of the user including the one
+// used in the request.
+func logoutUserEverywhere(c *Context, w http.ResponseWriter, r *http.Request) {
+	ids, err := c.Srv.Store.AuthToken().RevokeAllForUser(c.User.ID)
+	if err != nil {
+		log.Println("Error in revoking tokens: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	c.Srv.denySessions(ids...)
+	writeJSON(map[string]int{"revoked": len(ids)}, http.StatusOK, w)
 }
 
 func getUserProfile(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !fetchRequestUser(c, w) {
+		return
+	}
 	jsonData, err := c.User.ToJSON()
 	if err != nil {
 		log.Println("Error in user json creation: ", err.Error())
@@ -60,6 +92,38 @@ func getUserProfile(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonData)
 }
 
+func updateUserProfile(c *Context, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	payload := &updateProfilePayload{}
+	if err := loadJSON(payload, r.Body); err != nil {
+		writeJSONResponse(errorResponse(errorInvalidJSON), http.StatusInternalServerError, w)
+		return
+	}
+	if !payload.isValid() {
+		payload.writeErrorMessage(w)
+		return
+	}
+
+	if !fetchRequestUser(c, w) {
+		return
+	}
+	user := *c.User
+	if payload.Name != nil {
+		user.Name = *payload.Name
+	}
+	if payload.BaseCurrency != nil {
+		user.BaseCurrency = *payload.BaseCurrency
+	}
+	user.UpdatedAt = time.Now()
+	if err := c.Srv.Store.User().UpdateUser(user); err != nil {
+		log.Println("Error in updating user: ", err.Error())
+		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
+		return
+	}
+	*c.User = user
+	getUserProfile(c, w, r)
+}
+
 func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 	payload := &createUserPayload{}
 	if err := loadJSON(payload, r.Body); err != nil {
@@ -71,7 +135,7 @@ func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := model.User{Email: payload.Email, Name: payload.Name, Active: true}
+	user := model.User{Email: payload.Email, Name: payload.Name, BaseCurrency: payload.BaseCurrency, Active: true}
 	user.SetPassword(payload.Password)
 	user.PreSave()
 	err := c.Srv.Store.User().StoreUser(user)
@@ -86,14 +150,11 @@ func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Println("Successfully created user with id", user.ID)
-	authToken, err := c.Srv.Store.AuthToken().Create(&user)
+	authToken, err := c.Srv.Store.AuthToken().Create(&user, requestDevice(r, ""))
 	if err != nil {
 		log.Println("Error in generating token.")
 		writeJSONResponse(errorResponse(errorDbWrite), http.StatusInternalServerError, w)
 		return
 	}
-	response := map[string]interface{}{
-		"user_id":    user.ID,
-		"auth_token": authToken.Key}
-	writeJSONResponse(response, http.StatusCreated, w)
+	writeTokenResponse(c, authToken, http.StatusCreated, w)
 }
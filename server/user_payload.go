@@ -1,10 +1,15 @@
 package server
 
-import "net/url"
+import (
+	"net/url"
+
+	"github.com/ragsagar/wolff/model"
+)
 
 type loginUserPayload struct {
-	Email    string
-	Password string
+	Email      string
+	Password   string
+	DeviceName string `json:"device_name"`
 	payloadValidator
 }
 
@@ -18,13 +23,85 @@ func (p *loginUserPayload) isValid() bool {
 		p.errs.Add("password", errorIsRequired)
 	}
 
+	if len(p.DeviceName) > model.DeviceNameMaxLength {
+		p.errs.Add("device_name", errorMaxLength)
+	}
+
+	return len(p.errs) == 0
+}
+
+type refreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token"`
+	payloadValidator
+}
+
+func (p *refreshTokenPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.RefreshToken == "" {
+		p.errs.Add("refresh_token", errorIsRequired)
+	}
+	return len(p.errs) == 0
+}
+
+// passwordMinLength is the minimum length of the passwords of users.
+const passwordMinLength = 6
+
+type changePasswordPayload struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+	payloadValidator
+}
+
+func (p *changePasswordPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.OldPassword == "" {
+		p.errs.Add("old_password", errorIsRequired)
+	}
+	if p.NewPassword == "" {
+		p.errs.Add("new_password", errorIsRequired)
+	} else if len(p.NewPassword) < passwordMinLength {
+		p.errs.Add("new_password", errorMinLength)
+	}
+	return len(p.errs) == 0
+}
+
+type passwordResetPayload struct {
+	Email string
+	payloadValidator
+}
+
+func (p *passwordResetPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Email == "" {
+		p.errs.Add("email", errorIsRequired)
+	}
+	return len(p.errs) == 0
+}
+
+type confirmPasswordResetPayload struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+	payloadValidator
+}
+
+func (p *confirmPasswordResetPayload) isValid() bool {
+	p.errs = url.Values{}
+	if p.Token == "" {
+		p.errs.Add("token", errorIsRequired)
+	}
+	if p.NewPassword == "" {
+		p.errs.Add("new_password", errorIsRequired)
+	} else if len(p.NewPassword) < passwordMinLength {
+		p.errs.Add("new_password", errorMinLength)
+	}
 	return len(p.errs) == 0
 }
 
 type createUserPayload struct {
-	Email    string
-	Name     string
-	Password string
+	Email        string
+	Name         string
+	Password     string
+	BaseCurrency string `json:"base_currency"`
 	payloadValidator
 }
 
@@ -37,7 +114,7 @@ func (p *createUserPayload) isValid() bool {
 
 	if p.Password == "" {
 		p.errs.Add("password", errorIsRequired)
-	} else if len(p.Password) < 6 {
+	} else if len(p.Password) < passwordMinLength {
 		p.errs.Add("password", errorMinLength)
 	}
 
@@ -45,5 +122,30 @@ func (p *createUserPayload) isValid() bool {
 		p.errs.Add("name", errorIsRequired)
 	}
 
+	if p.BaseCurrency != "" && !model.IsValidCurrency(p.BaseCurrency) {
+		p.errs.Add("base_currency", errorInvalidCurrency)
+	}
+
+	return len(p.errs) == 0
+}
+
+// updateProfilePayload is used for partial updates of user profile.
+type updateProfilePayload struct {
+	Name         *string `json:"name"`
+	BaseCurrency *string `json:"base_currency"`
+	payloadValidator
+}
+
+func (p *updateProfilePayload) isValid() bool {
+	p.errs = url.Values{}
+
+	if p.Name != nil && *p.Name == "" {
+		p.errs.Add("name", errorIsRequired)
+	}
+
+	if p.BaseCurrency != nil && !model.IsValidCurrency(*p.BaseCurrency) {
+		p.errs.Add("base_currency", errorInvalidCurrency)
+	}
+
 	return len(p.errs) == 0
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/ragsagar/wolff/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -31,7 +32,7 @@ func TestLoginUser(t *testing.T) {
 		Active:    true,
 	}
 
-	t1 := model.AuthToken{Key: "1234", UserID: expectedUser.ID, User: &expectedUser}
+	t1 := model.AuthToken{Key: "1234", Active: true, UserID: expectedUser.ID, User: &expectedUser}
 
 	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
 	testStore.tokenStore.On("Create", mock.Anything).Return(nil)
@@ -83,6 +84,21 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func TestLoginUnknownUser(t *testing.T) {
+	testStore := NewMockStore()
+	testStore.userStore.On("GetUserByEmail", "unknown@gmail.com").Return((*model.User)(nil), pg.ErrNoRows)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("POST", "/api/users/login/", bytes.NewBufferString(`{"email": "unknown@gmail.com", "password": "password"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"error": "Invalid email or password"}`, recorder.Body.String())
+}
+
 // func TestGetUserById(t *testing.T) {
 // 	testStore := NewMockStore()
 //
@@ -93,7 +109,7 @@ func TestLoginUser(t *testing.T) {
 // 		UpdatedAt: time.Now(),
 // 		Active:    true,
 // 	}
-// 	t1 := model.AuthToken{Key: "1234", UserID: expectedUser.Id, User: &expectedUser}
+// 	t1 := model.AuthToken{Key: "1234", Active: true, UserID: expectedUser.Id, User: &expectedUser}
 //
 // 	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
 // 	testStore.userStore.On("GetUserByID", expectedUser.Id).Return(&expectedUser, nil)
@@ -130,7 +146,7 @@ func TestCreateUser(t *testing.T) {
 		UpdatedAt: time.Now(),
 		Active:    true,
 	}
-	t1 := model.AuthToken{Key: "1234", UserID: expectedUser.ID, User: &expectedUser}
+	t1 := model.AuthToken{Key: "1234", Active: true, UserID: expectedUser.ID, User: &expectedUser}
 
 	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
 	testStore.tokenStore.On("Create", mock.Anything).Return(nil)
@@ -200,7 +216,7 @@ func TestGetUserProfile(t *testing.T) {
 		UpdatedAt: time.Now(),
 		Active:    true,
 	}
-	t1 := model.AuthToken{Key: "1234", UserID: expectedUser.ID, User: &expectedUser}
+	t1 := model.AuthToken{Key: "1234", Active: true, UserID: expectedUser.ID, User: &expectedUser}
 
 	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
 	// testStore.userStore.On("GetUserByID", expectedUser.Id).Return(&expectedUser, nil)
@@ -226,3 +242,83 @@ func TestGetUserProfile(t *testing.T) {
 	}
 	testStore.userStore.AssertExpectations(t)
 }
+
+func TestUpdateUserProfile(t *testing.T) {
+	testStore := NewMockStore()
+
+	expectedUser := model.User{
+		ID:     "b89505a4-a451-45e5-912e-4ef8c1441be6",
+		Email:  "testuser1@gmail.com",
+		Name:   "Test",
+		Active: true,
+	}
+	t1 := model.AuthToken{Key: "1234", Active: true, UserID: expectedUser.ID, User: &expectedUser}
+	testStore.tokenStore.On("Find", "1234").Return(&t1, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("PATCH", "/api/users/profile/", bytes.NewBufferString(`{"base_currency": "inr"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req, err = http.NewRequest("PATCH", "/api/users/profile/", bytes.NewBufferString(`{"base_currency": "INR"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	user := model.User{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "INR", user.BaseCurrency)
+	assert.Equal(t, "Test", user.Name)
+}
+
+func TestLogoutUser(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.tokenStore.On("Revoke", "s1").Return(nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("POST", "/api/users/logout/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	testStore.tokenStore.AssertCalled(t, "Revoke", "s1")
+
+	// The revoked token can't be used anymore.
+	req, err = http.NewRequest("GET", "/api/users/profile/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder = httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLogoutUserEverywhere(t *testing.T) {
+	testStore := setupMockStoreData(t)
+	testStore.tokenStore.On("RevokeAllForUser", "b89505a4-a451-45e5-912e-4ef8c1441be6").Return([]string{"s1", "s2", "s3"}, nil)
+	srv := NewServer(testStore)
+
+	req, err := http.NewRequest("POST", "/api/users/logout/all/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", "1234")
+	recorder := httptest.NewRecorder()
+	srv.Routes.Root.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"revoked": 3}`, recorder.Body.String())
+}
@@ -0,0 +1,163 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// CSVMapping tells which columns of the CSV file have the fields of the
+// transactions, using the names in the header row. Amount is the column of
+// signed amounts. Banks listing the money going out and coming in separate
+// columns can be mapped using Debit and Credit instead.
+type CSVMapping struct {
+	Date   string
+	Amount string
+	Debit  string
+	Credit string
+	Title  string
+	// Delimiter is the field delimiter, comma if not set.
+	Delimiter rune
+}
+
+// columns returns the indexes of the mapped columns in the header. Indexes
+// of the columns not mapped are -1.
+func (m CSVMapping) columns(header []string) (map[string]int, error) {
+	indexes := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := indexes[name]; !ok {
+			indexes[name] = i
+		}
+	}
+	columns := map[string]int{}
+	for field, name := range map[string]string{"date": m.Date, "amount": m.Amount, "debit": m.Debit, "credit": m.Credit, "title": m.Title} {
+		columns[field] = -1
+		if name == "" {
+			continue
+		}
+		i, ok := indexes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, &LineError{Line: 1, Code: ErrorMissingColumn}
+		}
+		columns[field] = i
+	}
+	return columns, nil
+}
+
+func parseCSV(r io.Reader, options Options) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if options.Mapping.Delimiter != 0 {
+		reader.Comma = options.Mapping.Delimiter
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, &LineError{Line: 1, Code: ErrorInvalidFile}
+	}
+	if len(header) > 0 {
+		// Some spreadsheets start the file with byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns, err := options.Mapping.columns(header)
+	if err != nil {
+		return nil, err
+	}
+	order := options.DateOrder
+	if order == "" {
+		order = DateOrderYMD
+	}
+
+	var transactions []Transaction
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				line = parseErr.Line
+			}
+			return nil, &LineError{Line: line, Code: ErrorInvalidFile}
+		}
+		if isBlank(record) {
+			continue
+		}
+		field := func(name string) string {
+			i := columns[name]
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		t := Transaction{Line: line, Title: field("title")}
+		if t.Date, err = parseDate(field("date"), order); err != nil {
+			return nil, &LineError{Line: line, Code: ErrorInvalidDate}
+		}
+		if columns["amount"] >= 0 {
+			t.Amount, err = parseAmount(field("amount"), options.DecimalComma)
+		} else {
+			t.Amount, err = parseDebitCredit(field("debit"), field("credit"), options.DecimalComma)
+		}
+		if err != nil {
+			return nil, &LineError{Line: line, Code: ErrorInvalidAmount}
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// parseDebitCredit returns the signed amount from the separate debit and
+// credit columns, one of which is usually empty.
+func parseDebitCredit(debit, credit string, decimalComma bool) (amount model.Money, err error) {
+	if debit != "" {
+		if amount, err = parseAmount(debit, decimalComma); err != nil {
+			return amount, err
+		}
+		if amount.Amount > 0 {
+			amount.Amount = -amount.Amount
+		}
+	}
+	if credit != "" {
+		value, err := parseAmount(credit, decimalComma)
+		if err != nil {
+			return amount, err
+		}
+		if value.Amount < 0 {
+			value.Amount = -value.Amount
+		}
+		amount.Amount += value.Amount
+	}
+	return amount, nil
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseDelimiter returns the delimiter rune of the given value, which
+// should be a single character. Returns false if it can't be used as a
+// CSV delimiter.
+func ParseDelimiter(value string) (rune, bool) {
+	if value == "" {
+		return ',', true
+	}
+	if value == `\t` {
+		return '\t', true
+	}
+	delimiter, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || delimiter == utf8.RuneError || delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		return 0, false
+	}
+	return delimiter, true
+}
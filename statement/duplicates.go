@@ -0,0 +1,31 @@
+package statement
+
+import "github.com/ragsagar/wolff/model"
+
+// FindDuplicates returns the id of the existing expense each of the imported
+// expenses duplicates, or an empty string if it is new. Expenses of the same
+// account with the same kind, amount and date are taken as duplicates. An
+// existing expense is matched only once, so two identical purchases on the
+// same day are imported once each.
+func FindDuplicates(imported []model.Expense, existing []model.Expense) []string {
+	used := make([]bool, len(existing))
+	duplicates := make([]string, len(imported))
+	for i, expense := range imported {
+		for j, other := range existing {
+			if used[j] || !isSameTransaction(expense, other) {
+				continue
+			}
+			used[j] = true
+			duplicates[i] = other.ID
+			break
+		}
+	}
+	return duplicates
+}
+
+func isSameTransaction(a, b model.Expense) bool {
+	return a.AccountID == b.AccountID &&
+		a.Kind == b.Kind &&
+		a.Amount.Amount == b.Amount.Amount &&
+		model.TruncateDate(a.Date.UTC()).Equal(model.TruncateDate(b.Date.UTC()))
+}
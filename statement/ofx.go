@@ -0,0 +1,82 @@
+package statement
+
+import (
+	"html"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// parseOFX reads the transactions of OFX files. Both the SGML files of OFX 1
+// without the closing tags and the XML files of OFX 2 are accepted, since
+// only the values of the tags in STMTTRN aggregates are used.
+func parseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	upper := strings.ToUpper(content)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, &LineError{Code: ErrorInvalidFile}
+	}
+
+	var transactions []Transaction
+	const start = "<STMTTRN>"
+	for offset := 0; ; {
+		i := strings.Index(upper[offset:], start)
+		if i < 0 {
+			break
+		}
+		begin := offset + i + len(start)
+		end := len(upper)
+		if j := strings.Index(upper[begin:], "</STMTTRN>"); j >= 0 {
+			end = begin + j
+		} else if j := strings.Index(upper[begin:], start); j >= 0 {
+			end = begin + j
+		}
+		offset = end
+
+		line := strings.Count(content[:begin], "\n") + 1
+		values := ofxValues(content[begin:end])
+		t := Transaction{Line: line, Title: values["NAME"]}
+		if t.Title == "" {
+			t.Title = values["MEMO"]
+		}
+		if t.Date, err = parseDate(ofxDate(values["DTPOSTED"]), DateOrderYMD); err != nil {
+			return nil, &LineError{Line: line, Code: ErrorInvalidDate}
+		}
+		amount := values["TRNAMT"]
+		if t.Amount, err = parseAmount(amount, !strings.Contains(amount, ".")); err != nil {
+			return nil, &LineError{Line: line, Code: ErrorInvalidAmount}
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// ofxValues returns the values of the tags in the given part of OFX file
+// keyed by the upper case tag names. Closing tags are skipped.
+func ofxValues(content string) map[string]string {
+	values := map[string]string{}
+	for _, part := range strings.Split(content, "<")[1:] {
+		i := strings.IndexByte(part, '>')
+		if i <= 0 || part[0] == '/' {
+			continue
+		}
+		name := strings.ToUpper(strings.TrimSpace(part[:i]))
+		if _, ok := values[name]; !ok {
+			values[name] = html.UnescapeString(strings.TrimSpace(part[i+1:]))
+		}
+	}
+	return values
+}
+
+// ofxDate returns the date part of OFX datetime like 20190102120000[-5:EST]
+// in a form parseDate understands.
+func ofxDate(value string) string {
+	if len(value) < 8 {
+		return value
+	}
+	return value[0:4] + "-" + value[4:6] + "-" + value[6:8]
+}
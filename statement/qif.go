@@ -0,0 +1,79 @@
+package statement
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// parseQIF reads the transactions of QIF files. Each transaction is a group
+// of lines starting with a field code and ends with a line of ^. Dates are in
+// month, day, year order unless the options say otherwise.
+func parseQIF(r io.Reader, options Options) ([]Transaction, error) {
+	order := options.DateOrder
+	if order == "" {
+		order = DateOrderMDY
+	}
+	scanner := bufio.NewScanner(r)
+	var (
+		transactions []Transaction
+		current      *Transaction
+		date, amount string
+		memo         string
+		line         int
+		err          error
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if line == 1 && !strings.HasPrefix(text, "!") {
+			return nil, &LineError{Line: line, Code: ErrorInvalidFile}
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+		switch code {
+		case '!':
+			// Header lines like !Type:Bank
+			continue
+		case '^':
+			if current == nil {
+				continue
+			}
+			if current.Title == "" {
+				current.Title = memo
+			}
+			// Years like '19 are written with an apostrophe.
+			if current.Date, err = parseDate(strings.Replace(date, "'", "/", -1), order); err != nil {
+				return nil, &LineError{Line: current.Line, Code: ErrorInvalidDate}
+			}
+			if current.Amount, err = parseAmount(amount, options.DecimalComma); err != nil {
+				return nil, &LineError{Line: current.Line, Code: ErrorInvalidAmount}
+			}
+			transactions = append(transactions, *current)
+			current, date, amount, memo = nil, "", "", ""
+			continue
+		}
+		if current == nil {
+			current = &Transaction{Line: line}
+		}
+		switch code {
+		case 'D':
+			date = value
+		case 'T', 'U':
+			amount = value
+		case 'P':
+			current.Title = value
+		case 'M':
+			memo = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, &LineError{Line: current.Line, Code: ErrorInvalidFile}
+	}
+	return transactions, nil
+}
@@ -0,0 +1,185 @@
+// Package statement parses the bank statement files in CSV, OFX and QIF
+// formats into transactions that can be imported as expenses.
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// Supported statement formats.
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+// IsValidFormat returns true if the given format is one of the supported formats.
+func IsValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatOFX || format == FormatQIF
+}
+
+// Orders of day, month and year in the dates of CSV and QIF files. OFX dates
+// are always in year, month, day order.
+const (
+	DateOrderYMD = "ymd"
+	DateOrderDMY = "dmy"
+	DateOrderMDY = "mdy"
+)
+
+// IsValidDateOrder returns true if the given order is one of the date orders.
+func IsValidDateOrder(order string) bool {
+	return order == DateOrderYMD || order == DateOrderDMY || order == DateOrderMDY
+}
+
+// Error codes of the lines that can't be parsed.
+const (
+	ErrorInvalidDate   = "invalid_date"
+	ErrorInvalidAmount = "invalid_amount"
+	ErrorMissingColumn = "missing_column"
+	ErrorInvalidFile   = "invalid_file"
+)
+
+// LineError is returned when a line of the statement can't be parsed. Line
+// starts from 1 and is 0 when the error is not about a single line.
+type LineError struct {
+	Line int
+	Code string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("statement line %d: %s", e.Line, e.Code)
+}
+
+// Transaction is a single entry of the statement. Amount is negative for the
+// money going out of the account and positive for the money coming in.
+type Transaction struct {
+	Line   int
+	Date   time.Time
+	Amount model.Money
+	Title  string
+}
+
+// Expense returns the transaction as an expense of the account. Money going
+// out is an expense and money coming in is income.
+func (t Transaction) Expense(account *model.ExpenseAccount) model.Expense {
+	expense := model.Expense{
+		AccountID: account.ID,
+		Account:   account,
+		Date:      t.Date,
+		Amount:    t.Amount,
+		Title:     t.Title,
+		Kind:      model.KindExpense,
+		UserID:    account.UserID,
+	}
+	if t.Amount.Amount > 0 {
+		expense.Kind = model.KindIncome
+	} else {
+		expense.Amount.Amount = -t.Amount.Amount
+	}
+	expense.SetCurrency(account.Currency)
+	return expense
+}
+
+// Options are the settings used in parsing the statement. Mapping is needed
+// only for CSV files.
+type Options struct {
+	DateOrder string
+	// DecimalComma is set when the amounts use comma as the decimal
+	// separator, like 1.234,50.
+	DecimalComma bool
+	Mapping      CSVMapping
+}
+
+// Parse reads the transactions from the statement in the given format.
+// Transactions with zero amount are left out.
+func Parse(format string, r io.Reader, options Options) ([]Transaction, error) {
+	var (
+		transactions []Transaction
+		err          error
+	)
+	switch format {
+	case FormatCSV:
+		transactions, err = parseCSV(r, options)
+	case FormatOFX:
+		transactions, err = parseOFX(r)
+	case FormatQIF:
+		transactions, err = parseQIF(r, options)
+	default:
+		return nil, fmt.Errorf("invalid statement format %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := transactions[:0]
+	for _, t := range transactions {
+		if t.Amount.Amount != 0 {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// parseDate parses the date in the given order. Day, month and year can be
+// separated by any non digit character, and anything after them like the
+// time is ignored. Two digit years are taken as years of this century.
+func parseDate(value string, order string) (time.Time, error) {
+	parts := strings.FieldsFunc(value, func(c rune) bool { return c < '0' || c > '9' })
+	if len(parts) < 3 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	numbers := make([]int, 3)
+	for i := range numbers {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return time.Time{}, err
+		}
+		numbers[i] = n
+	}
+	var year, month, day int
+	switch order {
+	case DateOrderDMY:
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	case DateOrderMDY:
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	default:
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	}
+	if year < 100 {
+		year += 2000
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// parseAmount parses the amount after removing the thousands separators.
+// Amounts in parentheses are negative.
+func parseAmount(value string, decimalComma bool) (model.Money, error) {
+	value = strings.Replace(strings.TrimSpace(value), " ", "", -1)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	if negative {
+		value = value[1 : len(value)-1]
+	}
+	if decimalComma {
+		value = strings.Replace(value, ".", "", -1)
+		value = strings.Replace(value, ",", ".", -1)
+	} else {
+		value = strings.Replace(value, ",", "", -1)
+	}
+	amount, err := model.ParseMoney(value, "")
+	if err != nil {
+		return amount, err
+	}
+	if negative {
+		amount.Amount = -amount.Amount
+	}
+	return amount, nil
+}
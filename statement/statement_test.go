@@ -0,0 +1,158 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseCSV(t *testing.T) {
+	content := "\ufeffBooking Date,Description,Amount,Balance\n" +
+		"2019-01-02,Grocery store,-12.50,100\n" +
+		"\n" +
+		"2019-01-03,\"Salary, January\",\"1,500.00\",1600\n" +
+		"2019-01-04,Card check,0,1600\n"
+	options := Options{Mapping: CSVMapping{Date: "booking date", Amount: "Amount", Title: "Description"}}
+	transactions, err := Parse(FormatCSV, strings.NewReader(content), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Transaction{
+		{Line: 2, Date: date(2019, 1, 2), Amount: model.NewMoney(-1250, ""), Title: "Grocery store"},
+		{Line: 4, Date: date(2019, 1, 3), Amount: model.NewMoney(150000, ""), Title: "Salary, January"},
+	}, transactions)
+
+	content = "Datum;Text;Soll;Haben\n" +
+		"02.01.2019;Supermarkt;1.012,50;\n" +
+		"03.01.2019;Gehalt;;2.000,00\n"
+	options = Options{
+		DateOrder:    DateOrderDMY,
+		DecimalComma: true,
+		Mapping:      CSVMapping{Date: "Datum", Debit: "Soll", Credit: "Haben", Title: "Text", Delimiter: ';'},
+	}
+	transactions, err = Parse(FormatCSV, strings.NewReader(content), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Transaction{
+		{Line: 2, Date: date(2019, 1, 2), Amount: model.NewMoney(-101250, ""), Title: "Supermarkt"},
+		{Line: 3, Date: date(2019, 1, 3), Amount: model.NewMoney(200000, ""), Title: "Gehalt"},
+	}, transactions)
+
+	cases := []struct {
+		content  string
+		mapping  CSVMapping
+		expected *LineError
+	}{
+		{"Date,Amount\n2019-01-02,10\n", CSVMapping{Date: "Date", Amount: "Value"}, &LineError{Line: 1, Code: ErrorMissingColumn}},
+		{"Date,Amount\n2019-01-02,10\n2019-02-30,10\n", CSVMapping{Date: "Date", Amount: "Amount"}, &LineError{Line: 3, Code: ErrorInvalidDate}},
+		{"Date,Amount\n2019-01-02,ten\n", CSVMapping{Date: "Date", Amount: "Amount"}, &LineError{Line: 2, Code: ErrorInvalidAmount}},
+		{"", CSVMapping{Date: "Date", Amount: "Amount"}, &LineError{Line: 1, Code: ErrorInvalidFile}},
+	}
+	for _, tc := range cases {
+		_, err := Parse(FormatCSV, strings.NewReader(tc.content), Options{Mapping: tc.mapping})
+		assert.Equal(t, tc.expected, err, tc.content)
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	content := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20190102120000[-5:EST]
+<TRNAMT>-12.50
+<FITID>1001
+<NAME>Tom &amp; Jerry's
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20190103
+<TRNAMT>1500,00
+<FITID>1002
+<MEMO>Salary
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+	transactions, err := Parse(FormatOFX, strings.NewReader(content), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Transaction{
+		{Line: 9, Date: date(2019, 1, 2), Amount: model.NewMoney(-1250, ""), Title: "Tom & Jerry's"},
+		{Line: 16, Date: date(2019, 1, 3), Amount: model.NewMoney(150000, ""), Title: "Salary"},
+	}, transactions)
+
+	_, err = Parse(FormatOFX, strings.NewReader("<OFX><STMTTRN><DTPOSTED>2019<TRNAMT>1</STMTTRN></OFX>"), Options{})
+	assert.Equal(t, &LineError{Line: 1, Code: ErrorInvalidDate}, err)
+	_, err = Parse(FormatOFX, strings.NewReader("Date,Amount\n"), Options{})
+	assert.Equal(t, &LineError{Code: ErrorInvalidFile}, err)
+}
+
+func TestParseQIF(t *testing.T) {
+	content := "!Type:Bank\n" +
+		"D01/02'19\n" +
+		"T-12.50\n" +
+		"PGrocery store\n" +
+		"^\n" +
+		"D1/ 3/2019\n" +
+		"U1,500.00\n" +
+		"MSalary\n" +
+		"^\n"
+	transactions, err := Parse(FormatQIF, strings.NewReader(content), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Transaction{
+		{Line: 2, Date: date(2019, 1, 2), Amount: model.NewMoney(-1250, ""), Title: "Grocery store"},
+		{Line: 6, Date: date(2019, 1, 3), Amount: model.NewMoney(150000, ""), Title: "Salary"},
+	}, transactions)
+
+	_, err = Parse(FormatQIF, strings.NewReader("!Type:Bank\nD13/01/2019\nT-1\n^\n"), Options{})
+	assert.Equal(t, &LineError{Line: 2, Code: ErrorInvalidDate}, err)
+	_, err = Parse(FormatQIF, strings.NewReader("!Type:Bank\nD01/13/2019\nT-1\n"), Options{})
+	assert.Equal(t, &LineError{Line: 2, Code: ErrorInvalidFile}, err)
+	_, err = Parse(FormatQIF, strings.NewReader("Date,Amount\n"), Options{})
+	assert.Equal(t, &LineError{Line: 1, Code: ErrorInvalidFile}, err)
+}
+
+func TestTransactionExpense(t *testing.T) {
+	account := &model.ExpenseAccount{ID: "111", Currency: "EUR", UserID: "1"}
+	expense := Transaction{Date: date(2019, 1, 2), Amount: model.NewMoney(-1250, ""), Title: "Grocery"}.Expense(account)
+	assert.Equal(t, model.KindExpense, expense.Kind)
+	assert.Equal(t, model.NewMoney(1250, "EUR"), expense.Amount)
+	assert.Equal(t, "1", expense.UserID)
+
+	expense = Transaction{Date: date(2019, 1, 2), Amount: model.NewMoney(1250, "")}.Expense(account)
+	assert.Equal(t, model.KindIncome, expense.Kind)
+	assert.Equal(t, model.NewMoney(1250, "EUR"), expense.Amount)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	day := time.Date(2019, 1, 2, 10, 0, 0, 0, time.FixedZone("IST", 19800))
+	existing := []model.Expense{
+		{ID: "1", AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(1250, "EUR"), Date: day},
+		{ID: "2", AccountID: "111", Kind: model.KindIncome, Amount: model.NewMoney(1250, "EUR"), Date: day},
+	}
+	imported := []model.Expense{
+		{AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(1250, "EUR"), Date: date(2019, 1, 2)},
+		{AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(1250, "EUR"), Date: date(2019, 1, 2)},
+		{AccountID: "111", Kind: model.KindExpense, Amount: model.NewMoney(1250, "EUR"), Date: date(2019, 1, 3)},
+		{AccountID: "111", Kind: model.KindIncome, Amount: model.NewMoney(1250, "EUR"), Date: date(2019, 1, 2)},
+	}
+	assert.Equal(t, []string{"1", "", "", "2"}, FindDuplicates(imported, existing))
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrAttachmentNotFound is returned when there is no content with the key.
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// ErrInvalidAttachmentKey is returned for keys which can't be used as names.
+var ErrInvalidAttachmentKey = errors.New("invalid attachment key")
+
+// AttachmentStore keeps the content of the attachments of expenses. The
+// metadata of the attachments is kept in ExpenseStore.
+type AttachmentStore interface {
+	Put(key string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalAttachmentStore is the AttachmentStore which keeps the content as
+// files in a directory of the local filesystem.
+type LocalAttachmentStore struct {
+	dir string
+}
+
+// NewLocalAttachmentStore returns new LocalAttachmentStore keeping files in
+// dir, which is created if it doesn't exist.
+func NewLocalAttachmentStore(dir string) (*LocalAttachmentStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &LocalAttachmentStore{dir: dir}, nil
+}
+
+func (ls LocalAttachmentStore) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || filepath.Base(key) != key {
+		return "", ErrInvalidAttachmentKey
+	}
+	return filepath.Join(ls.dir, key), nil
+}
+
+// Put saves the content under the key. The content is written to a
+// temporary file first so that a failed upload doesn't leave a partial file.
+func (ls LocalAttachmentStore) Put(key string, content io.Reader) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(ls.dir, ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Get opens the content saved under the key.
+func (ls LocalAttachmentStore) Get(key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrAttachmentNotFound
+	}
+	return file, err
+}
+
+// Delete removes the content saved under the key. Deleting a missing key is
+// not an error.
+func (ls LocalAttachmentStore) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
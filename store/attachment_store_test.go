@@ -0,0 +1,51 @@
+package store
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalAttachmentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attachments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	attachments, err := NewLocalAttachmentStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := attachments.Put("a1", bytes.NewBufferString("receipt")); err != nil {
+		t.Fatal(err)
+	}
+	content, err := attachments.Get("a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(content)
+	content.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "receipt", string(data))
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(files), "Temporary upload file is left behind.")
+
+	assert.NoError(t, attachments.Delete("a1"))
+	_, err = attachments.Get("a1")
+	assert.Equal(t, ErrAttachmentNotFound, err)
+	assert.NoError(t, attachments.Delete("a1"))
+
+	for _, key := range []string{"", "..", "../a1", "dir/a1"} {
+		assert.Equal(t, ErrInvalidAttachmentKey, attachments.Put(key, bytes.NewBufferString("receipt")), key)
+	}
+}
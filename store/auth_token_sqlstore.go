@@ -3,6 +3,9 @@ package store
 import (
 	"errors"
 	"log"
+	"time"
+
+	"github.com/go-pg/pg"
 
 	"github.com/ragsagar/wolff/model"
 )
@@ -17,24 +20,169 @@ func NewAuthTokenSQLStore(sqlStore SQLStore) *AuthTokenSQLStore {
 	return &AuthTokenSQLStore{sqlStore: &sqlStore}
 }
 
-// Create will create a new token and return it.
-func (authTokenSQLStore AuthTokenSQLStore) Create(user *model.User) (*model.AuthToken, error) {
-	authToken := model.NewAuthToken(user)
+// ErrInvalidRefreshToken is returned when refreshing with a refresh token
+// which doesn't exist, is expired or belongs to a revoked session.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrRefreshTokenReused is returned when refreshing with a refresh token
+// which was already used. The session of the token is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// Create will create a new token for the user on the device and return it
+// along with its refresh token.
+func (authTokenSQLStore AuthTokenSQLStore) Create(user *model.User, device model.Device) (*model.AuthToken, error) {
+	authToken := model.NewAuthToken(user, device)
 	authToken.PreSave()
-	err := authTokenSQLStore.sqlStore.db.Insert(authToken)
+	err := authTokenSQLStore.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(authToken); err != nil {
+			return err
+		}
+		return tx.Insert(authToken.Refresh)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return authToken, nil
 }
 
-// Find returns the AuthToken instance with the given token key in database.
+// Refresh uses the refresh token with the given key to replace the key of
+// its session and returns the session with the new refresh token. Using a
+// refresh token again revokes its session, which is returned along with
+// ErrRefreshTokenReused.
+func (authTokenSQLStore AuthTokenSQLStore) Refresh(key string, now time.Time) (*model.AuthToken, error) {
+	authToken := new(model.AuthToken)
+	reused := false
+	err := authTokenSQLStore.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		refresh := new(model.RefreshToken)
+		err := tx.Model(refresh).Where("key = ?", key).For("UPDATE").Select()
+		if err == pg.ErrNoRows {
+			return ErrInvalidRefreshToken
+		} else if err != nil {
+			return err
+		}
+		err = tx.Model(authToken).Column("auth_token.*", "User").Relation("User").
+			Where("auth_token.id = ?", refresh.SessionID).
+			Select()
+		if err == pg.ErrNoRows {
+			return ErrInvalidRefreshToken
+		} else if err != nil {
+			return err
+		}
+		if refresh.Used {
+			// Revoking is committed, the error is returned after the transaction.
+			reused = true
+			_, err := tx.Model(authToken).Set("active = FALSE").Where("id = ?", authToken.ID).Update()
+			return err
+		}
+		if !authToken.Active || !refresh.Expiry.After(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		_, err = tx.Model(refresh).Set("used = TRUE").Where("key = ?", refresh.Key).Update()
+		if err != nil {
+			return err
+		}
+		authToken.Rotate(now)
+		if err := tx.Update(authToken); err != nil {
+			return err
+		}
+		return tx.Insert(authToken.Refresh)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		authToken.Active = false
+		return authToken, ErrRefreshTokenReused
+	}
+	return authToken, nil
+}
+
+// Find returns the active and unexpired AuthToken instance with the given
+// token key in database.
 func (authTokenSQLStore AuthTokenSQLStore) Find(token string) (*model.AuthToken, error) {
 	authToken := new(model.AuthToken)
-	err := authTokenSQLStore.sqlStore.db.Model(authToken).Column("auth_token.*", "User").Relation("User").Where("auth_token.key = ? AND auth_token.expiry > NOW()", token).Select()
+	err := authTokenSQLStore.sqlStore.db.Model(authToken).Column("auth_token.*", "User").Relation("User").Where("auth_token.key = ? AND auth_token.active AND auth_token.expiry > NOW()", token).Select()
 	if err != nil {
 		log.Println("Error in fetching token: ", err.Error())
 		return nil, errors.New("Token not found in db.")
 	}
 	return authToken, nil
 }
+
+// Touch updates LastUsedAt of the token to the given time.
+func (authTokenSQLStore AuthTokenSQLStore) Touch(authToken *model.AuthToken, now time.Time) error {
+	_, err := authTokenSQLStore.sqlStore.db.Model((*model.AuthToken)(nil)).
+		Set("last_used_at = ?", now).
+		Where("key = ?", authToken.Key).
+		Update()
+	if err != nil {
+		return err
+	}
+	authToken.LastUsedAt = now
+	return nil
+}
+
+// GetSessions returns the active tokens of the user which can still be used
+// or refreshed, the most recently used first.
+func (authTokenSQLStore AuthTokenSQLStore) GetSessions(userID string) ([]model.AuthToken, error) {
+	var tokens []model.AuthToken
+	err := authTokenSQLStore.sqlStore.db.Model(&tokens).
+		Where("user_id = ? AND active AND GREATEST(expiry, refresh_expiry) > NOW()", userID).
+		Order("last_used_at DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetSessionByID returns the token with the given session id.
+func (authTokenSQLStore AuthTokenSQLStore) GetSessionByID(id string) (*model.AuthToken, error) {
+	authToken := new(model.AuthToken)
+	err := authTokenSQLStore.sqlStore.db.Model(authToken).Where("id = ?", id).Select()
+	if err != nil {
+		return nil, err
+	}
+	return authToken, nil
+}
+
+// Revoke deactivates the given token so that it can't be used anymore.
+func (authTokenSQLStore AuthTokenSQLStore) Revoke(authToken *model.AuthToken) error {
+	_, err := authTokenSQLStore.sqlStore.db.Model((*model.AuthToken)(nil)).
+		Set("active = FALSE").
+		Where("id = ?", authToken.ID).
+		Update()
+	if err != nil {
+		return err
+	}
+	authToken.Active = false
+	return nil
+}
+
+// RevokeAllForUser deactivates all the tokens of the user and returns the
+// ids of the tokens which were active.
+func (authTokenSQLStore AuthTokenSQLStore) RevokeAllForUser(userID string) ([]string, error) {
+	return authTokenSQLStore.revokeForUser(userID, "")
+}
+
+// RevokeOthersForUser deactivates the tokens of the user except the one with
+// the given session id and returns the ids of the tokens which were active.
+func (authTokenSQLStore AuthTokenSQLStore) RevokeOthersForUser(userID, sessionID string) ([]string, error) {
+	return authTokenSQLStore.revokeForUser(userID, sessionID)
+}
+
+func (authTokenSQLStore AuthTokenSQLStore) revokeForUser(userID, exceptID string) ([]string, error) {
+	var ids pg.Strings
+	q := authTokenSQLStore.sqlStore.db.Model((*model.AuthToken)(nil)).
+		Set("active = FALSE").
+		Where("user_id = ? AND active", userID)
+	if exceptID != "" {
+		q = q.Where("id <> ?", exceptID)
+	}
+	_, err := q.Returning("id").Update(&ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
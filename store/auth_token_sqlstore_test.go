@@ -31,6 +31,7 @@ func (s *AuthTokenSQLStoreSuite) SetupSuite() {
 	s.db = db
 	queries := []string{
 		`DROP TABLE auth_tokens`,
+		`DROP TABLE IF EXISTS refresh_tokens`,
 		`DROP TABLE users`,
 	}
 	for _, query := range queries {
@@ -84,6 +85,7 @@ func (s *AuthTokenSQLStoreSuite) SetupTest() {
 	queries := []string{
 		`TRUNCATE users`,
 		`TRUNCATE auth_tokens`,
+		`TRUNCATE refresh_tokens`,
 	}
 	for _, query := range queries {
 		_, err := s.db.Query(query)
@@ -112,17 +114,19 @@ func (s *AuthTokenSQLStoreSuite) SetupTest() {
 	}
 
 	testTokens := []struct {
-		key    string
-		expiry time.Time
-		active bool
-		userID string
+		id       string
+		key      string
+		expiry   time.Time
+		active   bool
+		userID   string
+		lastUsed time.Time
 	}{
-		{"1234", time.Now().Add(time.Hour * 1), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
-		{"5678", time.Now().Add(time.Hour * 2), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
-		{"2345", time.Now().Add(time.Hour * -3), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
+		{"a1b2c3d4-0000-4000-8000-000000000001", "1234", time.Now().Add(time.Hour * 1), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", time.Now().Add(time.Hour * -2)},
+		{"a1b2c3d4-0000-4000-8000-000000000002", "5678", time.Now().Add(time.Hour * 2), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", time.Now().Add(time.Hour * -1)},
+		{"a1b2c3d4-0000-4000-8000-000000000003", "2345", time.Now().Add(time.Hour * -3), true, "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", time.Now().Add(time.Hour * -4)},
 	}
 	for _, t := range testTokens {
-		_, err := s.db.Query("INSERT INTO auth_tokens (key, expiry, active, user_id) VALUES ($1, $2, $3, $4)", t.key, t.expiry, t.active, t.userID)
+		_, err := s.db.Query("INSERT INTO auth_tokens (id, key, expiry, active, user_id, last_used_at) VALUES ($1, $2, $3, $4, $5, $6)", t.id, t.key, t.expiry, t.active, t.userID, t.lastUsed)
 		if err != nil {
 			log.Println("Error in inserting.")
 			s.T().Fatal(err)
@@ -170,12 +174,136 @@ func (s *AuthTokenSQLStoreSuite) TestFind() {
 func (s *AuthTokenSQLStoreSuite) TestCreate() {
 	uid := "6a2191d9-77b2-4f14-b1c4-edb094be7cca"
 	user := &model.User{ID: uid}
-	authToken, err := s.store.AuthToken().Create(user)
+	authToken, err := s.store.AuthToken().Create(user, model.Device{Name: "Phone", UserAgent: "wolff-android/1.0", IP: "10.0.0.1"})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if authToken.ID == "" {
+		s.T().Errorf("New token should have a session id.")
+	}
+	session, err := s.store.AuthToken().GetSessionByID(authToken.ID)
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	if session.DeviceName != "Phone" || session.UserAgent != "wolff-android/1.0" || session.IP != "10.0.0.1" {
+		s.T().Errorf("Device of the token is not saved. %+v", session)
+	}
 
 	if !authToken.Expiry.After(time.Now()) {
 		s.T().Errorf("New token shouldn't be already expired.")
 	}
 }
+
+func (s *AuthTokenSQLStoreSuite) TestRevoke() {
+	aToken, err := s.store.AuthToken().Find("1234")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if err := s.store.AuthToken().Revoke(aToken); err != nil {
+		s.T().Fatal(err)
+	}
+	if aToken.Active {
+		s.T().Errorf("Revoked token shouldn't be active.")
+	}
+	if _, err := s.store.AuthToken().Find("1234"); err == nil {
+		s.T().Errorf("Find shouldn't return revoked token.")
+	}
+	if _, err := s.store.AuthToken().Find("5678"); err != nil {
+		s.T().Errorf("Revoking a token shouldn't affect other tokens. Error %s", err)
+	}
+
+	// Only the expired token and 5678 were left active.
+	ids, err := s.store.AuthToken().RevokeAllForUser("5d6e34c8-46b7-11e6-ba7c-cafec0ffee00")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if len(ids) != 2 {
+		s.T().Errorf("Expected 2 tokens to be revoked, got %v", ids)
+	}
+	if _, err := s.store.AuthToken().Find("5678"); err == nil {
+		s.T().Errorf("Find shouldn't return revoked token.")
+	}
+}
+
+func (s *AuthTokenSQLStoreSuite) TestSessions() {
+	sessions, err := s.store.AuthToken().GetSessions("5d6e34c8-46b7-11e6-ba7c-cafec0ffee00")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	// Expired token is left out and the recently used one comes first.
+	if len(sessions) != 2 || sessions[0].Key != "5678" || sessions[1].Key != "1234" {
+		s.T().Fatalf("Sessions are not matching. %+v", sessions)
+	}
+
+	now := time.Now()
+	if err := s.store.AuthToken().Touch(&sessions[1], now); err != nil {
+		s.T().Fatal(err)
+	}
+	sessions, err = s.store.AuthToken().GetSessions("5d6e34c8-46b7-11e6-ba7c-cafec0ffee00")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if sessions[0].Key != "1234" {
+		s.T().Errorf("Touched token should be the most recently used.")
+	}
+}
+
+func (s *AuthTokenSQLStoreSuite) TestRefresh() {
+	user := &model.User{ID: "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"}
+	authToken, err := s.store.AuthToken().Create(user, model.Device{Name: "Phone"})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	oldKey, oldRefresh := authToken.Key, authToken.Refresh.Key
+
+	refreshed, err := s.store.AuthToken().Refresh(oldRefresh, time.Now())
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if refreshed.ID != authToken.ID || refreshed.Key == oldKey || refreshed.Refresh.Key == oldRefresh {
+		s.T().Errorf("Refresh should rotate the keys of the same session. %+v", refreshed)
+	}
+	if refreshed.User == nil {
+		s.T().Errorf("User object is nil in refreshed AuthToken.")
+	}
+	if _, err := s.store.AuthToken().Find(oldKey); err == nil {
+		s.T().Errorf("Find shouldn't return the replaced key.")
+	}
+	if _, err := s.store.AuthToken().Find(refreshed.Key); err != nil {
+		s.T().Errorf("Find should return the new key. Error %s", err)
+	}
+
+	// Reusing the old refresh token revokes the session.
+	revoked, err := s.store.AuthToken().Refresh(oldRefresh, time.Now())
+	if err != ErrRefreshTokenReused {
+		s.T().Errorf("Expected ErrRefreshTokenReused, got %v", err)
+	}
+	if revoked == nil || revoked.ID != authToken.ID {
+		s.T().Errorf("Revoked session should be returned with ErrRefreshTokenReused. %+v", revoked)
+	}
+	if _, err := s.store.AuthToken().Find(refreshed.Key); err == nil {
+		s.T().Errorf("Find shouldn't return token of the revoked session.")
+	}
+	if _, err := s.store.AuthToken().Refresh(refreshed.Refresh.Key, time.Now()); err != ErrInvalidRefreshToken {
+		s.T().Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+	if _, err := s.store.AuthToken().Refresh("invalid", time.Now()); err != ErrInvalidRefreshToken {
+		s.T().Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func (s *AuthTokenSQLStoreSuite) TestRevokeOthers() {
+	ids, err := s.store.AuthToken().RevokeOthersForUser("5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", "a1b2c3d4-0000-4000-8000-000000000001")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if len(ids) != 2 {
+		s.T().Errorf("Expected 2 tokens to be revoked, got %v", ids)
+	}
+	if _, err := s.store.AuthToken().Find("1234"); err != nil {
+		s.T().Errorf("Find should return the token which is kept. Error %s", err)
+	}
+	if _, err := s.store.AuthToken().Find("5678"); err == nil {
+		s.T().Errorf("Find shouldn't return revoked token.")
+	}
+}
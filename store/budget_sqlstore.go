@@ -0,0 +1,65 @@
+package store
+
+import (
+	"log"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// BudgetSQLStore is the SQL implementation of BudgetStore interface.
+type BudgetSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewBudgetSQLStore returns new BudgetSQLStore object.
+func NewBudgetSQLStore(sqlStore SQLStore) *BudgetSQLStore {
+	return &BudgetSQLStore{sqlStore: &sqlStore}
+}
+
+// StoreBudget saves the given budget after populating ID, CreatedAt and UpdatedAt fields.
+func (bs BudgetSQLStore) StoreBudget(budget *model.Budget) error {
+	budget.PreSave()
+	return bs.sqlStore.db.Insert(budget)
+}
+
+// GetBudgets returns all the budgets of the given user with their category and account.
+func (bs BudgetSQLStore) GetBudgets(userId string) ([]model.Budget, error) {
+	var budgets []model.Budget
+	err := bs.sqlStore.db.Model(&budgets).
+		Relation("Category").
+		Relation("Account").
+		Where("budget.user_id = ?", userId).
+		Order("budget.created_at ASC").
+		Select()
+	if err != nil {
+		log.Println("Error in fetching budgets for user_id ", userId)
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetBudgetByID fetches the Budget object with given id.
+func (bs BudgetSQLStore) GetBudgetByID(id string) (*model.Budget, error) {
+	budget := new(model.Budget)
+	err := bs.sqlStore.db.Model(budget).
+		Relation("Category").
+		Relation("Account").
+		Where("budget.id = ?", id).
+		Select()
+	if err != nil {
+		log.Println("Error in fetching budget with id ", id)
+		return nil, err
+	}
+	return budget, nil
+}
+
+// UpdateBudget saves the changes in the given budget after refreshing UpdatedAt field.
+func (bs BudgetSQLStore) UpdateBudget(budget *model.Budget) error {
+	budget.PreUpdate()
+	return bs.sqlStore.db.Update(budget)
+}
+
+// DeleteBudget removes the given budget.
+func (bs BudgetSQLStore) DeleteBudget(budget *model.Budget) error {
+	return bs.sqlStore.db.Delete(budget)
+}
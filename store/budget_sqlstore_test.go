@@ -0,0 +1,126 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BudgetSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *BudgetSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite BudgetSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	if _, err := s.db.Query(`DROP TABLE IF EXISTS budgets`); err != nil {
+		s.T().Fatal(err)
+	}
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *BudgetSQLStoreSuite) SetupTest() {
+	queries := []string{
+		`TRUNCATE budgets`,
+		`TRUNCATE expense_accounts`,
+		`TRUNCATE expense_categories`,
+	}
+	for _, query := range queries {
+		if _, err := s.db.Query(query); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	_, err := s.db.Query("INSERT INTO expense_accounts (id, name, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)", "1234", "Bank", "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", now)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.db.Query("INSERT INTO expense_categories (id, name, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)", "15678", "Grocery", "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00", now)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func TestBudgetSQLStoreSuite(t *testing.T) {
+	s := new(BudgetSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *BudgetSQLStoreSuite) TestBudgets() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	otherUserID := "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"
+	month := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	grocery := &model.Budget{CategoryID: "15678", Amount: model.NewMoney(20000, ""), Currency: "EUR", StartDate: month, UserID: userID}
+	bank := &model.Budget{AccountID: "1234", Amount: model.NewMoney(50000, ""), Currency: "EUR", Rollover: true, StartDate: month, UserID: userID}
+	other := &model.Budget{CategoryID: "25678", Amount: model.NewMoney(1000, ""), StartDate: month, UserID: otherUserID}
+	for _, budget := range []*model.Budget{grocery, bank, other} {
+		if err := s.store.Budget().StoreBudget(budget); err != nil {
+			s.T().Fatal(err)
+		}
+		assert.NotEmpty(s.T(), budget.ID, "Budget id is empty.")
+	}
+
+	// Only the budgets of the user are returned, with their category and account.
+	budgets, err := s.store.Budget().GetBudgets(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 2, len(budgets)) {
+		assert.Equal(s.T(), grocery.ID, budgets[0].ID)
+		if assert.NotNil(s.T(), budgets[0].Category) {
+			assert.Equal(s.T(), "Grocery", budgets[0].Category.Name)
+		}
+		assert.Nil(s.T(), budgets[0].Account)
+		assert.Equal(s.T(), "200.00", budgets[0].Amount.String())
+		assert.Equal(s.T(), bank.ID, budgets[1].ID)
+		if assert.NotNil(s.T(), budgets[1].Account) {
+			assert.Equal(s.T(), "Bank", budgets[1].Account.Name)
+		}
+		assert.True(s.T(), budgets[1].Rollover)
+	}
+	budgets, err = s.store.Budget().GetBudgets(otherUserID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(budgets))
+
+	grocery.Amount = model.NewMoney(25000, "")
+	grocery.Rollover = true
+	if err := s.store.Budget().UpdateBudget(grocery); err != nil {
+		s.T().Fatal(err)
+	}
+	budget, err := s.store.Budget().GetBudgetByID(grocery.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "250.00", budget.Amount.String())
+	assert.True(s.T(), budget.Rollover)
+	assert.True(s.T(), budget.StartDate.Equal(month), "Start date is changed.")
+
+	if err := s.store.Budget().DeleteBudget(budget); err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Budget().GetBudgetByID(grocery.ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+	if _, err := s.store.Budget().GetBudgetByID(other.ID); err != nil {
+		s.T().Fatal(err)
+	}
+}
@@ -1,3 +1,14 @@
 package store
 
 const UniqueEmailConstraint = "users_unique_email"
+
+// Error codes returned while parsing url values of filters.
+const (
+	ErrorInvalidDate    = "invalid_date"
+	ErrorInvalidRange   = "invalid_range"
+	ErrorInvalidInteger = "invalid_integer"
+	ErrorInvalidBoolean = "invalid_boolean"
+	ErrorInvalidAmount  = "invalid_amount"
+	ErrorInvalidChoice  = "invalid_choice"
+	ErrorInvalidCursor  = "invalid_cursor"
+)
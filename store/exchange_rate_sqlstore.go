@@ -0,0 +1,59 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/ragsagar/wolff/model"
+)
+
+// ExchangeRateSQLStore is the SQL implementation of ExchangeRateStore interface.
+type ExchangeRateSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewExchangeRateSQLStore returns new ExchangeRateSQLStore object.
+func NewExchangeRateSQLStore(sqlStore SQLStore) *ExchangeRateSQLStore {
+	return &ExchangeRateSQLStore{sqlStore: &sqlStore}
+}
+
+// StoreRates saves the given rates, replacing the existing rates for the same date and currencies.
+func (ers ExchangeRateSQLStore) StoreRates(rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	_, err := ers.sqlStore.db.Model(&rates).
+		OnConflict("(date, from_currency, to_currency) DO UPDATE").
+		Set("rate = EXCLUDED.rate").
+		Insert()
+	return err
+}
+
+// FindRate returns the latest rate for converting from one currency to another
+// on or before the given date. If only the inverse rate is known it is inverted.
+func (ers ExchangeRateSQLStore) FindRate(from, to string, date time.Time) (*model.ExchangeRate, error) {
+	rate := new(model.ExchangeRate)
+	err := ers.sqlStore.db.Model(rate).
+		Where("date <= ?", date).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.WhereOr("from_currency = ? AND to_currency = ?", from, to).
+				WhereOr("from_currency = ? AND to_currency = ?", to, from)
+			return q, nil
+		}).
+		Order("date DESC").
+		Limit(1).
+		Select()
+	if err != nil {
+		if err != pg.ErrNoRows {
+			log.Println("Error in fetching exchange rate: ", err.Error())
+		}
+		return nil, err
+	}
+	if rate.FromCurrency != from {
+		rate.FromCurrency, rate.ToCurrency = from, to
+		rate.Rate = 1 / rate.Rate
+	}
+	return rate, nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExchangeRateSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *ExchangeRateSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite ExchangeRateSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	_, err = s.db.Query(`DROP TABLE IF EXISTS exchange_rates`)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *ExchangeRateSQLStoreSuite) SetupTest() {
+	_, err := s.db.Query(`TRUNCATE exchange_rates`)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	rates := []model.ExchangeRate{
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), FromCurrency: "EUR", ToCurrency: "INR", Rate: 80},
+		{Date: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), FromCurrency: "EUR", ToCurrency: "INR", Rate: 79},
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), FromCurrency: "USD", ToCurrency: "EUR", Rate: 0.5},
+	}
+	err = s.store.ExchangeRate().StoreRates(rates)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func TestExchangeRateSQLStoreSuite(t *testing.T) {
+	s := new(ExchangeRateSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *ExchangeRateSQLStoreSuite) TestFindRate() {
+	rate, err := s.store.ExchangeRate().FindRate("EUR", "INR", time.Date(2019, 1, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 80.0, rate.Rate, "Latest rate before the date is not used.")
+
+	rate, err = s.store.ExchangeRate().FindRate("EUR", "INR", time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 79.0, rate.Rate)
+
+	// Inverse rate
+	rate, err = s.store.ExchangeRate().FindRate("EUR", "USD", time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 2.0, rate.Rate)
+	assert.Equal(s.T(), "EUR", rate.FromCurrency)
+
+	_, err = s.store.ExchangeRate().FindRate("EUR", "INR", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+}
+
+func (s *ExchangeRateSQLStoreSuite) TestStoreRatesReplacesExisting() {
+	date := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := s.store.ExchangeRate().StoreRates([]model.ExchangeRate{{Date: date, FromCurrency: "EUR", ToCurrency: "INR", Rate: 81}})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	rate, err := s.store.ExchangeRate().FindRate("EUR", "INR", date)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 81.0, rate.Rate)
+}
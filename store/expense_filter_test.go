@@ -0,0 +1,141 @@
+package store
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpenseFilterParseURLValues(t *testing.T) {
+	now := time.Now()
+
+	f := ExpenseFilter{}
+	errs := f.ParseURLValues(url.Values{})
+	assert.Empty(t, errs)
+	assert.Equal(t, now.Year(), f.year, "Year is not defaulted to current year.")
+	assert.Equal(t, int(now.Month()), f.month, "Month is not defaulted to current month.")
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{"all_time": {"true"}})
+	assert.Empty(t, errs)
+	assert.Equal(t, 0, f.year)
+	assert.Equal(t, 0, f.month)
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{"year": {"2018"}})
+	assert.Empty(t, errs)
+	assert.Equal(t, 2018, f.year)
+	assert.Equal(t, 0, f.month, "Month is defaulted when only year is given.")
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{
+		"from":       {"2019-01-01"},
+		"to":         {"2019-01-31"},
+		"account_id": {"1234", "2234"},
+		"amount_min": {"10"},
+		"amount_max": {"20.5"},
+		"title":      {"nesto"},
+		"sort":       {"-amount"},
+	})
+	assert.Empty(t, errs)
+	assert.Equal(t, 0, f.year)
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), f.from)
+	assert.Equal(t, []string{"1234", "2234"}, f.accountIDs)
+	assert.Equal(t, model.NewMoney(1000, ""), *f.minAmount)
+	assert.Equal(t, model.NewMoney(2050, ""), *f.maxAmount)
+	assert.Equal(t, "nesto", f.title)
+	assert.Equal(t, "-amount", f.order)
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{
+		"year":       {"abc"},
+		"month":      {"13"},
+		"from":       {"01-01-2019"},
+		"all_time":   {"maybe"},
+		"amount_min": {"1.234"},
+		"sort":       {"colour"},
+		"limit":      {"-1"},
+	})
+	assert.Equal(t, url.Values{
+		"year":       {ErrorInvalidInteger},
+		"month":      {ErrorInvalidChoice},
+		"from":       {ErrorInvalidDate},
+		"all_time":   {ErrorInvalidBoolean},
+		"amount_min": {ErrorInvalidAmount},
+		"sort":       {ErrorInvalidChoice},
+		"limit":      {ErrorInvalidInteger},
+	}, errs)
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{"amount_min": {"10"}, "amount_max": {"5"}, "from": {"2019-02-01"}, "to": {"2019-01-01"}})
+	assert.Equal(t, url.Values{"amount_max": {ErrorInvalidRange}, "to": {ErrorInvalidRange}}, errs)
+}
+
+func TestExpenseFilterCursor(t *testing.T) {
+	date := time.Date(2019, 1, 2, 10, 30, 0, 123000, time.UTC)
+	expenses := []model.Expense{{ID: "1", Date: date}, {ID: "2", Date: date}}
+
+	f := ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"limit": {"2"}}))
+	cursor := f.NextCursor(expenses)
+	assert.NotEmpty(t, cursor)
+	assert.Empty(t, f.NextCursor(expenses[:1]), "Cursor is returned for the last page.")
+
+	f = ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"cursor": {cursor}, "sort": {"date"}}))
+	if assert.NotNil(t, f.cursor) {
+		assert.Equal(t, "2", f.cursor.id)
+		assert.True(t, date.Equal(f.cursor.date))
+	}
+
+	f = ExpenseFilter{}
+	errs := f.ParseURLValues(url.Values{"cursor": {cursor}, "sort": {"amount"}})
+	assert.Equal(t, url.Values{"cursor": {ErrorInvalidCursor}}, errs)
+	assert.Empty(t, f.NextCursor(expenses), "Cursor is returned for order without cursor support.")
+
+	f = ExpenseFilter{}
+	errs = f.ParseURLValues(url.Values{"cursor": {"not a cursor"}})
+	assert.Equal(t, url.Values{"cursor": {ErrorInvalidCursor}}, errs)
+}
+
+func TestExpenseFilterKind(t *testing.T) {
+	f := ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"kind": {"income"}}))
+	assert.Equal(t, model.KindIncome, f.kind)
+
+	f = ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"kind": {ExpenseFilterAllKinds}}))
+
+	f = ExpenseFilter{}
+	errs := f.ParseURLValues(url.Values{"kind": {"refund"}})
+	assert.Equal(t, url.Values{"kind": {ErrorInvalidChoice}}, errs)
+}
+
+func TestExpenseFilterTags(t *testing.T) {
+	f := ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"tag": {" Reimbursable", "business-trip-berlin"}}))
+	assert.Equal(t, []string{"reimbursable", "business-trip-berlin"}, f.tags)
+}
+
+func TestExpenseFilterExport(t *testing.T) {
+	query := func(f ExpenseFilter) string {
+		q := orm.NewQuery(nil, (*model.Expense)(nil)).Apply(f.ExportFilter)
+		return string(q.AppendFormat(nil, orm.Formatter{}))
+	}
+
+	f := ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"all_time": {"true"}}))
+	assert.NotContains(t, query(f), "LIMIT", "Export is limited without limit parameter.")
+
+	f = ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"all_time": {"true"}, "sort": {"amount"}, "page": {"2"}}))
+	assert.Contains(t, query(f), "LIMIT 100 OFFSET 100")
+
+	f = ExpenseFilter{}
+	assert.Empty(t, f.ParseURLValues(url.Values{"all_time": {"true"}, "limit": {"20"}}))
+	assert.Contains(t, query(f), "LIMIT 20")
+}
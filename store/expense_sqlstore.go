@@ -1,10 +1,16 @@
 package store
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/go-pg/pg/urlvalues"
 	"github.com/ragsagar/wolff/model"
@@ -20,17 +26,74 @@ func NewExpenseSQLStore(sqlStore SQLStore) *ExpenseSQLStore {
 	return &ExpenseSQLStore{sqlStore: &sqlStore}
 }
 
-// Store saves the given expense object into database after populating ID, CreatedAt and UpdatedAt fields.
+// Store saves the given expense object into database along with its tags
+// after populating ID, CreatedAt and UpdatedAt fields.
 func (ess ExpenseSQLStore) Store(expense *model.Expense) error {
 	expense.PreSave()
-	err := ess.sqlStore.db.Insert(expense)
-	return err
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(expense); err != nil {
+			return err
+		}
+		return saveExpenseTags(tx, expense)
+	})
+}
+
+// StoreExpenses saves all the given expenses along with their tags in one
+// transaction, so none of them are saved if any of them fails.
+func (ess ExpenseSQLStore) StoreExpenses(expenses []model.Expense) error {
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		for i := range expenses {
+			expense := &expenses[i]
+			expense.PreSave()
+			if err := tx.Insert(expense); err != nil {
+				return err
+			}
+			if err := saveExpenseTags(tx, expense); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateExpenses saves the changes in all the given expenses along with
+// their tags in one transaction.
+func (ess ExpenseSQLStore) UpdateExpenses(expenses []model.Expense) error {
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		for i := range expenses {
+			expense := &expenses[i]
+			expense.PreUpdate()
+			if err := tx.Update(expense); err != nil {
+				return err
+			}
+			if err := saveExpenseTags(tx, expense); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAccountExpenses returns the transactions of all kinds in the account
+// between the given dates, both inclusive.
+func (ess ExpenseSQLStore) GetAccountExpenses(accountID string, from, to time.Time) ([]model.Expense, error) {
+	var expenses []model.Expense
+	err := ess.sqlStore.db.Model(&expenses).
+		Where("expense.account_id = ?", accountID).
+		Where("expense.date >= ?", model.TruncateDate(from)).
+		Where("expense.date < ?", model.TruncateDate(to).AddDate(0, 0, 1)).
+		Order("expense.date ASC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
 }
 
-// GetByID fetches the Expense object with given id with its related ExpenseAccount, ExpenseCategory and User
+// GetByID fetches the Expense object with given id with its related ExpenseAccount, ExpenseCategory, User and Tags
 func (ess ExpenseSQLStore) GetByID(id string) (*model.Expense, error) {
 	expense := new(model.Expense)
-	err := ess.sqlStore.db.Model(expense).Relation("Account").Relation("Category").Relation("User").Where("expense.id = ?", id).Select()
+	err := ess.sqlStore.db.Model(expense).Relation("Account").Relation("Category").Relation("User").Relation("Tags").Where("expense.id = ?", id).Select()
 	if err != nil {
 		log.Println("Error in fetching expense with id ", id)
 		return nil, err
@@ -38,23 +101,252 @@ func (ess ExpenseSQLStore) GetByID(id string) (*model.Expense, error) {
 	return expense, nil
 }
 
+// UpdateExpense saves the changes in the given expense object after
+// refreshing UpdatedAt field. Tags of the expense are replaced with Tags.
+func (ess ExpenseSQLStore) UpdateExpense(expense *model.Expense) error {
+	expense.PreUpdate()
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Update(expense); err != nil {
+			return err
+		}
+		return saveExpenseTags(tx, expense)
+	})
+}
+
+// DeleteExpense removes the given expense from database along with its
+// tags, duplicate dismissals and the metadata of its attachments. Content of
+// the attachments should be removed from the AttachmentStore separately.
+func (ess ExpenseSQLStore) DeleteExpense(expense *model.Expense) error {
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Model((*model.ExpenseTag)(nil)).Where("expense_id = ?", expense.ID).Delete()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model((*model.Attachment)(nil)).Where("expense_id = ?", expense.ID).Delete()
+		if err != nil {
+			return err
+		}
+		if err := deleteDuplicateDismissals(tx, expense.ID); err != nil {
+			return err
+		}
+		return tx.Delete(expense)
+	})
+}
+
+// GetDuplicateCandidates returns the user's expenses having another expense
+// in the same account with the same kind and amount within a few days, along
+// with their account, category and tags. The titles are not compared, use
+// model.GroupDuplicates for finding the actual duplicates.
+func (ess ExpenseSQLStore) GetDuplicateCandidates(userId string) ([]model.Expense, error) {
+	var expenses []model.Expense
+	err := ess.sqlStore.db.Model(&expenses).
+		Relation("Account").
+		Relation("Category").
+		Relation("Tags").
+		Where("expense.user_id = ?", userId).
+		// One more day than the window since the dates are compared with their time.
+		Where(`EXISTS (SELECT 1 FROM expenses AS other
+			WHERE other.user_id = expense.user_id AND other.id <> expense.id
+			AND other.account_id = expense.account_id AND other.kind = expense.kind
+			AND other.amount = expense.amount
+			AND other.date BETWEEN expense.date - make_interval(days => ?0) AND expense.date + make_interval(days => ?0))`,
+			model.DuplicateDateWindow+1).
+		Order("expense.date ASC", "expense.id ASC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// GetPossibleDuplicates returns the expenses of the user in the same account
+// with the same kind and amount within a few days of the given expense. The
+// titles are not compared, use model.IsLikelyDuplicate for it.
+func (ess ExpenseSQLStore) GetPossibleDuplicates(expense *model.Expense) ([]model.Expense, error) {
+	window := model.DuplicateDateWindow + 1
+	var expenses []model.Expense
+	err := ess.sqlStore.db.Model(&expenses).
+		Where("expense.user_id = ?", expense.UserID).
+		Where("expense.account_id = ?", expense.AccountID).
+		Where("expense.kind = ?", expense.Kind).
+		Where("expense.amount = ?", expense.Amount).
+		Where("expense.date BETWEEN ? AND ?", expense.Date.AddDate(0, 0, -window), expense.Date.AddDate(0, 0, window)).
+		Where("expense.id <> ?", expense.ID).
+		Order("expense.date ASC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// GetDuplicateDismissals returns the pairs of the user's expenses which are
+// marked as not duplicates.
+func (ess ExpenseSQLStore) GetDuplicateDismissals(userId string) ([]model.DuplicateDismissal, error) {
+	var dismissals []model.DuplicateDismissal
+	err := ess.sqlStore.db.Model(&dismissals).Where("user_id = ?", userId).Select()
+	if err != nil {
+		return nil, err
+	}
+	return dismissals, nil
+}
+
+// DismissDuplicates saves the given dismissals, skipping the ones which are
+// already saved.
+func (ess ExpenseSQLStore) DismissDuplicates(dismissals []model.DuplicateDismissal) error {
+	if len(dismissals) == 0 {
+		return nil
+	}
+	_, err := ess.sqlStore.db.Model(&dismissals).OnConflict("DO NOTHING").Insert()
+	return err
+}
+
+// MergeExpenses keeps the given expense and removes its duplicates in one
+// transaction. Attachments of the duplicates are moved to the kept expense,
+// which should already have the other details merged using model.Expense.Merge.
+func (ess ExpenseSQLStore) MergeExpenses(keep *model.Expense, duplicates []model.Expense) error {
+	keep.PreUpdate()
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		for i := range duplicates {
+			duplicate := &duplicates[i]
+			_, err := tx.Model((*model.Attachment)(nil)).
+				Set("expense_id = ?", keep.ID).
+				Where("expense_id = ?", duplicate.ID).
+				Update()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Model((*model.ExpenseTag)(nil)).Where("expense_id = ?", duplicate.ID).Delete()
+			if err != nil {
+				return err
+			}
+			if err := deleteDuplicateDismissals(tx, duplicate.ID); err != nil {
+				return err
+			}
+			if err := tx.Delete(duplicate); err != nil {
+				return err
+			}
+		}
+		if err := tx.Update(keep); err != nil {
+			return err
+		}
+		return saveExpenseTags(tx, keep)
+	})
+}
+
+// deleteDuplicateDismissals removes the dismissals of the expense.
+func deleteDuplicateDismissals(tx *pg.Tx, expenseID string) error {
+	_, err := tx.Model((*model.DuplicateDismissal)(nil)).
+		Where("expense_id = ?0 OR other_id = ?0", expenseID).
+		Delete()
+	return err
+}
+
+// StoreAttachment saves the metadata of the attachment after populating ID and CreatedAt fields.
+func (ess ExpenseSQLStore) StoreAttachment(attachment *model.Attachment) error {
+	attachment.PreSave()
+	return ess.sqlStore.db.Insert(attachment)
+}
+
+// GetAttachments returns the attachments of the expense in the order they are added.
+func (ess ExpenseSQLStore) GetAttachments(expenseID string) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := ess.sqlStore.db.Model(&attachments).
+		Where("attachment.expense_id = ?", expenseID).
+		Order("attachment.created_at ASC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetAttachmentByID fetches the Attachment object with given id.
+func (ess ExpenseSQLStore) GetAttachmentByID(id string) (*model.Attachment, error) {
+	attachment := new(model.Attachment)
+	err := ess.sqlStore.db.Model(attachment).Where("attachment.id = ?", id).Select()
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// DeleteAttachment removes the metadata of the attachment.
+func (ess ExpenseSQLStore) DeleteAttachment(attachment *model.Attachment) error {
+	return ess.sqlStore.db.Delete(attachment)
+}
+
+// AttachmentsSize returns the total size of the attachments of the user in bytes.
+func (ess ExpenseSQLStore) AttachmentsSize(userId string) (int64, error) {
+	var size int64
+	err := ess.sqlStore.db.Model((*model.Attachment)(nil)).
+		ColumnExpr("COALESCE(SUM(attachment.size), 0)").
+		Where("attachment.user_id = ?", userId).
+		Select(&size)
+	return size, err
+}
+
 func (ess ExpenseSQLStore) GetExpenses(userId string, filter ExpenseFilter) ([]model.Expense, error) {
 	var expenses []model.Expense
-	err := ess.sqlStore.db.Model(&expenses).Column("expense.*").Relation("Category").Relation("Account").Where("expense.user_id = ?", userId).Apply(filter.Filter).Select()
+	err := ess.sqlStore.db.Model(&expenses).Column("expense.*").Relation("Category").Relation("Account").Relation("Tags").Where("expense.user_id = ?", userId).Apply(filter.Filter).Select()
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// GetAllExpenses returns the user's expenses matching the filter along with
+// their tags. Unlike GetExpenses, the expenses are paged only when limit or
+// page parameter is given.
+func (ess ExpenseSQLStore) GetAllExpenses(userId string, filter ExpenseFilter) ([]model.Expense, error) {
+	var expenses []model.Expense
+	err := ess.sqlStore.db.Model(&expenses).Relation("Tags").Where("expense.user_id = ?", userId).Apply(filter.ExportFilter).Select()
 	if err != nil {
 		return nil, err
 	}
 	return expenses, nil
 }
 
+// ExportExpenses calls fn for each of the user's expenses matching the filter
+// without loading all of them into memory. Rows are reused between the
+// calls, so fn shouldn't keep a reference to them.
+func (ess ExpenseSQLStore) ExportExpenses(userId string, filter ExpenseFilter, fn func(*model.ExpenseExportRow) error) error {
+	return ess.sqlStore.db.Model((*model.Expense)(nil)).
+		ColumnExpr("expense.id, expense.date, expense.kind, expense.title, expense.amount, expense.currency, expense.reconciled").
+		ColumnExpr("account.name AS account_name, category.name AS category_name").
+		ColumnExpr(`(SELECT string_agg(tags.name, ', ' ORDER BY tags.name) FROM expense_tags
+			JOIN tags ON tags.id = expense_tags.tag_id
+			WHERE expense_tags.expense_id = expense.id) AS tags`).
+		Join("LEFT JOIN expense_accounts AS account ON account.id = expense.account_id").
+		Join("LEFT JOIN expense_categories AS category ON category.id = expense.category_id").
+		Where("expense.user_id = ?", userId).
+		Apply(filter.ExportFilter).
+		ForEach(func(row *model.ExpenseExportRow) error {
+			row.Amount.Currency = row.Currency
+			return fn(row)
+		})
+}
+
+// CountExpenses returns the number of user's expenses matching the filter,
+// ignoring the pagination.
+func (ess ExpenseSQLStore) CountExpenses(userId string, filter ExpenseFilter) (int, error) {
+	return ess.sqlStore.db.Model((*model.Expense)(nil)).Where("expense.user_id = ?", userId).Apply(filter.where).Count()
+}
+
 func (ess ExpenseSQLStore) StoreAccount(expenseAccount model.ExpenseAccount) error {
 	err := ess.sqlStore.db.Insert(&expenseAccount)
 	return err
 }
 
-func (ess ExpenseSQLStore) GetExpenseAccounts(userId string) ([]model.ExpenseAccount, error) {
+// GetExpenseAccounts returns the accounts of the user. Archived accounts are
+// left out unless includeArchived is set.
+func (ess ExpenseSQLStore) GetExpenseAccounts(userId string, includeArchived bool) ([]model.ExpenseAccount, error) {
 	var expenseAccounts []model.ExpenseAccount
-	err := ess.sqlStore.db.Model(&expenseAccounts).Where("user_id = ?", userId).Select()
+	q := ess.sqlStore.db.Model(&expenseAccounts).Where("user_id = ?", userId)
+	if !includeArchived {
+		q = q.Where("NOT archived")
+	}
+	err := q.Select()
 	if err != nil {
 		log.Println("Error in fetching accounts for user_id ", userId)
 		return nil, err
@@ -72,22 +364,312 @@ func (ess ExpenseSQLStore) GetAccountByID(id string) (*model.ExpenseAccount, err
 	return expenseAccount, nil
 }
 
-func (ess ExpenseSQLStore) DeleteAccount(expenseAccount *model.ExpenseAccount) error {
-	return ess.sqlStore.db.Delete(expenseAccount)
+// UpdateAccount saves the changes in the given account after refreshing UpdatedAt field.
+func (ess ExpenseSQLStore) UpdateAccount(expenseAccount *model.ExpenseAccount) error {
+	expenseAccount.PreUpdate()
+	return ess.sqlStore.db.Update(expenseAccount)
+}
+
+// AccountInUseError is returned when deleting an account which still has
+// transactions and no account is given to move them to, or which has
+// transfers with the account the transactions are moved to.
+type AccountInUseError struct {
+	Count int
+}
+
+func (e *AccountInUseError) Error() string {
+	return fmt.Sprintf("account has %d transactions", e.Count)
 }
 
-// func (ess ExpenseSQLStore) DeleteExpenseWithUserID(id, userId string) error {
-// 	return ess.sqlStore.db.Delete()
-// }
+// DeleteAccount removes the given account along with its budgets and the
+// rules matching only its expenses. If moveTo is given the expenses, income,
+// transfers, recurring expenses, budgets and rules of the account are moved
+// to it first, otherwise an *AccountInUseError is returned when the account
+// has any transactions. Transfers between the account and moveTo can't be
+// moved, an *AccountInUseError with their number is returned if there are
+// any.
+func (ess ExpenseSQLStore) DeleteAccount(expenseAccount *model.ExpenseAccount, moveTo *model.ExpenseAccount) error {
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		if moveTo == nil {
+			count, err := countAccountTransactions(tx, expenseAccount.ID)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return &AccountInUseError{Count: count}
+			}
+		} else if err := moveAccountTransactions(tx, expenseAccount.ID, moveTo.ID); err != nil {
+			return err
+		}
+		for _, m := range []interface{}{(*model.Budget)(nil), (*model.Rule)(nil)} {
+			_, err := tx.Model(m).Where("account_id = ?", expenseAccount.ID).Delete()
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(expenseAccount)
+	})
+}
+
+// countAccountTransactions returns the number of expenses, income, transfers
+// and recurring expenses of the account.
+func countAccountTransactions(tx *pg.Tx, id string) (int, error) {
+	expenses, err := tx.Model((*model.Expense)(nil)).Where("account_id = ?", id).Count()
+	if err != nil {
+		return 0, err
+	}
+	transfers, err := tx.Model((*model.Transfer)(nil)).Where("from_account_id = ?0 OR to_account_id = ?0", id).Count()
+	if err != nil {
+		return 0, err
+	}
+	recurring, err := tx.Model((*model.RecurringExpense)(nil)).Where("account_id = ?", id).Count()
+	if err != nil {
+		return 0, err
+	}
+	return expenses + transfers + recurring, nil
+}
+
+// moveAccountTransactions moves everything referring to the account with id
+// to the account with toID. Returns an *AccountInUseError if there are
+// transfers between the two accounts as they would become transfers into the
+// same account.
+func moveAccountTransactions(tx *pg.Tx, id, toID string) error {
+	count, err := tx.Model((*model.Transfer)(nil)).
+		Where("(from_account_id = ?0 AND to_account_id = ?1) OR (from_account_id = ?1 AND to_account_id = ?0)", id, toID).
+		Count()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &AccountInUseError{Count: count}
+	}
+	updates := []struct {
+		model  interface{}
+		column string
+	}{
+		{(*model.Expense)(nil), "account_id"},
+		{(*model.RecurringExpense)(nil), "account_id"},
+		{(*model.Budget)(nil), "account_id"},
+		{(*model.Rule)(nil), "account_id"},
+		{(*model.Transfer)(nil), "from_account_id"},
+		{(*model.Transfer)(nil), "to_account_id"},
+	}
+	for _, update := range updates {
+		_, err := tx.Model(update.model).Set("? = ?", pg.F(update.column), toID).Where("? = ?", pg.F(update.column), id).Update()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StoreCategory saves the given category into database after populating ID, CreatedAt and UpdatedAt fields.
+func (ess ExpenseSQLStore) StoreCategory(category *model.ExpenseCategory) error {
+	category.PreSave()
+	return ess.sqlStore.db.Insert(category)
+}
+
+// GetExpenseCategories returns all the categories created by the given user.
+func (ess ExpenseSQLStore) GetExpenseCategories(userId string) ([]model.ExpenseCategory, error) {
+	var categories []model.ExpenseCategory
+	err := ess.sqlStore.db.Model(&categories).Where("user_id = ?", userId).Order("name ASC").Select()
+	if err != nil {
+		log.Println("Error in fetching categories for user_id ", userId)
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetCategoryByID fetches the ExpenseCategory object with given id.
+func (ess ExpenseSQLStore) GetCategoryByID(id string) (*model.ExpenseCategory, error) {
+	category := new(model.ExpenseCategory)
+	err := ess.sqlStore.db.Model(category).Where("expense_category.id = ?", id).Select()
+	if err != nil {
+		log.Println("Error in fetching category: ", err.Error())
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateCategory saves the changes in the given category.
+func (ess ExpenseSQLStore) UpdateCategory(category *model.ExpenseCategory) error {
+	category.UpdatedAt = time.Now()
+	return ess.sqlStore.db.Update(category)
+}
+
+// DeleteCategory removes the given category along with its budgets and unsets
+// it from the expenses, recurring expenses and rules using it.
+func (ess ExpenseSQLStore) DeleteCategory(category *model.ExpenseCategory) error {
+	return ess.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		for _, m := range []interface{}{(*model.Expense)(nil), (*model.RecurringExpense)(nil), (*model.Rule)(nil)} {
+			_, err := tx.Model(m).Set("category_id = NULL").Where("category_id = ?", category.ID).Update()
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Model((*model.Budget)(nil)).Where("category_id = ?", category.ID).Delete()
+		if err != nil {
+			return err
+		}
+		return tx.Delete(category)
+	})
+}
+
+// expenseSortOrders maps the values of sort parameter to the order clauses.
+var expenseSortOrders = map[string]string{
+	"date":        "expense.date ASC",
+	"-date":       "expense.date DESC",
+	"amount":      "expense.amount ASC",
+	"-amount":     "expense.amount DESC",
+	"title":       "expense.title ASC",
+	"-title":      "expense.title DESC",
+	"created_at":  "expense.created_at ASC",
+	"-created_at": "expense.created_at DESC",
+}
 
+// ExpenseFilter filters and orders the expenses using the url query
+// parameters. When no date parameter is given, expenses of the current month
+// are returned unless all_time is set. Only the transactions of KindExpense
+// are returned unless kind parameter says otherwise. When tag parameter is
+// repeated, only the expenses having all the tags are returned.
+//
+// Expenses sorted by date can be paged using the cursor returned by
+// NextCursor, which continues after the last expense of the previous page
+// even if expenses are added in between. Other orders use page based paging.
 type ExpenseFilter struct {
 	*urlvalues.Pager
-	// Filter(*orm.Query) (*orm.Query, error)
-	year  int
-	month int
+	year        int
+	month       int
+	from        time.Time
+	to          time.Time
+	accountIDs  []string
+	categoryIDs []string
+	minAmount   *model.Money
+	maxAmount   *model.Money
+	title       string
+	tags        []string
+	order       string
+	kind        string
+	cursor      *expenseCursor
+}
+
+// ExpenseFilterAllKinds is the value of kind parameter for listing both expenses and income.
+const ExpenseFilterAllKinds = "all"
+
+// SetKind limits the filter to the transactions of the given kind.
+func (f *ExpenseFilter) SetKind(kind string) {
+	f.kind = kind
+}
+
+// expenseCursor is the position of an expense in the listing sorted by date.
+type expenseCursor struct {
+	date time.Time
+	id   string
+}
+
+// encodeExpenseCursor returns the opaque cursor pointing to the given expense.
+func encodeExpenseCursor(expense model.Expense) string {
+	value := expense.Date.Format(time.RFC3339Nano) + "|" + expense.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// decodeExpenseCursor parses the cursor created by encodeExpenseCursor.
+func decodeExpenseCursor(value string) (*expenseCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(string(data), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errInvalidCursor
+	}
+	date, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return &expenseCursor{date: date, id: parts[1]}, nil
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// sortOrder returns the sort parameter of the filter or the default order.
+func (f ExpenseFilter) sortOrder() string {
+	if f.order == "" {
+		return "-date"
+	}
+	return f.order
+}
+
+// usesCursor returns true if the expenses are paged using the cursor.
+func (f ExpenseFilter) usesCursor() bool {
+	order := f.sortOrder()
+	return order == "date" || order == "-date"
+}
+
+// NextCursor returns the cursor to the page after the given expenses, which
+// were fetched using this filter. Returns an empty string if there are no
+// more pages or the sort order doesn't support cursors.
+func (f ExpenseFilter) NextCursor(expenses []model.Expense) string {
+	if !f.usesCursor() || len(expenses) == 0 || len(expenses) < f.Pager.GetLimit() {
+		return ""
+	}
+	return encodeExpenseCursor(expenses[len(expenses)-1])
 }
 
 func (f ExpenseFilter) Filter(q *orm.Query) (*orm.Query, error) {
+	return f.filter(q, true)
+}
+
+// ExportFilter applies the filter like Filter, but limits the number of
+// expenses only when limit or page parameter is given so that exports and
+// bulk updates get all the matching expenses by default.
+func (f ExpenseFilter) ExportFilter(q *orm.Query) (*orm.Query, error) {
+	paged := f.Pager != nil && (f.Pager.Limit > 0 || f.Pager.Offset > 0)
+	return f.filter(q, paged)
+}
+
+func (f ExpenseFilter) filter(q *orm.Query, paged bool) (*orm.Query, error) {
+	q, err := f.where(q)
+	if err != nil {
+		return nil, err
+	}
+
+	order := f.sortOrder()
+	q = q.OrderExpr(expenseSortOrders[order])
+	if !f.usesCursor() {
+		q = q.OrderExpr("expense.id ASC")
+		if paged {
+			q = q.Apply(f.Pager.Pagination)
+		}
+		return q, nil
+	}
+
+	// Keyset pagination on (date, id), the id breaks the ties between
+	// expenses of the same date in the direction of the date order.
+	direction, op := "ASC", ">"
+	if order == "-date" {
+		direction, op = "DESC", "<"
+	}
+	q = q.OrderExpr("expense.id " + direction)
+	if f.cursor != nil {
+		q = q.Where("(expense.date, expense.id) "+op+" (?, ?)", f.cursor.date, f.cursor.id)
+	}
+	if paged {
+		q = q.Limit(f.Pager.GetLimit())
+	}
+	return q, nil
+}
+
+// where applies the conditions of the filter without the ordering and pagination.
+func (f ExpenseFilter) where(q *orm.Query) (*orm.Query, error) {
+	if f.kind != ExpenseFilterAllKinds {
+		kind := f.kind
+		if kind == "" {
+			kind = model.KindExpense
+		}
+		q = q.Where("expense.kind = ?", kind)
+	}
+
 	if f.year > 0 {
 		q = q.Where("EXTRACT(YEAR FROM expense.date) = ?", f.year)
 	}
@@ -96,28 +678,267 @@ func (f ExpenseFilter) Filter(q *orm.Query) (*orm.Query, error) {
 		q = q.Where("EXTRACT(MONTH FROM expense.date) = ?", f.month)
 	}
 
-	q = q.Apply(f.Pager.Pagination)
+	if !f.from.IsZero() {
+		q = q.Where("expense.date >= ?", f.from)
+	}
+
+	if !f.to.IsZero() {
+		// To date is inclusive.
+		q = q.Where("expense.date < ?", f.to.AddDate(0, 0, 1))
+	}
+
+	if len(f.accountIDs) > 0 {
+		q = q.Where("expense.account_id IN (?)", pg.In(f.accountIDs))
+	}
+
+	if len(f.categoryIDs) > 0 {
+		q = q.Where("expense.category_id IN (?)", pg.In(f.categoryIDs))
+	}
+
+	if f.minAmount != nil {
+		q = q.Where("expense.amount >= ?", *f.minAmount)
+	}
+
+	if f.maxAmount != nil {
+		q = q.Where("expense.amount <= ?", *f.maxAmount)
+	}
+
+	if f.title != "" {
+		q = q.Where("expense.title ILIKE ?", "%"+likeEscaper.Replace(f.title)+"%")
+	}
+
+	for _, tag := range f.tags {
+		q = q.Where(`EXISTS (SELECT 1 FROM expense_tags
+			JOIN tags ON tags.id = expense_tags.tag_id
+			WHERE expense_tags.expense_id = expense.id AND tags.name = ?)`, tag)
+	}
+
 	return q, nil
 }
 
-func (f *ExpenseFilter) ParseURLValues(values url.Values) {
-	v := urlvalues.Values(values)
-	currentTime := time.Now()
+// likeEscaper escapes the wildcard characters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
 
-	year, err := v.Int("year")
-	if err != nil || year == 0 {
-		// default year to current year
-		f.year = currentTime.Year()
-	} else {
-		f.year = year
+// ParseURLValues reads the filter from the url values. Returns the errors
+// keyed by the parameter name when a value can't be parsed.
+func (f *ExpenseFilter) ParseURLValues(values url.Values) url.Values {
+	errs := url.Values{}
+	f.year = parseInt(values, "year", errs)
+	f.month = parseInt(values, "month", errs)
+	if f.month < 0 || f.month > 12 {
+		errs.Add("month", ErrorInvalidChoice)
+	}
+	f.from = parseDate(values, "from", errs)
+	f.to = parseDate(values, "to", errs)
+	if !f.from.IsZero() && !f.to.IsZero() && f.to.Before(f.from) {
+		errs.Add("to", ErrorInvalidRange)
 	}
 
-	month, err := v.Int("month")
-	if err != nil || month == 0 {
+	allTime := false
+	if value := values.Get("all_time"); value != "" {
+		var err error
+		if allTime, err = strconv.ParseBool(value); err != nil {
+			errs.Add("all_time", ErrorInvalidBoolean)
+		}
+	}
+	hasDates := f.year > 0 || f.month > 0 || !f.from.IsZero() || !f.to.IsZero()
+	if !allTime && !hasDates {
+		// Default to the current month.
+		currentTime := time.Now()
+		f.year = currentTime.Year()
 		f.month = int(currentTime.Month())
-	} else {
-		f.month = month
+	} else if f.month > 0 && f.year == 0 {
+		f.year = time.Now().Year()
+	}
+
+	f.accountIDs = values["account_id"]
+	f.categoryIDs = values["category_id"]
+	f.minAmount = parseAmount(values, "amount_min", errs)
+	f.maxAmount = parseAmount(values, "amount_max", errs)
+	if f.minAmount != nil && f.maxAmount != nil && f.maxAmount.Cmp(*f.minAmount) < 0 {
+		errs.Add("amount_max", ErrorInvalidRange)
+	}
+	f.title = values.Get("title")
+	for _, tag := range values["tag"] {
+		f.tags = append(f.tags, model.NormalizeTagName(tag))
+	}
+
+	f.kind = values.Get("kind")
+	if f.kind != "" && f.kind != ExpenseFilterAllKinds && !model.IsValidKind(f.kind) {
+		errs.Add("kind", ErrorInvalidChoice)
+	}
+
+	f.order = values.Get("sort")
+	if _, ok := expenseSortOrders[f.order]; f.order != "" && !ok {
+		errs.Add("sort", ErrorInvalidChoice)
+	}
+
+	f.Pager = &urlvalues.Pager{Limit: parseInt(values, "limit", errs)}
+	if f.Pager.Limit < 0 {
+		errs.Add("limit", ErrorInvalidInteger)
+	}
+	f.Pager.SetPage(parseInt(values, "page", errs))
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeExpenseCursor(value)
+		if err != nil || !f.usesCursor() {
+			errs.Add("cursor", ErrorInvalidCursor)
+		}
+		f.cursor = cursor
+	}
+	return errs
+}
+
+// parseInt returns the integer value of the url parameter with given name.
+// Adds an error if the value is not a valid integer.
+func parseInt(values url.Values, name string, errs url.Values) int {
+	value := values.Get(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		errs.Add(name, ErrorInvalidInteger)
 	}
+	return n
+}
 
-	f.Pager = urlvalues.NewPager(v)
+// parseDate returns the date in the url parameter with given name.
+// Adds an error if the value is not in DateFormat.
+func parseDate(values url.Values, name string, errs url.Values) time.Time {
+	value := values.Get(name)
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		errs.Add(name, ErrorInvalidDate)
+	}
+	return date
+}
+
+// parseAmount returns the amount in the url parameter with given name.
+// Adds an error if the value is not a valid amount.
+func parseAmount(values url.Values, name string, errs url.Values) *model.Money {
+	value := values.Get(name)
+	if value == "" {
+		return nil
+	}
+	amount, err := model.ParseMoney(value, "")
+	if err != nil {
+		errs.Add(name, ErrorInvalidAmount)
+		return nil
+	}
+	return &amount
+}
+
+// AccountBalance returns the balance of the account at the end of the given
+// day. Income and expenses are counted from the opening date of the account
+// and transfers add to or take from the balance. Expenses in a currency
+// other than the account currency are left out and counted as unconverted.
+func (ess ExpenseSQLStore) AccountBalance(account *model.ExpenseAccount, day time.Time) (*model.AccountBalance, error) {
+	balance := model.NewAccountBalance(account, day)
+	until := balance.Date.AddDate(0, 0, 1)
+	since := func(column string) func(*orm.Query) (*orm.Query, error) {
+		return func(q *orm.Query) (*orm.Query, error) {
+			if !account.OpeningDate.IsZero() {
+				q = q.Where("? >= ?", pg.F(column), account.OpeningDate)
+			}
+			return q.Where("? < ?", pg.F(column), until), nil
+		}
+	}
+
+	// Expenses with an empty currency are in the account currency.
+	sameCurrency := pg.Q("COALESCE(expense.currency, '') IN (?, '')", account.Currency)
+	err := ess.sqlStore.db.Model((*model.Expense)(nil)).
+		ColumnExpr("COALESCE(SUM(expense.amount) FILTER (WHERE expense.kind = ? AND ?), 0) AS income", model.KindIncome, sameCurrency).
+		ColumnExpr("COALESCE(SUM(expense.amount) FILTER (WHERE expense.kind = ? AND ?), 0) AS expense", model.KindExpense, sameCurrency).
+		ColumnExpr("COUNT(*) FILTER (WHERE NOT ?) AS unconverted", sameCurrency).
+		Where("expense.account_id = ?", account.ID).
+		Apply(since("expense.date")).
+		Select(balance)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ess.sqlStore.db.Model((*model.Transfer)(nil)).
+		ColumnExpr("COALESCE(SUM(transfer.to_amount) FILTER (WHERE transfer.to_account_id = ?), 0) AS transfers_in", account.ID).
+		ColumnExpr("COALESCE(SUM(transfer.amount) FILTER (WHERE transfer.from_account_id = ?), 0) AS transfers_out", account.ID).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.WhereOr("transfer.from_account_id = ?", account.ID).
+				WhereOr("transfer.to_account_id = ?", account.ID)
+			return q, nil
+		}).
+		Apply(since("transfer.date")).
+		Select(balance)
+	if err != nil {
+		return nil, err
+	}
+	balance.Total()
+	return balance, nil
+}
+
+// GetAccountBalances returns the balances of all the user's accounts at the
+// end of the given day, counted the same way as AccountBalance but in a
+// single query.
+func (ess ExpenseSQLStore) GetAccountBalances(userId string, day time.Time) ([]model.AccountBalance, error) {
+	until := model.TruncateDate(day).AddDate(0, 0, 1)
+	// Expenses with an empty currency are in the account currency.
+	expenses := `LEFT JOIN (
+		SELECT expense.account_id,
+			SUM(expense.amount) FILTER (WHERE expense.kind = ?0 AND ?2) AS income,
+			SUM(expense.amount) FILTER (WHERE expense.kind = ?1 AND ?2) AS expense,
+			COUNT(*) FILTER (WHERE NOT ?2) AS unconverted
+		FROM expenses AS expense
+		JOIN expense_accounts AS account ON account.id = expense.account_id
+		WHERE account.user_id = ?3 AND expense.date < ?4
+			AND (account.opening_date IS NULL OR expense.date >= account.opening_date)
+		GROUP BY expense.account_id) AS expenses ON expenses.account_id = expense_account.id`
+	transfers := `LEFT JOIN (
+		SELECT side.account_id, SUM(side.amount_in) AS transfers_in, SUM(side.amount_out) AS transfers_out
+		FROM (
+			SELECT transfer.to_account_id AS account_id, transfer.to_amount AS amount_in, 0 AS amount_out, transfer.date
+			FROM transfers AS transfer
+			UNION ALL
+			SELECT transfer.from_account_id, 0, transfer.amount, transfer.date
+			FROM transfers AS transfer) AS side
+		JOIN expense_accounts AS account ON account.id = side.account_id
+		WHERE account.user_id = ?0 AND side.date < ?1
+			AND (account.opening_date IS NULL OR side.date >= account.opening_date)
+		GROUP BY side.account_id) AS transfers ON transfers.account_id = expense_account.id`
+	sameCurrency := pg.Q("COALESCE(expense.currency, '') IN (COALESCE(account.currency, ''), '')")
+
+	var balances []model.AccountBalance
+	err := ess.sqlStore.db.Model((*model.ExpenseAccount)(nil)).
+		ColumnExpr("expense_account.id AS account_id, expense_account.currency, expense_account.opening_balance").
+		ColumnExpr("COALESCE(expenses.income, 0) AS income, COALESCE(expenses.expense, 0) AS expense").
+		ColumnExpr("COALESCE(expenses.unconverted, 0) AS unconverted").
+		ColumnExpr("COALESCE(transfers.transfers_in, 0) AS transfers_in, COALESCE(transfers.transfers_out, 0) AS transfers_out").
+		Join(expenses, model.KindIncome, model.KindExpense, sameCurrency, userId, until).
+		Join(transfers, userId, until).
+		Where("expense_account.user_id = ?", userId).
+		Select(&balances)
+	if err != nil {
+		return nil, err
+	}
+	for i := range balances {
+		balances[i].Date = model.TruncateDate(day)
+		balances[i].Total()
+	}
+	return balances, nil
+}
+
+// ReconcileAccount marks the expenses of the account up to the end of the
+// given day as reconciled. Returns the number of expenses newly marked.
+func (ess ExpenseSQLStore) ReconcileAccount(account *model.ExpenseAccount, day time.Time) (int, error) {
+	res, err := ess.sqlStore.db.Model((*model.Expense)(nil)).
+		Set("reconciled = TRUE").
+		Where("expense.account_id = ?", account.ID).
+		Where("expense.date < ?", model.TruncateDate(day).AddDate(0, 0, 1)).
+		Where("NOT expense.reconciled").
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
 }
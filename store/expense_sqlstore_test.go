@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/ragsagar/wolff/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -52,6 +53,12 @@ func (s *ExpenseSQLStoreSuite) SetupTest() {
 		`TRUNCATE expenses`,
 		`TRUNCATE expense_categories`,
 		`TRUNCATE expense_accounts`,
+		`TRUNCATE transfers`,
+		`TRUNCATE recurring_expenses`,
+		`TRUNCATE budgets`,
+		`TRUNCATE rules`,
+		`TRUNCATE attachments`,
+		`TRUNCATE duplicate_dismissals`,
 	}
 	for _, query := range queries {
 		_, err := s.db.Query(query)
@@ -167,7 +174,7 @@ func (s *ExpenseSQLStoreSuite) TestStore() {
 	expense := &model.Expense{
 		AccountID:  "1234",
 		CategoryID: "25678",
-		Amount:     200,
+		Amount:     model.NewMoney(20000, ""),
 		UserID:     "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00",
 	}
 
@@ -189,3 +196,370 @@ func (s *ExpenseSQLStoreSuite) TestStore() {
 	assert.Equal(s.T(), expense.CategoryID, exp.Category.ID)
 	assert.Equal(s.T(), "Category2", exp.Category.Name)
 }
+
+func (s *ExpenseSQLStoreSuite) TestUpdateExpense() {
+	exp, err := s.store.Expense().GetByID("14566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	exp.Title = "Updated"
+	exp.Amount = model.NewMoney(5500, "")
+	exp.CategoryID = "25678"
+	err = s.store.Expense().UpdateExpense(exp)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	exp, err = s.store.Expense().GetByID("14566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "Updated", exp.Title)
+	assert.Equal(s.T(), "55.00", exp.Amount.String())
+	assert.Equal(s.T(), "Category2", exp.Category.Name)
+}
+
+func (s *ExpenseSQLStoreSuite) TestDeleteExpense() {
+	exp, err := s.store.Expense().GetByID("24566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	err = s.store.Expense().DeleteExpense(exp)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Expense().GetByID("24566")
+	assert.NotNil(s.T(), err, "Expense is not deleted.")
+}
+
+func (s *ExpenseSQLStoreSuite) TestCategories() {
+	category := &model.ExpenseCategory{Name: "Category3", UserID: "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"}
+	err := s.store.Expense().StoreCategory(category)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.NotEmpty(s.T(), category.ID, "Category id is empty.")
+
+	categories, err := s.store.Expense().GetExpenseCategories("5d6e34c8-46b7-11e6-ba7c-cafec0ffee00")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 3, len(categories))
+
+	category.Name = "Renamed"
+	err = s.store.Expense().UpdateCategory(category)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	fetched, err := s.store.Expense().GetCategoryByID(category.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "Renamed", fetched.Name)
+
+	// Deleting a category should unset it from the expenses, recurring
+	// expenses and rules and remove its budgets.
+	used, err := s.store.Expense().GetCategoryByID("15678")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	recurring := &model.RecurringExpense{AccountID: "1234", CategoryID: "15678", Amount: model.NewMoney(5000, ""), Title: "Rent", Frequency: model.FrequencyMonthly, DayOfMonth: 1, StartDate: model.TruncateDate(time.Now()), UserID: used.UserID}
+	if err := s.store.Recurring().StoreRecurring(recurring); err != nil {
+		s.T().Fatal(err)
+	}
+	budget := &model.Budget{CategoryID: "15678", Amount: model.NewMoney(10000, ""), StartDate: model.TruncateDate(time.Now()), UserID: used.UserID}
+	if err := s.store.Budget().StoreBudget(budget); err != nil {
+		s.T().Fatal(err)
+	}
+	rule := &model.Rule{Name: "Groceries", TitleContains: "market", CategoryID: "15678", UserID: used.UserID}
+	if err := s.store.Rule().StoreRule(rule); err != nil {
+		s.T().Fatal(err)
+	}
+	err = s.store.Expense().DeleteCategory(used)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	exp, err := s.store.Expense().GetByID("14566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "", exp.CategoryID)
+	assert.Nil(s.T(), exp.Category)
+	recurring, err = s.store.Recurring().GetRecurringByID(recurring.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "", recurring.CategoryID)
+	_, err = s.store.Budget().GetBudgetByID(budget.ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+	rule, err = s.store.Rule().GetRuleByID(rule.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "", rule.CategoryID)
+}
+
+func (s *ExpenseSQLStoreSuite) TestAccountBalance() {
+	account, err := s.store.Expense().GetAccountByID("1234")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	account.OpeningBalance = model.NewMoney(100000, "")
+	if err := s.store.Expense().UpdateAccount(account); err != nil {
+		s.T().Fatal(err)
+	}
+	today := model.TruncateDate(time.Now())
+
+	income := &model.Expense{AccountID: "1234", Amount: model.NewMoney(25000, ""), Kind: model.KindIncome, Date: today, UserID: account.UserID}
+	if err := s.store.Expense().Store(income); err != nil {
+		s.T().Fatal(err)
+	}
+	transfers := []*model.Transfer{
+		{FromAccountID: "1234", ToAccountID: "2234", Amount: model.NewMoney(30000, ""), ToAmount: model.NewMoney(30000, ""), Date: today, UserID: account.UserID},
+		{FromAccountID: "2234", ToAccountID: "1234", Amount: model.NewMoney(5000, ""), ToAmount: model.NewMoney(5000, ""), Date: today, UserID: account.UserID},
+		// Transfers after the day are not counted.
+		{FromAccountID: "1234", ToAccountID: "2234", Amount: model.NewMoney(7000, ""), ToAmount: model.NewMoney(7000, ""), Date: today.AddDate(0, 0, 2), UserID: account.UserID},
+	}
+	for _, transfer := range transfers {
+		if err := s.store.Transfer().StoreTransfer(transfer); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+
+	balance, err := s.store.Expense().AccountBalance(account, today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "180.00", balance.Expense.String())
+	assert.Equal(s.T(), "250.00", balance.Income.String())
+	assert.Equal(s.T(), "50.00", balance.TransfersIn.String())
+	assert.Equal(s.T(), "300.00", balance.TransfersOut.String())
+	assert.Equal(s.T(), "820.00", balance.Balance.String())
+
+	balances, err := s.store.Expense().GetAccountBalances(account.UserID, today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	found := false
+	for _, b := range balances {
+		if b.AccountID == account.ID {
+			found = true
+			assert.Equal(s.T(), *balance, b, "Balance of the account is not the same as AccountBalance.")
+		}
+	}
+	assert.True(s.T(), found, "Balance of the account is missing.")
+
+	// Transactions before the opening date are part of the opening balance.
+	account.OpeningDate = today.AddDate(0, 0, 1)
+	balance, err = s.store.Expense().AccountBalance(account, today.AddDate(0, 0, 1))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "1000.00", balance.Balance.String())
+
+	count, err := s.store.Expense().ReconcileAccount(account, today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 4, count)
+	count, err = s.store.Expense().ReconcileAccount(account, today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 0, count, "Reconciled expenses are marked again.")
+}
+
+func (s *ExpenseSQLStoreSuite) TestDeleteAccount() {
+	account, err := s.store.Expense().GetAccountByID("2234")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	err = s.store.Expense().DeleteAccount(account, nil)
+	if assert.IsType(s.T(), &AccountInUseError{}, err) {
+		assert.Equal(s.T(), 2, err.(*AccountInUseError).Count)
+	}
+
+	moveTo, err := s.store.Expense().GetAccountByID("1234")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	rule := &model.Rule{Name: "Dinner", TitleContains: "dinner", AccountID: "2234", UserID: account.UserID}
+	if err := s.store.Rule().StoreRule(rule); err != nil {
+		s.T().Fatal(err)
+	}
+	// Transfers between the two accounts can't be moved.
+	transfer := &model.Transfer{FromAccountID: "1234", ToAccountID: "2234", Amount: model.NewMoney(3000, ""), ToAmount: model.NewMoney(3000, ""), Date: model.TruncateDate(time.Now()), UserID: account.UserID}
+	if err := s.store.Transfer().StoreTransfer(transfer); err != nil {
+		s.T().Fatal(err)
+	}
+	err = s.store.Expense().DeleteAccount(account, moveTo)
+	if assert.IsType(s.T(), &AccountInUseError{}, err) {
+		assert.Equal(s.T(), 1, err.(*AccountInUseError).Count)
+	}
+	if err := s.store.Transfer().DeleteTransfer(transfer); err != nil {
+		s.T().Fatal(err)
+	}
+	if err := s.store.Expense().DeleteAccount(account, moveTo); err != nil {
+		s.T().Fatal(err)
+	}
+	rule, err = s.store.Rule().GetRuleByID(rule.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "1234", rule.AccountID)
+	exp, err := s.store.Expense().GetByID("44566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "1234", exp.AccountID)
+	_, err = s.store.Expense().GetAccountByID("2234")
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+
+	// Archived accounts are hidden from the listing.
+	moveTo.Archived = true
+	if err := s.store.Expense().UpdateAccount(moveTo); err != nil {
+		s.T().Fatal(err)
+	}
+	accounts, err := s.store.Expense().GetExpenseAccounts(moveTo.UserID, false)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Empty(s.T(), accounts)
+	accounts, err = s.store.Expense().GetExpenseAccounts(moveTo.UserID, true)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(accounts))
+}
+
+func (s *ExpenseSQLStoreSuite) TestAttachments() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	for _, size := range []int64{1000, 2500} {
+		attachment := &model.Attachment{ExpenseID: "14566", FileName: "receipt.png", ContentType: "image/png", Size: size, UserID: userID}
+		if err := s.store.Expense().StoreAttachment(attachment); err != nil {
+			s.T().Fatal(err)
+		}
+		assert.NotEmpty(s.T(), attachment.ID)
+	}
+
+	attachments, err := s.store.Expense().GetAttachments("14566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 2, len(attachments))
+	size, err := s.store.Expense().AttachmentsSize(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), int64(3500), size)
+
+	if err := s.store.Expense().DeleteAttachment(&attachments[0]); err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Expense().GetAttachmentByID(attachments[0].ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+
+	// Deleting the expense removes the rest of its attachments.
+	exp, err := s.store.Expense().GetByID("14566")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if err := s.store.Expense().DeleteExpense(exp); err != nil {
+		s.T().Fatal(err)
+	}
+	size, err = s.store.Expense().AttachmentsSize(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), int64(0), size)
+}
+
+func (s *ExpenseSQLStoreSuite) TestStoreExpenses() {
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	expenses := []model.Expense{
+		{AccountID: "2234", Amount: model.NewMoney(1250, ""), Date: date, Title: "Grocery", UserID: "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
+		{AccountID: "2234", Amount: model.NewMoney(300, ""), Date: date.AddDate(0, 0, 1), Title: "Coffee", UserID: "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
+	}
+	if err := s.store.Expense().StoreExpenses(expenses); err != nil {
+		s.T().Fatal(err)
+	}
+	assert.NotEmpty(s.T(), expenses[0].ID)
+
+	stored, err := s.store.Expense().GetAccountExpenses("2234", date, date)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 1, len(stored)) {
+		assert.Equal(s.T(), expenses[0].ID, stored[0].ID)
+	}
+}
+
+func (s *ExpenseSQLStoreSuite) TestDuplicates() {
+	userID := "7d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	date := time.Date(2019, 3, 4, 10, 0, 0, 0, time.UTC)
+	expenses := []model.Expense{
+		{AccountID: "2299", Amount: model.NewMoney(1250, ""), Date: date, Title: "Lidl", UserID: userID},
+		{AccountID: "2299", Amount: model.NewMoney(1250, ""), Date: date.AddDate(0, 0, 2), Title: "LIDL 123", UserID: userID,
+			Tags: []model.Tag{{Name: "weekly"}}},
+		{AccountID: "2299", Amount: model.NewMoney(1250, ""), Date: date.AddDate(0, 0, 10), Title: "Lidl", UserID: userID},
+		{AccountID: "2299", Amount: model.NewMoney(300, ""), Date: date, Title: "Coffee", UserID: userID},
+	}
+	if err := s.store.Expense().StoreExpenses(expenses); err != nil {
+		s.T().Fatal(err)
+	}
+	attachment := &model.Attachment{ExpenseID: expenses[1].ID, FileName: "receipt.png", ContentType: "image/png", Size: 10, UserID: userID}
+	if err := s.store.Expense().StoreAttachment(attachment); err != nil {
+		s.T().Fatal(err)
+	}
+
+	candidates, err := s.store.Expense().GetDuplicateCandidates(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 2, len(candidates)) {
+		assert.Equal(s.T(), expenses[0].ID, candidates[0].ID)
+		assert.Equal(s.T(), expenses[1].ID, candidates[1].ID)
+	}
+	possible, err := s.store.Expense().GetPossibleDuplicates(&expenses[0])
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 1, len(possible)) {
+		assert.Equal(s.T(), expenses[1].ID, possible[0].ID)
+	}
+
+	dismissal := model.NewDuplicateDismissal(userID, expenses[0].ID, expenses[1].ID)
+	for i := 0; i < 2; i++ {
+		if err := s.store.Expense().DismissDuplicates([]model.DuplicateDismissal{dismissal}); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+	dismissals, err := s.store.Expense().GetDuplicateDismissals(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(dismissals))
+
+	keep := &candidates[0]
+	keep.Merge(&candidates[1])
+	if err := s.store.Expense().MergeExpenses(keep, candidates[1:]); err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Expense().GetByID(expenses[1].ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+	merged, err := s.store.Expense().GetByID(keep.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(merged.Tags))
+	attachments, err := s.store.Expense().GetAttachments(keep.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(attachments))
+	dismissals, err = s.store.Expense().GetDuplicateDismissals(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 0, len(dismissals))
+}
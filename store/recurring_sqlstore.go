@@ -0,0 +1,118 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+)
+
+// RecurringExpenseSQLStore is the SQL implementation of RecurringExpenseStore interface.
+type RecurringExpenseSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewRecurringExpenseSQLStore returns new RecurringExpenseSQLStore object.
+func NewRecurringExpenseSQLStore(sqlStore SQLStore) *RecurringExpenseSQLStore {
+	return &RecurringExpenseSQLStore{sqlStore: &sqlStore}
+}
+
+// StoreRecurring saves the given recurring expense after populating ID, CreatedAt, UpdatedAt and NextDate fields.
+func (rs RecurringExpenseSQLStore) StoreRecurring(recurring *model.RecurringExpense) error {
+	recurring.PreSave()
+	return rs.sqlStore.db.Insert(recurring)
+}
+
+// GetRecurringExpenses returns all the recurring expenses of the given user ordered by the next date.
+func (rs RecurringExpenseSQLStore) GetRecurringExpenses(userId string) ([]model.RecurringExpense, error) {
+	var recurring []model.RecurringExpense
+	err := rs.sqlStore.db.Model(&recurring).
+		Relation("Account").
+		Relation("Category").
+		Where("recurring_expense.user_id = ?", userId).
+		Order("recurring_expense.next_date ASC").
+		Select()
+	if err != nil {
+		log.Println("Error in fetching recurring expenses for user_id ", userId)
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// GetRecurringByID fetches the RecurringExpense object with given id.
+func (rs RecurringExpenseSQLStore) GetRecurringByID(id string) (*model.RecurringExpense, error) {
+	recurring := new(model.RecurringExpense)
+	err := rs.sqlStore.db.Model(recurring).
+		Relation("Account").
+		Relation("Category").
+		Where("recurring_expense.id = ?", id).
+		Select()
+	if err != nil {
+		log.Println("Error in fetching recurring expense with id ", id)
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// UpdateRecurring saves the changes in the given recurring expense after refreshing UpdatedAt field.
+func (rs RecurringExpenseSQLStore) UpdateRecurring(recurring *model.RecurringExpense) error {
+	recurring.PreUpdate()
+	return rs.sqlStore.db.Update(recurring)
+}
+
+// DeleteRecurring removes the given recurring expense. Expenses created from it are kept.
+func (rs RecurringExpenseSQLStore) DeleteRecurring(recurring *model.RecurringExpense) error {
+	return rs.sqlStore.db.Delete(recurring)
+}
+
+// CreateDueExpenses saves the expenses of all the occurrences due till the
+// given day and returns the number of expenses created. Each recurring
+// expense is locked and its NextDate is moved in the same transaction as the
+// expenses are saved, so an occurrence is created only once even if the
+// server restarts or several servers run it at the same time.
+func (rs RecurringExpenseSQLStore) CreateDueExpenses(today time.Time) (int, error) {
+	today = model.TruncateDate(today)
+	var ids []string
+	err := rs.sqlStore.db.Model((*model.RecurringExpense)(nil)).
+		Column("id").
+		Where("next_date <= ?", today).
+		Where("end_date IS NULL OR next_date <= end_date").
+		Select(&ids)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var lastErr error
+	for _, id := range ids {
+		var expenses []model.Expense
+		err := rs.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+			recurring := new(model.RecurringExpense)
+			err := tx.Model(recurring).Where("id = ?", id).For("UPDATE").Select()
+			if err != nil {
+				return err
+			}
+			expenses = recurring.DueExpenses(today)
+			for i := range expenses {
+				expenses[i].PreSave()
+				if err := tx.Insert(&expenses[i]); err != nil {
+					return err
+				}
+			}
+			recurring.PreUpdate()
+			return tx.Update(recurring)
+		})
+		if err == pg.ErrNoRows {
+			// Deleted after the due ones were listed.
+			continue
+		}
+		if err != nil {
+			log.Println("Error in creating expenses of recurring expense", id, err.Error())
+			lastErr = err
+			continue
+		}
+		count += len(expenses)
+	}
+	return count, lastErr
+}
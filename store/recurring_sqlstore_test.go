@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecurringExpenseSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *RecurringExpenseSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite RecurringExpenseSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	_, err = s.db.Query(`DROP TABLE IF EXISTS recurring_expenses`)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *RecurringExpenseSQLStoreSuite) SetupTest() {
+	queries := []string{
+		`TRUNCATE expenses`,
+		`TRUNCATE recurring_expenses`,
+	}
+	for _, query := range queries {
+		_, err := s.db.Query(query)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+}
+
+func TestRecurringExpenseSQLStoreSuite(t *testing.T) {
+	s := new(RecurringExpenseSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *RecurringExpenseSQLStoreSuite) TestCreateDueExpenses() {
+	recurring := &model.RecurringExpense{
+		AccountID: "1234",
+		Amount:    model.NewMoney(95000, "EUR"),
+		Currency:  "EUR",
+		Title:     "Rent",
+		Frequency: model.FrequencyMonthly,
+		StartDate: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		UserID:    "u1",
+	}
+	if err := s.store.Recurring().StoreRecurring(recurring); err != nil {
+		s.T().Fatal(err)
+	}
+
+	today := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	count, err := s.store.Recurring().CreateDueExpenses(today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 3, count)
+
+	count, err = s.store.Recurring().CreateDueExpenses(today)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 0, count, "Occurrences are created again.")
+
+	expenses, err := s.store.Expense().GetExpenses("u1", ExpenseFilter{year: 2019, order: "date"})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 3, len(expenses))
+	assert.Equal(s.T(), "950.00", expenses[0].Amount.String())
+
+	recurring, err = s.store.Recurring().GetRecurringByID(recurring.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC), recurring.NextDate)
+}
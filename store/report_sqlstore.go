@@ -0,0 +1,193 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/go-pg/pg/types"
+	"github.com/ragsagar/wolff/model"
+)
+
+// DateFormat is the format of dates in url query parameters.
+const DateFormat = "2006-01-02"
+
+// ReportPeriods maps the supported report periods to the postgres date_trunc units.
+var ReportPeriods = map[string]string{
+	"daily":   "day",
+	"weekly":  "week",
+	"monthly": "month",
+	"yearly":  "year",
+}
+
+// ReportSQLStore is the SQL implementation of ReportStore interface.
+type ReportSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewReportSQLStore returns new ReportSQLStore object.
+func NewReportSQLStore(sqlStore SQLStore) *ReportSQLStore {
+	return &ReportSQLStore{sqlStore: &sqlStore}
+}
+
+// TotalsByCategory returns the expense totals of the user grouped by category.
+func (rs ReportSQLStore) TotalsByCategory(userId string, filter ReportFilter) ([]model.ReportEntry, error) {
+	return rs.totals(userId, filter, func(q *orm.Query) (*orm.Query, error) {
+		q = q.ColumnExpr("expense.category_id AS key, category.name AS name").
+			Join("LEFT JOIN expense_categories AS category ON category.id = expense.category_id").
+			Group("expense.category_id", "category.name").
+			Order("total DESC")
+		return q, nil
+	})
+}
+
+// TotalsByAccount returns the expense totals of the user grouped by account.
+func (rs ReportSQLStore) TotalsByAccount(userId string, filter ReportFilter) ([]model.ReportEntry, error) {
+	return rs.totals(userId, filter, func(q *orm.Query) (*orm.Query, error) {
+		q = q.ColumnExpr("expense.account_id AS key, account.name AS name").
+			Join("LEFT JOIN expense_accounts AS account ON account.id = expense.account_id").
+			Group("expense.account_id", "account.name").
+			Order("total DESC")
+		return q, nil
+	})
+}
+
+// TotalsByPeriod returns the expense totals of the user grouped by the given
+// period, which should be one of the keys of ReportPeriods. Key of each entry
+// is the start date of the period.
+func (rs ReportSQLStore) TotalsByPeriod(userId string, period string, filter ReportFilter) ([]model.ReportEntry, error) {
+	unit, ok := ReportPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid report period %s", period)
+	}
+	return rs.totals(userId, filter, func(q *orm.Query) (*orm.Query, error) {
+		q = q.ColumnExpr("to_char(date_trunc(?, expense.date), 'YYYY-MM-DD') AS key", unit).
+			GroupExpr("key").
+			Order("key ASC")
+		return q, nil
+	})
+}
+
+// totals runs the aggregation query of user's expenses grouped by the columns
+// added in group. Income is left out of the totals.
+func (rs ReportSQLStore) totals(userId string, filter ReportFilter, group func(*orm.Query) (*orm.Query, error)) ([]model.ReportEntry, error) {
+	var entries []model.ReportEntry
+	err := rs.sqlStore.db.Model((*model.Expense)(nil)).
+		ColumnExpr("COUNT(*) AS count").
+		ColumnExpr("COALESCE(SUM(?), 0) AS total", filter.amount()).
+		Where("expense.user_id = ?", userId).
+		Where("expense.kind = ?", model.KindExpense).
+		Apply(filter.Filter).
+		Apply(group).
+		Select(&entries)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Total.Currency = entries[i].Currency
+	}
+	return entries, nil
+}
+
+// CashFlowByPeriod returns the income, expense and net totals of the user
+// grouped by the given period, which should be one of the keys of
+// ReportPeriods. Key of each entry is the start date of the period.
+func (rs ReportSQLStore) CashFlowByPeriod(userId string, period string, filter ReportFilter) ([]model.CashFlowEntry, error) {
+	unit, ok := ReportPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid report period %s", period)
+	}
+	var entries []model.CashFlowEntry
+	err := rs.sqlStore.db.Model((*model.Expense)(nil)).
+		ColumnExpr("to_char(date_trunc(?, expense.date), 'YYYY-MM-DD') AS key", unit).
+		ColumnExpr("COUNT(*) AS count").
+		ColumnExpr("COALESCE(SUM(?) FILTER (WHERE expense.kind = ?), 0) AS income", filter.amount(), model.KindIncome).
+		ColumnExpr("COALESCE(SUM(?) FILTER (WHERE expense.kind = ?), 0) AS expense", filter.amount(), model.KindExpense).
+		Where("expense.user_id = ?", userId).
+		Apply(filter.Filter).
+		GroupExpr("key").
+		Order("key ASC").
+		Select(&entries)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.Income.Currency = entry.Currency
+		entry.Expense.Currency = entry.Currency
+		entry.Net, _ = entry.Income.Sub(entry.Expense)
+	}
+	return entries, nil
+}
+
+// ReportFilter limits the expenses used in reports to a date range and
+// optionally to an account or a category. If Currency is set the totals are
+// converted into it using the exchange rate on the expense date, otherwise
+// totals are grouped by currency.
+type ReportFilter struct {
+	From       time.Time
+	To         time.Time
+	AccountID  string
+	CategoryID string
+	Currency   string
+}
+
+// Filter applies the date range, account, category and the currency
+// conversion to the report query. The totals should be summed from amount.
+func (f ReportFilter) Filter(q *orm.Query) (*orm.Query, error) {
+	if !f.From.IsZero() {
+		q = q.Where("expense.date >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		// To date is inclusive.
+		q = q.Where("expense.date < ?", f.To.AddDate(0, 0, 1))
+	}
+	if f.AccountID != "" {
+		q = q.Where("expense.account_id = ?", f.AccountID)
+	}
+	if f.CategoryID != "" {
+		q = q.Where("expense.category_id = ?", f.CategoryID)
+	}
+
+	if f.Currency == "" {
+		q = q.ColumnExpr("expense.currency AS currency").
+			Group("expense.currency")
+		return q, nil
+	}
+
+	// Rate is 1 for expenses already in the currency, otherwise the latest
+	// known rate on or before the expense date in either direction.
+	q = q.Join(`LEFT JOIN LATERAL (
+		SELECT CASE WHEN r.from_currency = expense.currency THEN r.rate ELSE 1 / r.rate END AS rate
+		FROM exchange_rates AS r
+		WHERE r.date <= expense.date AND (
+			(r.from_currency = expense.currency AND r.to_currency = ?0) OR
+			(r.from_currency = ?0 AND r.to_currency = expense.currency))
+		ORDER BY r.date DESC LIMIT 1) AS rate ON expense.currency <> ?0`, f.Currency).
+		ColumnExpr("?::text AS currency", f.Currency).
+		ColumnExpr("COUNT(*) - COUNT(CASE WHEN expense.currency = ? THEN 1 ELSE rate.rate END) AS unconverted", f.Currency)
+	return q, nil
+}
+
+// amount returns the expression of the expense amount in the report
+// currency, which is NULL if there is no exchange rate for the expense.
+func (f ReportFilter) amount() types.ValueAppender {
+	if f.Currency == "" {
+		return pg.Q("expense.amount")
+	}
+	return pg.Q("ROUND(expense.amount * (CASE WHEN expense.currency = ? THEN 1 ELSE rate.rate END)::numeric, 2)", f.Currency)
+}
+
+// ParseURLValues reads the from and to dates from the url values. Returns
+// the errors keyed by the parameter name when a value can't be parsed.
+func (f *ReportFilter) ParseURLValues(values url.Values) url.Values {
+	errs := url.Values{}
+	f.From = parseDate(values, "from", errs)
+	f.To = parseDate(values, "to", errs)
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		errs.Add("to", ErrorInvalidRange)
+	}
+	return errs
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReportSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *ReportSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite ReportSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *ReportSQLStoreSuite) SetupTest() {
+	queries := []string{
+		`TRUNCATE expenses`,
+		`TRUNCATE expense_categories`,
+		`TRUNCATE expense_accounts`,
+		`TRUNCATE exchange_rates`,
+		`INSERT INTO expense_categories (id, name, user_id) VALUES
+			('15678', 'Category1', 'u1'), ('25678', 'Category2', 'u1')`,
+		`INSERT INTO expense_accounts (id, name, user_id) VALUES ('1234', 'Grocery', 'u1')`,
+		`INSERT INTO expenses (id, account_id, category_id, amount, currency, user_id, date) VALUES
+			('1', '1234', '15678', 10.10, 'EUR', 'u1', '2019-01-01'),
+			('2', '1234', '15678', 20.20, 'EUR', 'u1', '2019-01-15'),
+			('3', '1234', '25678', 100, 'INR', 'u1', '2019-02-03'),
+			('4', '1234', '25678', 5, 'USD', 'u1', '2019-02-03'),
+			('5', '1234', '25678', 99, 'EUR', 'u2', '2019-01-01')`,
+		`INSERT INTO expenses (id, account_id, amount, currency, user_id, date, kind) VALUES
+			('6', '1234', 500, 'EUR', 'u1', '2019-01-31', 'income')`,
+		`INSERT INTO exchange_rates (date, from_currency, to_currency, rate) VALUES ('2019-01-01', 'EUR', 'INR', 80)`,
+	}
+	for _, query := range queries {
+		_, err := s.db.Query(query)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+}
+
+func TestReportSQLStoreSuite(t *testing.T) {
+	s := new(ReportSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *ReportSQLStoreSuite) TestTotalsByCategory() {
+	entries, err := s.store.Report().TotalsByCategory("u1", ReportFilter{})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 3, len(entries))
+	assert.Equal(s.T(), model.ReportEntry{Key: "25678", Name: "Category2", Currency: "INR", Total: model.NewMoney(10000, "INR"), Count: 1}, entries[0])
+	assert.Equal(s.T(), model.ReportEntry{Key: "15678", Name: "Category1", Currency: "EUR", Total: model.NewMoney(3030, "EUR"), Count: 2}, entries[1])
+}
+
+func (s *ReportSQLStoreSuite) TestTotalsByPeriod() {
+	filter := ReportFilter{From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)}
+	entries, err := s.store.Report().TotalsByPeriod("u1", "monthly", filter)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(entries))
+	assert.Equal(s.T(), "2019-01-01", entries[0].Key)
+	assert.Equal(s.T(), "30.30", entries[0].Total.String())
+
+	_, err = s.store.Report().TotalsByPeriod("u1", "hourly", filter)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *ReportSQLStoreSuite) TestTotalsConverted() {
+	entries, err := s.store.Report().TotalsByAccount("u1", ReportFilter{Currency: "INR"})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(entries))
+	// 30.30 EUR at 80 plus 100 INR, USD expense has no rate.
+	assert.Equal(s.T(), model.ReportEntry{Key: "1234", Name: "Grocery", Currency: "INR", Total: model.NewMoney(252400, "INR"), Count: 4, Unconverted: 1}, entries[0])
+}
+
+func (s *ReportSQLStoreSuite) TestTotalsOfCategory() {
+	entries, err := s.store.Report().TotalsByPeriod("u1", "monthly", ReportFilter{CategoryID: "25678", Currency: "INR"})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(entries))
+	assert.Equal(s.T(), "2019-02-01", entries[0].Key)
+	assert.Equal(s.T(), "100.00", entries[0].Total.String())
+	assert.Equal(s.T(), 1, entries[0].Unconverted)
+}
+
+func (s *ReportSQLStoreSuite) TestCashFlowByPeriod() {
+	entries, err := s.store.Report().CashFlowByPeriod("u1", "monthly", ReportFilter{Currency: "EUR", To: time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 1, len(entries))
+	assert.Equal(s.T(), model.CashFlowEntry{
+		Key:      "2019-01-01",
+		Currency: "EUR",
+		Income:   model.NewMoney(50000, "EUR"),
+		Expense:  model.NewMoney(3030, "EUR"),
+		Net:      model.NewMoney(46970, "EUR"),
+		Count:    3,
+	}, entries[0])
+}
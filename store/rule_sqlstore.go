@@ -0,0 +1,59 @@
+package store
+
+import (
+	"log"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// RuleSQLStore is the SQL implementation of RuleStore interface.
+type RuleSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewRuleSQLStore returns new RuleSQLStore object.
+func NewRuleSQLStore(sqlStore SQLStore) *RuleSQLStore {
+	return &RuleSQLStore{sqlStore: &sqlStore}
+}
+
+// StoreRule saves the given rule after populating ID, CreatedAt and UpdatedAt fields.
+func (rs RuleSQLStore) StoreRule(rule *model.Rule) error {
+	rule.PreSave()
+	return rs.sqlStore.db.Insert(rule)
+}
+
+// GetRules returns the rules of the given user in the order they are applied.
+func (rs RuleSQLStore) GetRules(userId string) ([]model.Rule, error) {
+	var rules []model.Rule
+	err := rs.sqlStore.db.Model(&rules).
+		Where("rule.user_id = ?", userId).
+		Order("rule.position ASC", "rule.created_at ASC").
+		Select()
+	if err != nil {
+		log.Println("Error in fetching rules for user_id ", userId)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRuleByID fetches the Rule object with given id.
+func (rs RuleSQLStore) GetRuleByID(id string) (*model.Rule, error) {
+	rule := new(model.Rule)
+	err := rs.sqlStore.db.Model(rule).Where("rule.id = ?", id).Select()
+	if err != nil {
+		log.Println("Error in fetching rule with id ", id)
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule saves the changes in the given rule after refreshing UpdatedAt field.
+func (rs RuleSQLStore) UpdateRule(rule *model.Rule) error {
+	rule.PreUpdate()
+	return rs.sqlStore.db.Update(rule)
+}
+
+// DeleteRule removes the given rule.
+func (rs RuleSQLStore) DeleteRule(rule *model.Rule) error {
+	return rs.sqlStore.db.Delete(rule)
+}
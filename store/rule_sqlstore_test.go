@@ -0,0 +1,86 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RuleSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *RuleSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite RuleSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	if _, err := s.db.Query(`DROP TABLE IF EXISTS rules`); err != nil {
+		s.T().Fatal(err)
+	}
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *RuleSQLStoreSuite) SetupTest() {
+	if _, err := s.db.Query(`TRUNCATE rules`); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func TestRuleSQLStoreSuite(t *testing.T) {
+	s := new(RuleSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *RuleSQLStoreSuite) TestRules() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	max := model.NewMoney(5000, "")
+	taxi := &model.Rule{Name: "Taxi", Position: 2, TitleContains: "uber", TagNames: []string{"travel", "work"}, UserID: userID}
+	small := &model.Rule{Name: "Small", Position: 1, MaxAmount: &max, CategoryID: "15678", UserID: userID}
+	for _, rule := range []*model.Rule{taxi, small} {
+		if err := s.store.Rule().StoreRule(rule); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+
+	rules, err := s.store.Rule().GetRules(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 2, len(rules)) {
+		assert.Equal(s.T(), small.ID, rules[0].ID, "Rules are not ordered by position.")
+		assert.Equal(s.T(), "50.00", rules[0].MaxAmount.String())
+		assert.Nil(s.T(), rules[0].MinAmount)
+		assert.Equal(s.T(), []string{"travel", "work"}, rules[1].TagNames)
+	}
+
+	taxi.TagNames = []string{"travel"}
+	if err := s.store.Rule().UpdateRule(taxi); err != nil {
+		s.T().Fatal(err)
+	}
+	rule, err := s.store.Rule().GetRuleByID(taxi.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), []string{"travel"}, rule.TagNames)
+
+	if err := s.store.Rule().DeleteRule(rule); err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Rule().GetRuleByID(taxi.ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+}
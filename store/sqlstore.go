@@ -13,6 +13,13 @@ type SQLStore struct {
 	userSQLStore   *UserSQLStore
 	authTokenStore *AuthTokenSQLStore
 	expenseStore   *ExpenseSQLStore
+	rateStore      *ExchangeRateSQLStore
+	reportStore    *ReportSQLStore
+	recurringStore *RecurringExpenseSQLStore
+	budgetStore    *BudgetSQLStore
+	transferStore  *TransferSQLStore
+	tagStore       *TagSQLStore
+	ruleStore      *RuleSQLStore
 	db             *pg.DB
 }
 
@@ -39,6 +46,13 @@ func NewSQLStore(user, password, database, addr string) SQLStore {
 	sqlStore.userSQLStore = NewUserSQLStore(sqlStore)
 	sqlStore.authTokenStore = NewAuthTokenSQLStore(sqlStore)
 	sqlStore.expenseStore = NewExpenseSQLStore(sqlStore)
+	sqlStore.rateStore = NewExchangeRateSQLStore(sqlStore)
+	sqlStore.reportStore = NewReportSQLStore(sqlStore)
+	sqlStore.recurringStore = NewRecurringExpenseSQLStore(sqlStore)
+	sqlStore.budgetStore = NewBudgetSQLStore(sqlStore)
+	sqlStore.transferStore = NewTransferSQLStore(sqlStore)
+	sqlStore.tagStore = NewTagSQLStore(sqlStore)
+	sqlStore.ruleStore = NewRuleSQLStore(sqlStore)
 	createSchema(sqlStore.db)
 	return sqlStore
 }
@@ -58,6 +72,41 @@ func (sqlStore SQLStore) Expense() ExpenseStore {
 	return sqlStore.expenseStore
 }
 
+// ExchangeRate returns ExchangeRateSQLStore to implement Store interface
+func (sqlStore SQLStore) ExchangeRate() ExchangeRateStore {
+	return sqlStore.rateStore
+}
+
+// Report returns ReportSQLStore to implement Store interface
+func (sqlStore SQLStore) Report() ReportStore {
+	return sqlStore.reportStore
+}
+
+// Recurring returns RecurringExpenseSQLStore to implement Store interface
+func (sqlStore SQLStore) Recurring() RecurringExpenseStore {
+	return sqlStore.recurringStore
+}
+
+// Budget returns BudgetSQLStore to implement Store interface
+func (sqlStore SQLStore) Budget() BudgetStore {
+	return sqlStore.budgetStore
+}
+
+// Transfer returns TransferSQLStore to implement Store interface
+func (sqlStore SQLStore) Transfer() TransferStore {
+	return sqlStore.transferStore
+}
+
+// Tag returns TagSQLStore to implement Store interface
+func (sqlStore SQLStore) Tag() TagStore {
+	return sqlStore.tagStore
+}
+
+// Rule returns RuleSQLStore to implement Store interface
+func (sqlStore SQLStore) Rule() RuleStore {
+	return sqlStore.ruleStore
+}
+
 func createSchema(db *pg.DB) {
 	log.Println("Creating schema.")
 	// queries := []string{
@@ -86,9 +135,20 @@ func createSchema(db *pg.DB) {
 	models := []interface{}{
 		(*model.User)(nil),
 		(*model.AuthToken)(nil),
+		(*model.RefreshToken)(nil),
+		(*model.PasswordReset)(nil),
 		(*model.Expense)(nil),
 		(*model.ExpenseAccount)(nil),
 		(*model.ExpenseCategory)(nil),
+		(*model.ExchangeRate)(nil),
+		(*model.RecurringExpense)(nil),
+		(*model.Budget)(nil),
+		(*model.Transfer)(nil),
+		(*model.Tag)(nil),
+		(*model.ExpenseTag)(nil),
+		(*model.Attachment)(nil),
+		(*model.Rule)(nil),
+		(*model.DuplicateDismissal)(nil),
 	}
 	for _, model := range models {
 		err := db.CreateTable(model, &orm.CreateTableOptions{
@@ -99,4 +159,37 @@ func createSchema(db *pg.DB) {
 			panic(err)
 		}
 	}
+	migrateSchema(db)
+}
+
+// schemaMigrations bring the tables created by earlier versions up to date,
+// as CreateTable skips the existing tables. Each query should be safe to run
+// again.
+var schemaMigrations = []string{
+	`ALTER TABLE expenses ALTER COLUMN amount TYPE numeric USING round(amount::numeric, 2)`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS base_currency text`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency text`,
+	`ALTER TABLE expense_accounts ADD COLUMN IF NOT EXISTS currency text`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS kind text NOT NULL DEFAULT 'expense'`,
+	`ALTER TABLE expenses ADD COLUMN IF NOT EXISTS reconciled boolean NOT NULL DEFAULT false`,
+	`ALTER TABLE expense_accounts ADD COLUMN IF NOT EXISTS opening_balance numeric NOT NULL DEFAULT 0`,
+	`ALTER TABLE expense_accounts ADD COLUMN IF NOT EXISTS opening_date date`,
+	`ALTER TABLE expense_accounts ADD COLUMN IF NOT EXISTS archived boolean NOT NULL DEFAULT false`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS id text`,
+	`UPDATE auth_tokens SET id = md5(random()::text || key) WHERE id IS NULL`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS device_name text`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS user_agent text`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS ip text`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS created_at timestamptz`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS last_used_at timestamptz`,
+	`ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS refresh_expiry timestamptz`,
+}
+
+func migrateSchema(db *pg.DB) {
+	for _, query := range schemaMigrations {
+		_, err := db.Exec(query)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
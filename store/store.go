@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	"github.com/ragsagar/wolff/model"
 )
 
@@ -9,6 +11,13 @@ type Store interface {
 	User() UserStore
 	AuthToken() AuthTokenStore
 	Expense() ExpenseStore
+	ExchangeRate() ExchangeRateStore
+	Report() ReportStore
+	Recurring() RecurringExpenseStore
+	Budget() BudgetStore
+	Transfer() TransferStore
+	Tag() TagStore
+	Rule() RuleStore
 }
 
 // UserStore : Interface for User store.
@@ -17,21 +26,119 @@ type UserStore interface {
 	GetUserByEmail(email string) (*model.User, error)
 	StoreUser(user model.User) error
 	UpdateUser(user model.User) error
+	StorePasswordReset(reset *model.PasswordReset) error
+	ResetPassword(token string, passwordHash string, now time.Time) (*model.User, error)
 }
 
 // AuthTokenStore is an interface for AuthToken implementations.
 type AuthTokenStore interface {
-	Create(user *model.User) (*model.AuthToken, error)
+	Create(user *model.User, device model.Device) (*model.AuthToken, error)
 	Find(token string) (*model.AuthToken, error)
+	Refresh(key string, now time.Time) (*model.AuthToken, error)
+	Touch(authToken *model.AuthToken, now time.Time) error
+	GetSessions(userID string) ([]model.AuthToken, error)
+	GetSessionByID(id string) (*model.AuthToken, error)
+	Revoke(authToken *model.AuthToken) error
+	RevokeAllForUser(userID string) ([]string, error)
+	RevokeOthersForUser(userID, sessionID string) ([]string, error)
 }
 
 // ExpenseStore is the interface that defines methods expected in ExpenseStore implemntations
 type ExpenseStore interface {
 	Store(expense *model.Expense) error
 	GetByID(id string) (*model.Expense, error)
+	UpdateExpense(expense *model.Expense) error
+	DeleteExpense(expense *model.Expense) error
 	GetExpenses(userId string, filter ExpenseFilter) ([]model.Expense, error)
+	CountExpenses(userId string, filter ExpenseFilter) (int, error)
+	StoreExpenses(expenses []model.Expense) error
+	UpdateExpenses(expenses []model.Expense) error
+	GetAllExpenses(userId string, filter ExpenseFilter) ([]model.Expense, error)
+	GetAccountExpenses(accountID string, from, to time.Time) ([]model.Expense, error)
+	ExportExpenses(userId string, filter ExpenseFilter, fn func(*model.ExpenseExportRow) error) error
 	StoreAccount(expense model.ExpenseAccount) error
-	GetExpenseAccounts(userId string) ([]model.ExpenseAccount, error)
+	GetExpenseAccounts(userId string, includeArchived bool) ([]model.ExpenseAccount, error)
 	GetAccountByID(id string) (*model.ExpenseAccount, error)
-	DeleteAccount(*model.ExpenseAccount) error
+	UpdateAccount(account *model.ExpenseAccount) error
+	DeleteAccount(account *model.ExpenseAccount, moveTo *model.ExpenseAccount) error
+	AccountBalance(account *model.ExpenseAccount, day time.Time) (*model.AccountBalance, error)
+	GetAccountBalances(userId string, day time.Time) ([]model.AccountBalance, error)
+	ReconcileAccount(account *model.ExpenseAccount, day time.Time) (int, error)
+	StoreCategory(category *model.ExpenseCategory) error
+	GetExpenseCategories(userId string) ([]model.ExpenseCategory, error)
+	GetCategoryByID(id string) (*model.ExpenseCategory, error)
+	UpdateCategory(category *model.ExpenseCategory) error
+	DeleteCategory(category *model.ExpenseCategory) error
+	StoreAttachment(attachment *model.Attachment) error
+	GetAttachments(expenseID string) ([]model.Attachment, error)
+	GetAttachmentByID(id string) (*model.Attachment, error)
+	DeleteAttachment(attachment *model.Attachment) error
+	AttachmentsSize(userId string) (int64, error)
+	GetDuplicateCandidates(userId string) ([]model.Expense, error)
+	GetPossibleDuplicates(expense *model.Expense) ([]model.Expense, error)
+	GetDuplicateDismissals(userId string) ([]model.DuplicateDismissal, error)
+	DismissDuplicates(dismissals []model.DuplicateDismissal) error
+	MergeExpenses(keep *model.Expense, duplicates []model.Expense) error
+}
+
+// ExchangeRateStore is the interface for keeping currency exchange rates.
+type ExchangeRateStore interface {
+	StoreRates(rates []model.ExchangeRate) error
+	FindRate(from, to string, date time.Time) (*model.ExchangeRate, error)
+}
+
+// ReportStore is the interface for aggregated reports of user's expenses and income.
+type ReportStore interface {
+	TotalsByCategory(userId string, filter ReportFilter) ([]model.ReportEntry, error)
+	TotalsByAccount(userId string, filter ReportFilter) ([]model.ReportEntry, error)
+	TotalsByPeriod(userId string, period string, filter ReportFilter) ([]model.ReportEntry, error)
+	CashFlowByPeriod(userId string, period string, filter ReportFilter) ([]model.CashFlowEntry, error)
+}
+
+// RecurringExpenseStore is the interface for recurring expense templates and
+// creating the expenses when they are due.
+type RecurringExpenseStore interface {
+	StoreRecurring(recurring *model.RecurringExpense) error
+	GetRecurringExpenses(userId string) ([]model.RecurringExpense, error)
+	GetRecurringByID(id string) (*model.RecurringExpense, error)
+	UpdateRecurring(recurring *model.RecurringExpense) error
+	DeleteRecurring(recurring *model.RecurringExpense) error
+	CreateDueExpenses(today time.Time) (int, error)
+}
+
+// BudgetStore is the interface for monthly budgets of categories and accounts.
+type BudgetStore interface {
+	StoreBudget(budget *model.Budget) error
+	GetBudgets(userId string) ([]model.Budget, error)
+	GetBudgetByID(id string) (*model.Budget, error)
+	UpdateBudget(budget *model.Budget) error
+	DeleteBudget(budget *model.Budget) error
+}
+
+// TransferStore is the interface for transfers between the accounts of a user.
+type TransferStore interface {
+	StoreTransfer(transfer *model.Transfer) error
+	GetTransfers(userId string) ([]model.Transfer, error)
+	GetTransferByID(id string) (*model.Transfer, error)
+	UpdateTransfer(transfer *model.Transfer) error
+	DeleteTransfer(transfer *model.Transfer) error
+}
+
+// TagStore is the interface for the tags of expenses.
+type TagStore interface {
+	GetTags(userId string) ([]model.Tag, error)
+	GetTagByID(id string) (*model.Tag, error)
+	GetTagByName(userId string, name string) (*model.Tag, error)
+	UpdateTag(tag *model.Tag) error
+	MergeTags(tag *model.Tag, into *model.Tag) error
+	DeleteTag(tag *model.Tag) error
+}
+
+// RuleStore is the interface for the rules categorising the expenses.
+type RuleStore interface {
+	StoreRule(rule *model.Rule) error
+	GetRules(userId string) ([]model.Rule, error)
+	GetRuleByID(id string) (*model.Rule, error)
+	UpdateRule(rule *model.Rule) error
+	DeleteRule(rule *model.Rule) error
 }
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"log"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+)
+
+// TagSQLStore is the SQL implementation of TagStore interface.
+type TagSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewTagSQLStore returns new TagSQLStore object.
+func NewTagSQLStore(sqlStore SQLStore) *TagSQLStore {
+	return &TagSQLStore{sqlStore: &sqlStore}
+}
+
+// GetTags returns all the tags of the given user ordered by name.
+func (ts TagSQLStore) GetTags(userId string) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := ts.sqlStore.db.Model(&tags).Where("tag.user_id = ?", userId).Order("tag.name ASC").Select()
+	if err != nil {
+		log.Println("Error in fetching tags for user_id ", userId)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetTagByID fetches the Tag object with given id.
+func (ts TagSQLStore) GetTagByID(id string) (*model.Tag, error) {
+	tag := new(model.Tag)
+	err := ts.sqlStore.db.Model(tag).Where("tag.id = ?", id).Select()
+	if err != nil {
+		log.Println("Error in fetching tag with id ", id)
+		return nil, err
+	}
+	return tag, nil
+}
+
+// GetTagByName fetches the tag of the user with the given name.
+func (ts TagSQLStore) GetTagByName(userId string, name string) (*model.Tag, error) {
+	tag := new(model.Tag)
+	err := ts.sqlStore.db.Model(tag).Where("tag.user_id = ?", userId).Where("tag.name = ?", name).Select()
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// UpdateTag saves the changes in the given tag after refreshing UpdatedAt field.
+func (ts TagSQLStore) UpdateTag(tag *model.Tag) error {
+	tag.PreUpdate()
+	return ts.sqlStore.db.Update(tag)
+}
+
+// MergeTags moves the expenses of tag to the tag into and removes tag.
+func (ts TagSQLStore) MergeTags(tag *model.Tag, into *model.Tag) error {
+	return ts.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Exec(`INSERT INTO expense_tags (expense_id, tag_id)
+			SELECT expense_id, ? FROM expense_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, into.ID, tag.ID)
+		if err != nil {
+			return err
+		}
+		return deleteTag(tx, tag)
+	})
+}
+
+// DeleteTag removes the given tag from all the expenses and deletes it.
+func (ts TagSQLStore) DeleteTag(tag *model.Tag) error {
+	return ts.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		return deleteTag(tx, tag)
+	})
+}
+
+func deleteTag(tx *pg.Tx, tag *model.Tag) error {
+	_, err := tx.Model((*model.ExpenseTag)(nil)).Where("tag_id = ?", tag.ID).Delete()
+	if err != nil {
+		return err
+	}
+	return tx.Delete(tag)
+}
+
+// saveExpenseTags replaces the tags of the expense with expense.Tags. Tags
+// without an ID are looked up by their name and created if the user doesn't
+// have them yet.
+func saveExpenseTags(tx *pg.Tx, expense *model.Expense) error {
+	_, err := tx.Model((*model.ExpenseTag)(nil)).Where("expense_id = ?", expense.ID).Delete()
+	if err != nil {
+		return err
+	}
+	saved := make(map[string]bool)
+	for i := range expense.Tags {
+		tag := &expense.Tags[i]
+		if tag.ID == "" {
+			tag.UserID = expense.UserID
+			tag.PreSave()
+			_, err := tx.Model(tag).
+				Where("user_id = ?user_id").
+				Where("name = ?name").
+				SelectOrInsert()
+			if err != nil {
+				return err
+			}
+		}
+		if saved[tag.ID] {
+			continue
+		}
+		saved[tag.ID] = true
+		if err := tx.Insert(&model.ExpenseTag{ExpenseID: expense.ID, TagID: tag.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TagSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *TagSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite TagSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	queries := []string{
+		`DROP TABLE IF EXISTS tags`,
+		`DROP TABLE IF EXISTS expense_tags`,
+	}
+	for _, query := range queries {
+		_, err := s.db.Query(query)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *TagSQLStoreSuite) SetupTest() {
+	queries := []string{
+		`TRUNCATE expenses`,
+		`TRUNCATE tags`,
+		`TRUNCATE expense_tags`,
+	}
+	for _, query := range queries {
+		_, err := s.db.Query(query)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+}
+
+func TestTagSQLStoreSuite(t *testing.T) {
+	s := new(TagSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *TagSQLStoreSuite) TestExpenseTags() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	date := time.Date(2019, 1, 15, 0, 0, 0, 0, time.UTC)
+	lunch := &model.Expense{AccountID: "1234", Amount: model.NewMoney(1000, ""), Title: "Lunch", Date: date, UserID: userID,
+		Tags: []model.Tag{{Name: "reimbursable"}, {Name: "berlin"}}}
+	taxi := &model.Expense{AccountID: "1234", Amount: model.NewMoney(2000, ""), Title: "Taxi", Date: date, UserID: userID,
+		Tags: []model.Tag{{Name: "berlin"}}}
+	for _, expense := range []*model.Expense{lunch, taxi} {
+		if err := s.store.Expense().Store(expense); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+	assert.Equal(s.T(), lunch.Tags[1].ID, taxi.Tags[0].ID, "Tag with the same name is created again.")
+
+	tags, err := s.store.Tag().GetTags(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), 2, len(tags))
+
+	filter := ExpenseFilter{}
+	filter.ParseURLValues(url.Values{"tag": {"berlin", "reimbursable"}, "all_time": {"1"}})
+	expenses, err := s.store.Expense().GetExpenses(userID, filter)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 1, len(expenses)) {
+		assert.Equal(s.T(), lunch.ID, expenses[0].ID)
+		assert.Equal(s.T(), 2, len(expenses[0].Tags))
+	}
+
+	// Merging reimbursable into berlin leaves a single tag on lunch.
+	reimbursable, err := s.store.Tag().GetTagByName(userID, "reimbursable")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	berlin, err := s.store.Tag().GetTagByName(userID, "berlin")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if err := s.store.Tag().MergeTags(reimbursable, berlin); err != nil {
+		s.T().Fatal(err)
+	}
+	fetched, err := s.store.Expense().GetByID(lunch.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 1, len(fetched.Tags)) {
+		assert.Equal(s.T(), "berlin", fetched.Tags[0].Name)
+	}
+
+	fetched.Tags = nil
+	if err := s.store.Expense().UpdateExpense(fetched); err != nil {
+		s.T().Fatal(err)
+	}
+	fetched, err = s.store.Expense().GetByID(lunch.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Empty(s.T(), fetched.Tags)
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"log"
+
+	"github.com/ragsagar/wolff/model"
+)
+
+// TransferSQLStore is the SQL implementation of TransferStore interface.
+type TransferSQLStore struct {
+	sqlStore *SQLStore
+}
+
+// NewTransferSQLStore returns new TransferSQLStore object.
+func NewTransferSQLStore(sqlStore SQLStore) *TransferSQLStore {
+	return &TransferSQLStore{sqlStore: &sqlStore}
+}
+
+// StoreTransfer saves the given transfer after populating ID, CreatedAt and UpdatedAt fields.
+func (ts TransferSQLStore) StoreTransfer(transfer *model.Transfer) error {
+	transfer.PreSave()
+	return ts.sqlStore.db.Insert(transfer)
+}
+
+// GetTransfers returns all the transfers of the given user with their accounts, latest first.
+func (ts TransferSQLStore) GetTransfers(userId string) ([]model.Transfer, error) {
+	var transfers []model.Transfer
+	err := ts.sqlStore.db.Model(&transfers).
+		Relation("FromAccount").
+		Relation("ToAccount").
+		Where("transfer.user_id = ?", userId).
+		Order("transfer.date DESC", "transfer.id ASC").
+		Select()
+	if err != nil {
+		log.Println("Error in fetching transfers for user_id ", userId)
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// GetTransferByID fetches the Transfer object with given id.
+func (ts TransferSQLStore) GetTransferByID(id string) (*model.Transfer, error) {
+	transfer := new(model.Transfer)
+	err := ts.sqlStore.db.Model(transfer).
+		Relation("FromAccount").
+		Relation("ToAccount").
+		Where("transfer.id = ?", id).
+		Select()
+	if err != nil {
+		log.Println("Error in fetching transfer with id ", id)
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// UpdateTransfer saves the changes in the given transfer after refreshing UpdatedAt field.
+func (ts TransferSQLStore) UpdateTransfer(transfer *model.Transfer) error {
+	transfer.PreUpdate()
+	return ts.sqlStore.db.Update(transfer)
+}
+
+// DeleteTransfer removes the given transfer.
+func (ts TransferSQLStore) DeleteTransfer(transfer *model.Transfer) error {
+	return ts.sqlStore.db.Delete(transfer)
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TransferSQLStoreSuite struct {
+	suite.Suite
+	store SQLStore
+	db    *sql.DB
+}
+
+func (s *TransferSQLStoreSuite) SetupSuite() {
+	s.T().Log("SetupSuite TransferSQLStore running")
+	dbname := "wolffdb_test"
+	user := "wolffuser"
+	password := "password"
+	connString := fmt.Sprintf("host=localhost port=5432 user=%s "+
+		"password=%s dbname=%s sslmode=disable", user, password, dbname)
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.db = db
+	if _, err := s.db.Query(`DROP TABLE IF EXISTS transfers`); err != nil {
+		s.T().Fatal(err)
+	}
+	s.store = NewSQLStore(user, password, dbname, "localhost:5432")
+}
+
+func (s *TransferSQLStoreSuite) SetupTest() {
+	queries := []string{
+		`TRUNCATE transfers`,
+		`TRUNCATE expense_accounts`,
+	}
+	for _, query := range queries {
+		if _, err := s.db.Query(query); err != nil {
+			s.T().Fatal(err)
+		}
+	}
+
+	testAccounts := []struct {
+		name   string
+		id     string
+		userID string
+	}{
+		{"Bank", "1234", "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
+		{"Wallet", "2234", "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"},
+		{"Bank", "3234", "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"},
+		{"Wallet", "4234", "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"},
+	}
+	for _, ta := range testAccounts {
+		now := time.Now()
+		_, err := s.db.Query("INSERT INTO expense_accounts (id, name, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)", ta.id, ta.name, ta.userID, now)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+}
+
+func TestTransferSQLStoreSuite(t *testing.T) {
+	s := new(TransferSQLStoreSuite)
+	suite.Run(t, s)
+}
+
+func (s *TransferSQLStoreSuite) TestTransfers() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	otherUserID := "6d6e34c8-56b7-11e6-ba7c-cafec0ffee00"
+	day := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	older := &model.Transfer{FromAccountID: "1234", ToAccountID: "2234", Amount: model.NewMoney(5000, ""), ToAmount: model.NewMoney(5000, ""), Date: day, Title: "Cash", UserID: userID}
+	newer := &model.Transfer{FromAccountID: "2234", ToAccountID: "1234", Amount: model.NewMoney(2000, ""), ToAmount: model.NewMoney(2000, ""), Date: day.AddDate(0, 0, 1), Title: "Deposit", UserID: userID}
+	other := &model.Transfer{FromAccountID: "3234", ToAccountID: "4234", Amount: model.NewMoney(1000, ""), ToAmount: model.NewMoney(1000, ""), Date: day, UserID: otherUserID}
+	for _, transfer := range []*model.Transfer{older, newer, other} {
+		if err := s.store.Transfer().StoreTransfer(transfer); err != nil {
+			s.T().Fatal(err)
+		}
+		assert.NotEmpty(s.T(), transfer.ID, "Transfer id is empty.")
+	}
+
+	// Only the transfers of the user are returned, latest first.
+	transfers, err := s.store.Transfer().GetTransfers(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 2, len(transfers)) {
+		assert.Equal(s.T(), newer.ID, transfers[0].ID)
+		assert.Equal(s.T(), older.ID, transfers[1].ID)
+		if assert.NotNil(s.T(), transfers[1].FromAccount) && assert.NotNil(s.T(), transfers[1].ToAccount) {
+			assert.Equal(s.T(), "Bank", transfers[1].FromAccount.Name)
+			assert.Equal(s.T(), "Wallet", transfers[1].ToAccount.Name)
+		}
+		assert.Equal(s.T(), "50.00", transfers[1].Amount.String())
+	}
+	transfers, err = s.store.Transfer().GetTransfers(otherUserID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if assert.Equal(s.T(), 1, len(transfers)) {
+		assert.Equal(s.T(), other.ID, transfers[0].ID)
+	}
+
+	older.Title = "ATM"
+	older.Amount = model.NewMoney(6000, "")
+	older.ToAmount = model.NewMoney(6000, "")
+	if err := s.store.Transfer().UpdateTransfer(older); err != nil {
+		s.T().Fatal(err)
+	}
+	transfer, err := s.store.Transfer().GetTransferByID(older.ID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	assert.Equal(s.T(), "ATM", transfer.Title)
+	assert.Equal(s.T(), "60.00", transfer.Amount.String())
+	assert.Equal(s.T(), userID, transfer.UserID)
+
+	if err := s.store.Transfer().DeleteTransfer(transfer); err != nil {
+		s.T().Fatal(err)
+	}
+	_, err = s.store.Transfer().GetTransferByID(older.ID)
+	assert.Equal(s.T(), pg.ErrNoRows, err)
+	// Transfers of the other user are left alone.
+	if _, err := s.store.Transfer().GetTransferByID(other.ID); err != nil {
+		s.T().Fatal(err)
+	}
+}
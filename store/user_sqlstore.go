@@ -1,6 +1,16 @@
 package store
 
-import "github.com/ragsagar/wolff/model"
+import (
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/ragsagar/wolff/model"
+)
+
+// ErrInvalidResetToken is returned when using a password reset token which
+// doesn't exist, is expired or was already used.
+var ErrInvalidResetToken = errors.New("invalid password reset token")
 
 // UserSQLStore : SQL implementation for UserStore interface.
 type UserSQLStore struct {
@@ -43,3 +53,48 @@ func (uss UserSQLStore) UpdateUser(user model.User) error {
 	err := uss.sqlStore.db.Update(&user)
 	return err
 }
+
+// StorePasswordReset saves the given password reset.
+func (uss UserSQLStore) StorePasswordReset(reset *model.PasswordReset) error {
+	return uss.sqlStore.db.Insert(reset)
+}
+
+// ResetPassword uses the password reset with the given token to set the
+// hashed password of its user and returns the user. The other password
+// resets of the user are marked as used along with it, so none of them can
+// be used afterwards. ErrInvalidResetToken is returned if it can't be used.
+func (uss UserSQLStore) ResetPassword(token string, passwordHash string, now time.Time) (*model.User, error) {
+	user := new(model.User)
+	err := uss.sqlStore.db.RunInTransaction(func(tx *pg.Tx) error {
+		reset := new(model.PasswordReset)
+		_, err := tx.Model(reset).
+			Set("used_at = ?", now).
+			Where("token_hash = ?", model.HashResetToken(token)).
+			Where("used_at IS NULL AND expires_at > ?", now).
+			Returning("*").
+			Update()
+		if err == pg.ErrNoRows {
+			return ErrInvalidResetToken
+		} else if err != nil {
+			return err
+		}
+		_, err = tx.Model(user).
+			Set("password = ?", passwordHash).
+			Set("updated_at = ?", now).
+			Where("id = ?", reset.UserID).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model((*model.PasswordReset)(nil)).
+			Set("used_at = ?", now).
+			Where("user_id = ? AND used_at IS NULL", reset.UserID).
+			Update()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -207,3 +207,50 @@ func (s *UserSQLStoreSuite) TestUpdateUser() {
 		s.T().Errorf("Updating user failed.")
 	}
 }
+
+func (s *UserSQLStoreSuite) TestPasswordReset() {
+	userID := "5d6e34c8-46b7-11e6-ba7c-cafec0ffee00"
+	now := time.Now()
+	reset, token := model.NewPasswordReset(userID, now)
+	if err := s.store.User().StorePasswordReset(reset); err != nil {
+		s.T().Fatal(err)
+	}
+	other, otherToken := model.NewPasswordReset(userID, now)
+	if err := s.store.User().StorePasswordReset(other); err != nil {
+		s.T().Fatal(err)
+	}
+	expired, expiredToken := model.NewPasswordReset(userID, now.Add(-2*model.PasswordResetLifetime))
+	if err := s.store.User().StorePasswordReset(expired); err != nil {
+		s.T().Fatal(err)
+	}
+
+	passwordHash, _ := model.HashPassword("new password")
+	user, err := s.store.User().ResetPassword(token, passwordHash, now)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if user.ID != userID || !model.CheckPasswordHash("new password", user.Password) {
+		s.T().Errorf("Password of the user is not reset. %+v", user)
+	}
+	saved, err := s.store.User().GetUserByID(userID)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if saved.Password != passwordHash {
+		s.T().Errorf("Reset password is not saved.")
+	}
+	// Tokens can be used only once and the other tokens of the user are
+	// used along with it.
+	if _, err := s.store.User().ResetPassword(token, passwordHash, now); err != ErrInvalidResetToken {
+		s.T().Errorf("Expected ErrInvalidResetToken for used token, got %v", err)
+	}
+	if _, err := s.store.User().ResetPassword(otherToken, passwordHash, now); err != ErrInvalidResetToken {
+		s.T().Errorf("Expected ErrInvalidResetToken for other token of the user, got %v", err)
+	}
+	if _, err := s.store.User().ResetPassword(expiredToken, passwordHash, now); err != ErrInvalidResetToken {
+		s.T().Errorf("Expected ErrInvalidResetToken for expired token, got %v", err)
+	}
+	if _, err := s.store.User().ResetPassword("invalid", passwordHash, now); err != ErrInvalidResetToken {
+		s.T().Errorf("Expected ErrInvalidResetToken for invalid token, got %v", err)
+	}
+}
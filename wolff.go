@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/ragsagar/wolff/jwt"
+	"github.com/ragsagar/wolff/mail"
+	"github.com/ragsagar/wolff/model"
 	"github.com/ragsagar/wolff/server"
 	"github.com/ragsagar/wolff/store"
 	"github.com/spf13/viper"
@@ -25,6 +29,84 @@ func main() {
 		log.Fatalln("Fatal error reading config file", err)
 	}
 	dataStore := store.NewSQLStore(viper.GetString("DB_NAME"), viper.GetString("DB_PASSWORD"), viper.GetString("DB_USER"), viper.GetString("DB_SERVER"))
+	if ratesFile := viper.GetString("EXCHANGE_RATES_FILE"); ratesFile != "" {
+		loadExchangeRates(dataStore, ratesFile)
+	}
 	srv := server.NewServer(dataStore)
+	attachmentsDir := viper.GetString("ATTACHMENTS_DIR")
+	if attachmentsDir == "" {
+		attachmentsDir = "attachments"
+	}
+	if srv.Attachments, err = store.NewLocalAttachmentStore(attachmentsDir); err != nil {
+		log.Fatalln("Error creating attachments directory", err)
+	}
+	if quota := viper.GetInt64("ATTACHMENT_QUOTA"); quota > 0 {
+		srv.AttachmentQuota = quota
+	}
+	// JWT access tokens are used when a key is chosen. Older keys can be
+	// kept in JWT_KEYS until the tokens signed with them expire. Viper
+	// lowercases the key ids in JWT_KEYS.
+	if keyID := viper.GetString("JWT_KEY_ID"); keyID != "" {
+		if srv.JWT, err = jwt.NewSigner(viper.GetStringMapString("JWT_KEYS"), strings.ToLower(keyID)); err != nil {
+			log.Fatalln("Error in JWT configuration", err)
+		}
+	}
+	srv.Mailer = newMailer()
+	srv.PasswordResetURL = viper.GetString("PASSWORD_RESET_URL")
 	srv.Run(fmt.Sprintf(":%s", viper.GetString("APP_SERVER_PORT")))
 }
+
+// newMailer returns the mailer chosen by MAIL_BACKEND: "smtp" sends through
+// SMTP_ADDR, "file" writes the emails to MAIL_DIR and "log" writes them to
+// the log. The file and log backends expose the password reset tokens and
+// are meant only for development. Password reset is disabled when no
+// backend is chosen.
+func newMailer() mail.Mailer {
+	from := viper.GetString("MAIL_FROM")
+	switch backend := viper.GetString("MAIL_BACKEND"); backend {
+	case "":
+		return nil
+	case "smtp":
+		addr := viper.GetString("SMTP_ADDR")
+		if addr == "" {
+			log.Fatalln("SMTP_ADDR is required for the smtp mail backend")
+		}
+		return mail.SMTPMailer{
+			Addr:     addr,
+			Username: viper.GetString("SMTP_USERNAME"),
+			Password: viper.GetString("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "file":
+		dir := viper.GetString("MAIL_DIR")
+		if dir == "" {
+			log.Fatalln("MAIL_DIR is required for the file mail backend")
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			log.Fatalln("Error creating mail directory", err)
+		}
+		return mail.FileMailer{Dir: dir, From: from}
+	case "log":
+		return mail.LogMailer{From: from}
+	default:
+		log.Fatalln("Unknown mail backend", backend)
+		return nil
+	}
+}
+
+// loadExchangeRates reads the exchange rates from the given csv file and saves them in the store.
+func loadExchangeRates(dataStore store.Store, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalln("Error opening exchange rates file", err)
+	}
+	defer file.Close()
+	rates, err := model.ParseExchangeRatesCSV(file)
+	if err != nil {
+		log.Fatalln("Error parsing exchange rates file", err)
+	}
+	if err := dataStore.ExchangeRate().StoreRates(rates); err != nil {
+		log.Fatalln("Error saving exchange rates", err)
+	}
+	log.Println("Loaded", len(rates), "exchange rates from", path)
+}